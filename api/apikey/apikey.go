@@ -0,0 +1,199 @@
+// Package apikey defines the API key format and how keys are stored. It is
+// shared by the API, which verifies keys, and the CLI, which issues them.
+//
+// A key looks like wpk_live_<id>_<secret>_<checksum>. The id is public and
+// only used to find the stored record. The secret is 40 random base62
+// characters and is never stored. Instead each record keeps a random salt and
+// HMAC-SHA256(pepper, salt || secret), where the pepper is a server-side
+// secret kept outside the database. Secrets carry about 238 bits of entropy,
+// so a fast keyed hash is enough: a leaked users collection cannot be turned
+// back into usable keys without the pepper.
+//
+// The checksum is a CRC32 of everything before it, so a mistyped key can be
+// rejected without a database lookup and secret scanners can tell real keys
+// from lookalikes offline.
+package apikey
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"strings"
+)
+
+const (
+	// Prefix starts every key issued for production use
+	Prefix = "wpk_live_"
+	// Pattern matches keys for secret scanners
+	Pattern = `wpk_live_[0-9a-f]{16}_[0-9A-Za-z]{40}_[0-9A-Za-z]{6}`
+
+	idLength       = 16
+	secretLength   = 40
+	checksumLength = 6
+	base62         = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+var (
+	// ErrMalformed is returned for strings that are not keys of this format
+	ErrMalformed = errors.New("malformed API key")
+	// ErrChecksum is returned for well-formed keys whose checksum does not
+	// match, which usually means a typo or a truncated copy
+	ErrChecksum = errors.New("API key checksum mismatch")
+	// ErrWeakPepper is returned when the configured pepper is too short
+	ErrWeakPepper = errors.New("API key pepper must be at least 32 characters")
+)
+
+// Key is a parsed API key
+type Key struct {
+	ID     string
+	Secret string
+}
+
+// Generate returns a new key with a random id and secret
+func Generate() Key {
+	return Key{ID: NewID(), Secret: randomBase62(secretLength)}
+}
+
+// String formats the key as handed out to clients
+func (k Key) String() string {
+	body := Prefix + k.ID + "_" + k.Secret
+	return body + "_" + checksum(body)
+}
+
+// checksum encodes the CRC32 of body as six base62 characters
+func checksum(body string) string {
+	sum := crc32.ChecksumIEEE([]byte(body))
+	out := make([]byte, checksumLength)
+	for i := checksumLength - 1; i >= 0; i-- {
+		out[i] = base62[sum%62]
+		sum /= 62
+	}
+	return string(out)
+}
+
+// Validate checks a key offline: its shape, alphabet and checksum. It is used
+// by the CLI after issuing a key and by the API before any database lookup.
+func Validate(s string) error {
+	_, err := Parse(s)
+	return err
+}
+
+// Parse validates a key and splits it into its id and secret
+func Parse(s string) (Key, error) {
+	rest, ok := strings.CutPrefix(s, Prefix)
+	if !ok {
+		return Key{}, ErrMalformed
+	}
+	parts := strings.Split(rest, "_")
+	if len(parts) != 3 {
+		return Key{}, ErrMalformed
+	}
+	id, secret, sum := parts[0], parts[1], parts[2]
+	if len(id) != idLength || !isHex(id) ||
+		len(secret) != secretLength || !isBase62(secret) ||
+		len(sum) != checksumLength || !isBase62(sum) {
+		return Key{}, ErrMalformed
+	}
+
+	key := Key{ID: id, Secret: secret}
+	if checksum(Prefix+id+"_"+secret) != sum {
+		return Key{}, ErrChecksum
+	}
+	return key, nil
+}
+
+// IsLegacy reports whether s has the form of a key issued before this format:
+// a bare SHA-256 hex digest
+func IsLegacy(s string) bool {
+	return len(s) == sha256.Size*2 && isHex(s)
+}
+
+// LegacyID is the lookup id given to a migrated legacy key. Legacy keys have
+// no public part, so the id is derived from the key itself.
+func LegacyID(s string) string {
+	sum := sha256.Sum256([]byte("legacy:" + s))
+	return "l" + hex.EncodeToString(sum[:8])
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isBase62(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune(base62, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewID returns a random public key id
+func NewID() string {
+	return hex.EncodeToString(random(idLength / 2))
+}
+
+// NewSalt returns a random per-key salt
+func NewSalt() []byte {
+	return random(16)
+}
+
+func random(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// randomBase62 returns n uniformly random base62 characters. Bytes of 248 and
+// above are rejected so every character is equally likely.
+func randomBase62(n int) string {
+	out := make([]byte, 0, n)
+	for len(out) < n {
+		for _, b := range random(n) {
+			if b < 248 && len(out) < n {
+				out = append(out, base62[b%62])
+			}
+		}
+	}
+	return string(out)
+}
+
+// Hasher hashes key secrets with the server-side pepper
+type Hasher struct {
+	pepper []byte
+}
+
+// NewHasher creates a hasher for pepper, typically the API_KEY_PEPPER setting.
+// The CLI and the API must use the same pepper.
+func NewHasher(pepper string) (*Hasher, error) {
+	if len(pepper) < 32 {
+		return nil, ErrWeakPepper
+	}
+	return &Hasher{pepper: []byte(pepper)}, nil
+}
+
+// Hash returns the stored form of secret
+func (h *Hasher) Hash(salt []byte, secret string) []byte {
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write(salt)
+	mac.Write([]byte(secret))
+	return mac.Sum(nil)
+}
+
+// Verify reports in constant time whether secret matches a stored hash
+func (h *Hasher) Verify(salt, hash []byte, secret string) bool {
+	return hmac.Equal(h.Hash(salt, secret), hash)
+}
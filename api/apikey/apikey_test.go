@@ -0,0 +1,142 @@
+package apikey
+
+import (
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const (
+	testID     = "0123456789abcdef"
+	testSecret = "abcdefghijABCDEFGHIJ0123456789klmnopqrst"
+	// testKey is testID and testSecret with the CRC32 checksum computed
+	// independently of this package
+	testKey    = "wpk_live_0123456789abcdef_abcdefghijABCDEFGHIJ0123456789klmnopqrst_13n38A"
+	testPepper = "0123456789abcdef0123456789abcdef"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		err  error
+	}{
+		{"valid", testKey, nil},
+		{"empty", "", ErrMalformed},
+		{"test prefix", strings.Replace(testKey, "wpk_live_", "wpk_test_", 1), ErrMalformed},
+		{"no prefix", strings.TrimPrefix(testKey, Prefix), ErrMalformed},
+		{"legacy key", strings.Repeat("ab", 32), ErrMalformed},
+		{"missing checksum", Prefix + testID + "_" + testSecret, ErrMalformed},
+		{"extra part", testKey + "_x", ErrMalformed},
+		{"short id", Prefix + testID[1:] + "_" + testSecret + "_13n38A", ErrMalformed},
+		{"long id", Prefix + testID + "0_" + testSecret + "_13n38A", ErrMalformed},
+		{"uppercase id", Prefix + strings.ToUpper(testID) + "_" + testSecret + "_13n38A", ErrMalformed},
+		{"short secret", Prefix + testID + "_" + testSecret[1:] + "_13n38A", ErrMalformed},
+		{"long secret", Prefix + testID + "_" + testSecret + "x_13n38A", ErrMalformed},
+		{"short checksum", testKey[:len(testKey)-1], ErrMalformed},
+		{"long checksum", testKey + "A", ErrMalformed},
+		{"secret outside base62", Prefix + testID + "_" + testSecret[:39] + "-_13n38A", ErrMalformed},
+		{"secret with non-ASCII", Prefix + testID + "_" + testSecret[:38] + "é_13n38A", ErrMalformed},
+		{"checksum outside base62", testKey[:len(testKey)-1] + "+", ErrMalformed},
+		{"wrong checksum", testKey[:len(testKey)-1] + "B", ErrChecksum},
+		{"mistyped secret", strings.Replace(testKey, "abcdefghij", "abcdefghik", 1), ErrChecksum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := Parse(tt.key)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Parse(%q) = %v, want %v", tt.key, err, tt.err)
+			}
+			if err := Validate(tt.key); !errors.Is(err, tt.err) {
+				t.Errorf("Validate(%q) = %v, want %v", tt.key, err, tt.err)
+			}
+			if tt.err == nil && (key.ID != testID || key.Secret != testSecret) {
+				t.Errorf("Parse(%q) = %+v", tt.key, key)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	pattern := regexp.MustCompile("^" + Pattern + "$")
+	seen := make(map[string]bool)
+	for range 100 {
+		key := Generate()
+		s := key.String()
+		if !pattern.MatchString(s) {
+			t.Fatalf("%q does not match Pattern", s)
+		}
+		parsed, err := Parse(s)
+		if err != nil || parsed != key {
+			t.Fatalf("Parse(%q) = %+v, %v, want %+v", s, parsed, err, key)
+		}
+		if seen[key.ID] {
+			t.Fatalf("id %s issued twice", key.ID)
+		}
+		seen[key.ID] = true
+	}
+	if got := (Key{ID: testID, Secret: testSecret}).String(); got != testKey {
+		t.Errorf("String() = %q, want %q", got, testKey)
+	}
+}
+
+func TestHasher(t *testing.T) {
+	if _, err := NewHasher(testPepper[:31]); !errors.Is(err, ErrWeakPepper) {
+		t.Errorf("NewHasher with 31 characters = %v, want ErrWeakPepper", err)
+	}
+	h, err := NewHasher(testPepper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewHasher("fedcba9876543210fedcba9876543210")
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt := []byte("saltsaltsaltsalt")
+
+	// Reference HMAC-SHA256(pepper, salt || secret) values; a change to them
+	// locks every issued key out
+	tests := []struct {
+		hasher *Hasher
+		want   string
+	}{
+		{h, "d40ab0afa1e07db573eda24b731c599792b3e0527ca1342478e50c64402eb1b0"},
+		{other, "77681bd44fa15c18233f92f463bc20032c2bbd5f05e4f5f1981ef5c8761c379d"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(tt.hasher.Hash(salt, testSecret)); got != tt.want {
+			t.Errorf("Hash = %s, want %s", got, tt.want)
+		}
+	}
+
+	hash := h.Hash(salt, testSecret)
+	if !h.Verify(salt, hash, testSecret) {
+		t.Error("Verify rejects the secret it hashed")
+	}
+	if h.Verify(salt, hash, testSecret[:39]+"u") {
+		t.Error("Verify accepts another secret")
+	}
+	if h.Verify([]byte("othersaltothersa"), hash, testSecret) {
+		t.Error("Verify accepts another salt")
+	}
+	if other.Verify(salt, hash, testSecret) {
+		t.Error("Verify accepts a hash made with another pepper")
+	}
+}
+
+func TestLegacy(t *testing.T) {
+	legacy := strings.Repeat("0f", 32)
+	if !IsLegacy(legacy) {
+		t.Errorf("IsLegacy(%q) = false", legacy)
+	}
+	for _, s := range []string{testKey, legacy[:63], strings.ToUpper(legacy), legacy + "0"} {
+		if IsLegacy(s) {
+			t.Errorf("IsLegacy(%q) = true", s)
+		}
+	}
+	if id := LegacyID(legacy); len(id) != 17 || id[0] != 'l' || id != LegacyID(legacy) || id == LegacyID(strings.Repeat("f0", 32)) {
+		t.Errorf("LegacyID(%q) = %q, want a stable l-prefixed id per key", legacy, id)
+	}
+}
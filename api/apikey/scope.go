@@ -0,0 +1,70 @@
+package apikey
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Scopes a key can be granted. Each route of the API requires one of them.
+const (
+	// ScopeSearchRead allows reading packages, publishers and changes in any
+	// form: REST, feeds, streams, GraphQL, gRPC and the winget source
+	ScopeSearchRead = "search:read"
+	// ScopeExportRead allows downloading catalog snapshots
+	ScopeExportRead = "export:read"
+	// ScopeWebhooksWrite allows managing webhooks
+	ScopeWebhooksWrite = "webhooks:write"
+	// ScopeAdmin allows managing the keys of every user
+	ScopeAdmin = "admin"
+)
+
+// AllScopes lists every scope a key can hold
+var AllScopes = []string{ScopeSearchRead, ScopeExportRead, ScopeWebhooksWrite, ScopeAdmin}
+
+// DefaultScopes are granted to keys created without explicit scopes, and to
+// keys issued before scopes existed. They allow everything but admin.
+var DefaultScopes = []string{ScopeSearchRead, ScopeExportRead, ScopeWebhooksWrite}
+
+// ErrUnknownScope is returned for scope names not in AllScopes
+var ErrUnknownScope = errors.New("unknown scope")
+
+// ParseScopes splits a comma separated scope list, e.g. from a CLI flag, and
+// rejects unknown names. An empty list yields DefaultScopes.
+func ParseScopes(list string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(list, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return CleanScopes(scopes)
+}
+
+// CleanScopes validates scopes and drops duplicates. An empty list yields
+// DefaultScopes.
+func CleanScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return append([]string(nil), DefaultScopes...), nil
+	}
+	clean := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !HasScope(AllScopes, scope) {
+			return nil, fmt.Errorf("%w %q", ErrUnknownScope, scope)
+		}
+		if !HasScope(clean, scope) {
+			clean = append(clean, scope)
+		}
+	}
+	return clean, nil
+}
+
+// HasScope reports whether scopes contains scope
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,442 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Key states stored in the status field. Expiry is not a stored state: an
+// active key whose expiresAt has passed is reported as StatusExpired.
+const (
+	StatusActive  = "active"
+	StatusRevoked = "revoked"
+	StatusExpired = "expired"
+)
+
+const (
+	// DefaultOverlap is how long a rotated key keeps working by default
+	DefaultOverlap = 24 * time.Hour
+	// DefaultKeyName names keys created without a name, and keys issued
+	// before keys had names
+	DefaultKeyName = "default"
+)
+
+var (
+	// ErrUserNotFound is returned for emails that match no user
+	ErrUserNotFound = errors.New("user not found")
+	// ErrKeyNotFound is returned for key names the user has no key for
+	ErrKeyNotFound = errors.New("the user has no key with this name")
+	// ErrNameTaken is returned when creating a key under a name in use
+	ErrNameTaken = errors.New("the user already has a key with this name")
+	// ErrConflict is returned when the user's keys changed while updating them
+	ErrConflict = errors.New("the keys were changed concurrently, try again")
+)
+
+// StoredKey is one key of a user. Only the hash of its secret is stored.
+type StoredKey struct {
+	ID        string     `bson:"id"`
+	Name      string     `bson:"name"`
+	Salt      []byte     `bson:"salt"`
+	Hash      []byte     `bson:"hash"`
+	Scopes    []string   `bson:"scopes"`
+	Tier      string     `bson:"tier,omitempty"`
+	Status    string     `bson:"status"`
+	CreatedAt time.Time  `bson:"createdAt"`
+	ExpiresAt *time.Time `bson:"expiresAt,omitempty"`
+	RevokedAt *time.Time `bson:"revokedAt,omitempty"`
+	// ReplacedBy is the id of the key that replaced this one in a rotation.
+	// A replaced key keeps working until its ExpiresAt, the overlap end.
+	ReplacedBy string `bson:"replacedBy,omitempty"`
+}
+
+// TierName returns the rate limit tier of the key; keys issued before tiers
+// existed are on TierFree
+func (k StoredKey) TierName() string {
+	if k.Tier == "" {
+		return TierFree
+	}
+	return k.Tier
+}
+
+// EffectiveStatus returns the status of the key at now
+func (k StoredKey) EffectiveStatus(now time.Time) string {
+	switch {
+	case k.Status == StatusRevoked:
+		return StatusRevoked
+	case k.ExpiresAt != nil && !now.Before(*k.ExpiresAt):
+		return StatusExpired
+	}
+	return StatusActive
+}
+
+// KeyInfo describes a key without anything secret, for listings
+type KeyInfo struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Tier       string     `json:"tier"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	ReplacedBy string     `json:"replacedBy,omitempty"`
+}
+
+// Info describes the key at now
+func (k StoredKey) Info(now time.Time) KeyInfo {
+	return KeyInfo{
+		ID:         k.ID,
+		Name:       k.Name,
+		Scopes:     k.Scopes,
+		Tier:       k.TierName(),
+		Status:     k.EffectiveStatus(now),
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		RevokedAt:  k.RevokedAt,
+		ReplacedBy: k.ReplacedBy,
+	}
+}
+
+// User is a users document with the keys issued to it. Each name has one
+// current key; keys replaced by a rotation stay until their overlap ends.
+type User struct {
+	ID    bson.ObjectID `bson:"_id,omitempty"`
+	Email string        `bson:"email"`
+	Keys  []StoredKey   `bson:"keys"`
+}
+
+// Key returns the key with this id
+func (u User) Key(id string) (StoredKey, bool) {
+	for _, k := range u.Keys {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return StoredKey{}, false
+}
+
+// current returns the index of the key currently issued under name, or -1
+func (u User) current(name string) int {
+	for i, k := range u.Keys {
+		if k.Name == name && k.ReplacedBy == "" {
+			return i
+		}
+	}
+	return -1
+}
+
+// KeyInfos lists the keys of the user at now
+func (u User) KeyInfos(now time.Time) []KeyInfo {
+	infos := make([]KeyInfo, 0, len(u.Keys))
+	for _, k := range u.Keys {
+		infos = append(infos, k.Info(now))
+	}
+	return infos
+}
+
+// Store issues, rotates and revokes keys in the users collection. The CLI and
+// the admin API both go through it.
+type Store struct {
+	users  *mongo.Collection
+	hasher *Hasher
+}
+
+// NewStore creates a key store over the users collection
+func NewStore(users *mongo.Collection, hasher *Hasher) *Store {
+	return &Store{users: users, hasher: hasher}
+}
+
+// Hasher returns the hasher keys are stored with
+func (s *Store) Hasher() *Hasher {
+	return s.hasher
+}
+
+// EnsureIndexes makes key ids and emails unique and quick to look up. Two
+// concurrent registrations of one email therefore cannot both create a user.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.users.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "keys.id", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	return err
+}
+
+// find loads the user matching filter
+func (s *Store) find(ctx context.Context, filter bson.M) (User, error) {
+	var u User
+	err := s.users.FindOne(ctx, filter).Decode(&u)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return User{}, ErrUserNotFound
+	}
+	return u, err
+}
+
+// ByKeyID returns the user holding the key with this id
+func (s *Store) ByKeyID(ctx context.Context, id string) (User, error) {
+	return s.find(ctx, bson.M{"keys.id": id})
+}
+
+// ByEmail returns the user registered with email
+func (s *Store) ByEmail(ctx context.Context, email string) (User, error) {
+	return s.find(ctx, bson.M{"email": email})
+}
+
+// retained returns the keys worth storing at now. Keys replaced by a rotation
+// are dropped once their overlap ends; revoked keys are kept for the record.
+func retained(keys []StoredKey, now time.Time) []StoredKey {
+	kept := make([]StoredKey, 0, len(keys))
+	for _, k := range keys {
+		if k.ReplacedBy == "" || k.EffectiveStatus(now) != StatusExpired {
+			kept = append(kept, k)
+		}
+	}
+	return kept
+}
+
+// save replaces the keys of u, failing with ErrConflict if they changed
+// since u was loaded
+func (s *Store) save(ctx context.Context, u User, keys []StoredKey) error {
+	kept := retained(keys, time.Now())
+	result, err := s.users.UpdateOne(ctx, bson.M{"_id": u.ID, "keys": u.Keys}, bson.M{"$set": bson.M{"keys": kept}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// KeyOptions describe a key to issue. An empty Name is DefaultKeyName, empty
+// Scopes are DefaultScopes and an empty Tier is TierFree.
+type KeyOptions struct {
+	Name      string
+	Scopes    []string
+	Tier      string
+	ExpiresAt *time.Time
+}
+
+// newKey issues a key and returns it with its stored form
+func (s *Store) newKey(opts KeyOptions, now time.Time) (Key, StoredKey) {
+	key := Generate()
+	salt := NewSalt()
+	return key, StoredKey{
+		ID:        key.ID,
+		Name:      opts.Name,
+		Salt:      salt,
+		Hash:      s.hasher.Hash(salt, key.Secret),
+		Scopes:    opts.Scopes,
+		Tier:      opts.Tier,
+		Status:    StatusActive,
+		CreatedAt: now,
+		ExpiresAt: opts.ExpiresAt,
+	}
+}
+
+// CreateKey issues a named key to the user with email, registering the user
+// if needed, and returns the key. It is the only time the key is available.
+func (s *Store) CreateKey(ctx context.Context, email string, opts KeyOptions) (Key, error) {
+	if opts.Name == "" {
+		opts.Name = DefaultKeyName
+	}
+	var err error
+	if opts.Scopes, err = CleanScopes(opts.Scopes); err != nil {
+		return Key{}, err
+	}
+	if opts.Tier, err = ParseTier(opts.Tier); err != nil {
+		return Key{}, err
+	}
+	now := time.Now().UTC()
+	key, stored := s.newKey(opts, now)
+
+	u, err := s.ByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		_, err = s.users.InsertOne(ctx, User{Email: email, Keys: []StoredKey{stored}})
+		return key, err
+	}
+	if err != nil {
+		return Key{}, err
+	}
+
+	keys, err := u.withKey(stored)
+	if err != nil {
+		return Key{}, err
+	}
+	if err := s.save(ctx, u, keys); err != nil {
+		return Key{}, err
+	}
+	return key, nil
+}
+
+// withKey returns the keys of u with stored added under its name. The name of
+// a revoked key can be reused; the revoked key is kept for the record.
+func (u User) withKey(stored StoredKey) ([]StoredKey, error) {
+	keys := append([]StoredKey(nil), u.Keys...)
+	if i := u.current(stored.Name); i >= 0 {
+		if keys[i].Status != StatusRevoked {
+			return nil, ErrNameTaken
+		}
+		keys[i].ReplacedBy = stored.ID
+	}
+	return append(keys, stored), nil
+}
+
+// Rotate issues a new key under name with the same scopes and tier. The replaced key
+// keeps working for overlap so clients can switch without downtime; a zero
+// overlap ends it now. Rotating a revoked key issues a working one.
+func (s *Store) Rotate(ctx context.Context, email, name string, overlap time.Duration, expiresAt *time.Time) (Key, User, error) {
+	if name == "" {
+		name = DefaultKeyName
+	}
+	u, err := s.ByEmail(ctx, email)
+	if err != nil {
+		return Key{}, User{}, err
+	}
+	i := u.current(name)
+	if i < 0 {
+		return Key{}, User{}, ErrKeyNotFound
+	}
+
+	now := time.Now().UTC()
+	keys := append([]StoredKey(nil), u.Keys...)
+	old := keys[i]
+	key, stored := s.newKey(KeyOptions{Name: name, Scopes: old.Scopes, Tier: old.Tier, ExpiresAt: expiresAt}, now)
+
+	// Only a usable key gets an overlap; it never outlives its own expiry
+	if old.EffectiveStatus(now) == StatusActive {
+		end := now.Add(overlap)
+		if old.ExpiresAt != nil && old.ExpiresAt.Before(end) {
+			end = *old.ExpiresAt
+		}
+		old.ExpiresAt = &end
+	}
+	old.ReplacedBy = key.ID
+	keys[i] = old
+
+	if err := s.save(ctx, u, append(keys, stored)); err != nil {
+		return Key{}, User{}, err
+	}
+	return key, u, nil
+}
+
+// Revoke disables the keys issued under name, including one still in its
+// rotation overlap. An empty name revokes every key of the user.
+func (s *Store) Revoke(ctx context.Context, email, name string) (User, error) {
+	u, err := s.ByEmail(ctx, email)
+	if err != nil {
+		return User{}, err
+	}
+
+	now := time.Now().UTC()
+	keys := append([]StoredKey(nil), u.Keys...)
+	found := false
+	for i, k := range keys {
+		if name != "" && k.Name != name {
+			continue
+		}
+		found = true
+		if k.Status != StatusRevoked {
+			keys[i].Status = StatusRevoked
+			keys[i].RevokedAt = &now
+		}
+	}
+	if !found && name != "" {
+		return User{}, ErrKeyNotFound
+	}
+	return u, s.save(ctx, u, keys)
+}
+
+// SetTier moves the keys issued under name, including one still in its
+// rotation overlap, to another rate limit tier
+func (s *Store) SetTier(ctx context.Context, email, name, tier string) (User, error) {
+	tier, err := ParseTier(tier)
+	if err != nil {
+		return User{}, err
+	}
+	u, err := s.ByEmail(ctx, email)
+	if err != nil {
+		return User{}, err
+	}
+
+	keys := append([]StoredKey(nil), u.Keys...)
+	found := false
+	for i, k := range keys {
+		if k.Name == name {
+			found = true
+			keys[i].Tier = tier
+		}
+	}
+	if !found {
+		return User{}, ErrKeyNotFound
+	}
+	return u, s.save(ctx, u, keys)
+}
+
+// LegacyKey is the hashed default key a plaintext apiKey stored by an older
+// version is replaced with
+func LegacyKey(hasher *Hasher, apiKey string, now time.Time) StoredKey {
+	salt := NewSalt()
+	return StoredKey{
+		ID:        LegacyID(apiKey),
+		Name:      DefaultKeyName,
+		Salt:      salt,
+		Hash:      hasher.Hash(salt, apiKey),
+		Scopes:    DefaultScopes,
+		Status:    StatusActive,
+		CreatedAt: now.UTC(),
+	}
+}
+
+// legacyUpdate replaces a plaintext apiKey with a hashed default key
+func (s *Store) legacyUpdate(apiKey string) bson.M {
+	return bson.M{
+		"$set":   bson.M{"keys": []StoredKey{LegacyKey(s.hasher, apiKey, time.Now())}},
+		"$unset": bson.M{"apiKey": ""},
+	}
+}
+
+// MigrateLegacyKey hashes one plaintext key stored by an older version and
+// returns its user. The API calls it when such a key is first used.
+func (s *Store) MigrateLegacyKey(ctx context.Context, apiKey string) (User, error) {
+	var u User
+	err := s.users.FindOneAndUpdate(ctx, bson.M{"apiKey": apiKey}, s.legacyUpdate(apiKey),
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&u)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return User{}, ErrUserNotFound
+	}
+	return u, err
+}
+
+// MigrateLegacyKeys hashes every plaintext key stored by older versions. It
+// returns how many users were migrated.
+func (s *Store) MigrateLegacyKeys(ctx context.Context) (int, error) {
+	cursor, err := s.users.Find(ctx, bson.M{"apiKey": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var legacy struct {
+			ID     bson.ObjectID `bson:"_id"`
+			ApiKey string        `bson:"apiKey"`
+		}
+		if err := cursor.Decode(&legacy); err != nil {
+			return migrated, err
+		}
+		if !IsLegacy(legacy.ApiKey) {
+			continue
+		}
+		if _, err := s.users.UpdateOne(ctx, bson.M{"_id": legacy.ID, "apiKey": legacy.ApiKey}, s.legacyUpdate(legacy.ApiKey)); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, cursor.Err()
+}
@@ -0,0 +1,68 @@
+package apikey
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReuseRevokedKeyName(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Hour)
+	u := User{Keys: []StoredKey{
+		{ID: "old", Name: "ci", Status: StatusRevoked, RevokedAt: &revokedAt},
+		{ID: "laptop", Name: "laptop", Status: StatusActive},
+	}}
+
+	keys, err := u.withKey(StoredKey{ID: "new", Name: "ci", Status: StatusActive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := retained(keys, now)
+	if len(kept) != 3 {
+		t.Fatalf("kept %d keys, want the revoked one, laptop and the new one", len(kept))
+	}
+
+	saved := User{Keys: kept}
+	if old, ok := saved.Key("old"); !ok || old.Status != StatusRevoked || old.ReplacedBy != "new" {
+		t.Errorf("revoked key = %+v, %v, want it kept and replaced by new", old, ok)
+	}
+	if i := saved.current("ci"); i < 0 || saved.Keys[i].ID != "new" {
+		t.Errorf("current ci key is %d, want new", i)
+	}
+	if u.Keys[0].ReplacedBy != "" {
+		t.Error("withKey changed the keys of the loaded user")
+	}
+
+	// Names of working keys stay taken
+	if _, err := u.withKey(StoredKey{ID: "other", Name: "laptop"}); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("reusing a working key's name = %v, want ErrNameTaken", err)
+	}
+}
+
+func TestRetained(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+	keys := []StoredKey{
+		{ID: "current", Status: StatusActive},
+		{ID: "expired", Status: StatusActive, ExpiresAt: &past},
+		{ID: "overlapping", Status: StatusActive, ExpiresAt: &future, ReplacedBy: "current"},
+		{ID: "rotated", Status: StatusActive, ExpiresAt: &past, ReplacedBy: "current"},
+		{ID: "revoked", Status: StatusRevoked, RevokedAt: &past, ReplacedBy: "current"},
+	}
+
+	var ids []string
+	for _, k := range retained(keys, now) {
+		ids = append(ids, k.ID)
+	}
+	want := []string{"current", "expired", "overlapping", "revoked"}
+	if len(ids) != len(want) {
+		t.Fatalf("retained %q, want %q", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("retained %q, want %q", ids, want)
+			break
+		}
+	}
+}
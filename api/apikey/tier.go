@@ -0,0 +1,33 @@
+package apikey
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Rate limit tiers a key can be on. The limits of each tier are configured
+// by the API server; keys only carry the name.
+const (
+	TierFree     = "free"
+	TierTeam     = "team"
+	TierInternal = "internal"
+)
+
+// AllTiers lists every tier a key can be on
+var AllTiers = []string{TierFree, TierTeam, TierInternal}
+
+// ErrUnknownTier is returned for tier names not in AllTiers
+var ErrUnknownTier = errors.New("unknown tier")
+
+// ParseTier validates a tier name. An empty name is TierFree.
+func ParseTier(tier string) (string, error) {
+	if tier == "" {
+		return TierFree, nil
+	}
+	for _, t := range AllTiers {
+		if t == tier {
+			return tier, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownTier, tier)
+}
@@ -0,0 +1,77 @@
+// Command ingest loads a winget-pkgs checkout into the packages collection,
+// tracking when each version was added, updated or removed and appending the
+// differences to the change feed served at /api/v1/changes.
+//
+//	go run ./cmd/ingest -source ../winget-pkgs
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/ingest"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	logs "github.com/iamBijoyKar/winget-pkg/api/internal/utils"
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func main() {
+	source := flag.String("source", "", "path to a winget-pkgs checkout or its manifests directory")
+	commit := flag.String("commit", "", "winget-pkgs commit being ingested (default: HEAD of -source)")
+	flag.Parse()
+
+	if *source == "" {
+		logs.PrintError("Missing -source")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		logs.PrintWarning("No .env file found, using default environment variables")
+	}
+
+	if *commit == "" {
+		out, err := exec.Command("git", "-C", *source, "rev-parse", "HEAD").Output()
+		if err != nil {
+			logs.PrintWarning("Could not read the source commit: %v", err)
+		}
+		*commit = strings.TrimSpace(string(out))
+	}
+
+	start := time.Now()
+	manifests, err := ingest.LoadManifests(*source)
+	if err != nil {
+		logs.PrintError("Failed to read manifests: %v", err)
+		os.Exit(1)
+	}
+	logs.PrintInfo("Read %d manifests in %v", len(manifests), time.Since(start).Round(time.Millisecond))
+
+	ctx := context.Background()
+	client, err := mongo.Connect(options.Client().ApplyURI(os.Getenv("MONGODB_URL")))
+	if err != nil {
+		logs.PrintError("Failed to connect to MongoDB: %v", err)
+		os.Exit(1)
+	}
+	defer client.Disconnect(ctx)
+
+	// Each package is written in a transaction, which standalone servers lack
+	st := store.New(client.Database("winget"))
+	if err := st.CheckTransactions(ctx); err != nil {
+		logs.PrintError("Cannot ingest: %v", err)
+		os.Exit(1)
+	}
+
+	summary, err := ingest.New(st).Run(ctx, manifests, *commit)
+	if err != nil {
+		logs.PrintError("Ingest failed: %v", err)
+		os.Exit(1)
+	}
+	logs.PrintInfo("Ingested %s: %d versions added, %d updated, %d removed, %d change events",
+		*commit, summary.Added, summary.Updated, summary.Removed, len(summary.Changes))
+}
@@ -0,0 +1,215 @@
+// Package admin serves the key management endpoints used by operators to
+// list, create, revoke and rotate the API keys of users and move them between
+// rate limit tiers.
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/apikey"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/auth"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+)
+
+// Service manages the keys of every user. Access is checked by the router,
+// which only lets keys with the admin scope through.
+type Service struct {
+	keys          *apikey.Store
+	authenticator *auth.Authenticator
+}
+
+// New creates the admin endpoints
+func New(keys *apikey.Store, authenticator *auth.Authenticator) *Service {
+	return &Service{keys: keys, authenticator: authenticator}
+}
+
+// createRequest is the body of POST /admin/users/:email/keys
+type createRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	Tier      string   `json:"tier"`
+	ExpiresIn string   `json:"expiresIn"`
+}
+
+// updateRequest is the body of PATCH /admin/users/:email/keys/:name
+type updateRequest struct {
+	Tier string `json:"tier"`
+}
+
+// rotateRequest is the optional body of POST /admin/users/:email/keys/:name/rotate
+type rotateRequest struct {
+	Overlap   string `json:"overlap"`
+	ExpiresIn string `json:"expiresIn"`
+}
+
+// Register adds the admin endpoints below group
+func (s *Service) Register(group *gin.RouterGroup) {
+	group.GET("/users/:email/keys", s.listHandler)
+	group.POST("/users/:email/keys", s.createHandler)
+	group.PATCH("/users/:email/keys/:name", s.updateHandler)
+	group.POST("/users/:email/keys/:name/rotate", s.rotateHandler)
+	group.POST("/users/:email/keys/:name/revoke", s.revokeHandler)
+	group.POST("/users/:email/revoke", s.revokeHandler)
+}
+
+// abortWithError maps key store errors onto problem responses
+func abortWithError(c *gin.Context, err error, detail string) {
+	switch {
+	case errors.Is(err, apikey.ErrUserNotFound):
+		server.NotFound(c, "No user with email "+c.Param("email"))
+	case errors.Is(err, apikey.ErrKeyNotFound):
+		server.NotFound(c, c.Param("email")+" has no key named "+c.Param("name"))
+	case errors.Is(err, apikey.ErrNameTaken), errors.Is(err, apikey.ErrConflict):
+		server.AbortWithProblem(c, server.NewProblem(http.StatusConflict, server.CodeConflict, err.Error()))
+	default:
+		server.StoreUnavailable(c, detail)
+	}
+}
+
+// parseExpiresIn turns an expiresIn duration into an expiry time, nil when
+// the key should not expire
+func parseExpiresIn(c *gin.Context, expiresIn string) (*time.Time, bool) {
+	if expiresIn == "" {
+		return nil, true
+	}
+	d, err := time.ParseDuration(expiresIn)
+	if err != nil || d <= 0 {
+		server.InvalidBody(c, "expiresIn", "must be a positive duration such as 720h")
+		return nil, false
+	}
+	t := time.Now().UTC().Add(d)
+	return &t, true
+}
+
+// respondWithKeys lists the keys of the user with email
+func (s *Service) respondWithKeys(c *gin.Context, email string) {
+	u, err := s.keys.ByEmail(c.Request.Context(), email)
+	if err != nil {
+		abortWithError(c, err, "Failed to look up the user")
+		return
+	}
+	keys := u.KeyInfos(time.Now())
+	server.JSON(c, http.StatusOK, keys, gin.H{"email": u.Email, "count": len(keys)})
+}
+
+func (s *Service) listHandler(c *gin.Context) {
+	s.respondWithKeys(c, c.Param("email"))
+}
+
+func (s *Service) createHandler(c *gin.Context) {
+	var req createRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		server.InvalidBody(c, "body", "must be a JSON object with name, scopes, tier and expiresIn")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		server.InvalidBody(c, "name", "is required")
+		return
+	}
+	scopes, err := apikey.CleanScopes(req.Scopes)
+	if err != nil {
+		server.InvalidBody(c, "scopes", "must only contain "+strings.Join(apikey.AllScopes, ", "))
+		return
+	}
+	tier, err := apikey.ParseTier(req.Tier)
+	if err != nil {
+		server.InvalidBody(c, "tier", "must be one of "+strings.Join(apikey.AllTiers, ", "))
+		return
+	}
+	expiresAt, ok := parseExpiresIn(c, req.ExpiresIn)
+	if !ok {
+		return
+	}
+
+	key, err := s.keys.CreateKey(c.Request.Context(), c.Param("email"), apikey.KeyOptions{
+		Name:      req.Name,
+		Scopes:    scopes,
+		Tier:      tier,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		abortWithError(c, err, "Failed to create the key")
+		return
+	}
+
+	// The key is only ever shown in this response
+	server.JSON(c, http.StatusCreated, gin.H{
+		"key":       key.String(),
+		"id":        key.ID,
+		"name":      req.Name,
+		"scopes":    scopes,
+		"tier":      tier,
+		"expiresAt": expiresAt,
+	}, gin.H{"email": c.Param("email")})
+}
+
+func (s *Service) updateHandler(c *gin.Context) {
+	var req updateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		server.InvalidBody(c, "body", "must be a JSON object with tier")
+		return
+	}
+	if _, err := apikey.ParseTier(req.Tier); err != nil || req.Tier == "" {
+		server.InvalidBody(c, "tier", "must be one of "+strings.Join(apikey.AllTiers, ", "))
+		return
+	}
+
+	u, err := s.keys.SetTier(c.Request.Context(), c.Param("email"), c.Param("name"), req.Tier)
+	if err != nil {
+		abortWithError(c, err, "Failed to update the key")
+		return
+	}
+	// Cached keys carry their tier, so they are checked again
+	s.authenticator.Invalidate(u.ID.Hex())
+	s.respondWithKeys(c, u.Email)
+}
+
+func (s *Service) revokeHandler(c *gin.Context) {
+	// Without a name every key of the user is revoked
+	u, err := s.keys.Revoke(c.Request.Context(), c.Param("email"), c.Param("name"))
+	if err != nil {
+		abortWithError(c, err, "Failed to revoke the key")
+		return
+	}
+	s.authenticator.Invalidate(u.ID.Hex())
+	s.respondWithKeys(c, u.Email)
+}
+
+func (s *Service) rotateHandler(c *gin.Context) {
+	req := rotateRequest{Overlap: apikey.DefaultOverlap.String()}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			server.InvalidBody(c, "body", "must be a JSON object with overlap and expiresIn")
+			return
+		}
+	}
+	overlap, err := time.ParseDuration(req.Overlap)
+	if err != nil || overlap < 0 {
+		server.InvalidBody(c, "overlap", "must be a duration such as 24h")
+		return
+	}
+	expiresAt, ok := parseExpiresIn(c, req.ExpiresIn)
+	if !ok {
+		return
+	}
+
+	key, u, err := s.keys.Rotate(c.Request.Context(), c.Param("email"), c.Param("name"), overlap, expiresAt)
+	if err != nil {
+		abortWithError(c, err, "Failed to rotate the key")
+		return
+	}
+	s.authenticator.Invalidate(u.ID.Hex())
+
+	// The new key is only ever shown in this response
+	server.JSON(c, http.StatusCreated, gin.H{
+		"key":       key.String(),
+		"id":        key.ID,
+		"name":      c.Param("name"),
+		"expiresAt": expiresAt,
+	}, gin.H{"email": u.Email, "overlap": overlap.String()})
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/apikey"
+)
+
+var (
+	// ErrMissingKey is returned when no API key was sent
+	ErrMissingKey = errors.New("API key is required")
+	// ErrInvalidKey is returned when the API key matches no user
+	ErrInvalidKey = errors.New("invalid API key")
+	// ErrExpiredKey is returned for keys past their expiry or rotation overlap
+	ErrExpiredKey = errors.New("API key has expired")
+	// ErrRevokedKey is returned for keys that were revoked
+	ErrRevokedKey = errors.New("API key has been revoked")
+	// ErrUnavailable is returned when the users collection cannot be queried
+	ErrUnavailable = errors.New("API keys cannot be checked right now")
+)
+
+// Principal is the user an API key belongs to
+type Principal struct {
+	ID    string
+	Email string
+	// KeyID is the public id of the key used
+	KeyID string
+	// KeyName is the name the key was issued under
+	KeyName string
+	// Scopes are what the key may be used for
+	Scopes []string
+	// Tier is the rate limit tier of the key
+	Tier string
+}
+
+// HasScope reports whether the key used was granted scope
+func (p Principal) HasScope(scope string) bool {
+	return apikey.HasScope(p.Scopes, scope)
+}
+
+// principalKey is the context key of the authenticated principal
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying principal, for gRPC handlers and
+// interceptors that run after authentication
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal stored in ctx by NewContext
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// keyStore is the part of *apikey.Store the authenticator uses
+type keyStore interface {
+	ByKeyID(ctx context.Context, id string) (apikey.User, error)
+	MigrateLegacyKey(ctx context.Context, apiKey string) (apikey.User, error)
+	Hasher() *apikey.Hasher
+}
+
+// Authenticator checks API keys against the users collection. It is shared by
+// the HTTP middleware and the gRPC interceptors. Outcomes are cached, so most
+// requests are authenticated without a database round trip.
+type Authenticator struct {
+	keys  keyStore
+	cache *keyCache
+}
+
+// New creates an authenticator over the key store. A cache Size of 0 checks
+// every key against the database.
+func New(keys *apikey.Store, cache CacheConfig) *Authenticator {
+	return &Authenticator{keys: keys, cache: newKeyCache(cache)}
+}
+
+// Invalidate drops the cached keys of a user, e.g. after a key was revoked
+func (a *Authenticator) Invalidate(userID string) {
+	a.cache.invalidate(userID)
+}
+
+// Authenticate returns the user apiKey belongs to
+func (a *Authenticator) Authenticate(ctx context.Context, apiKey string) (Principal, error) {
+	if apiKey == "" {
+		return Principal{}, ErrMissingKey
+	}
+	// Malformed keys and typos are rejected offline and kept out of the cache,
+	// so junk keys cannot evict real ones
+	if apikey.Validate(apiKey) != nil && !apikey.IsLegacy(apiKey) {
+		return Principal{}, ErrInvalidKey
+	}
+	if entry, ok := a.cache.get(apiKey); ok {
+		return entry.principal, entry.err
+	}
+
+	principal, until, err := a.lookup(ctx, apiKey)
+	// Database failures are not cached, so keys work again as soon as it recovers
+	if !errors.Is(err, ErrUnavailable) {
+		a.cache.put(apiKey, principal, err, until)
+	}
+	return principal, err
+}
+
+// lookup checks a well-formed or legacy apiKey against the users collection.
+// For valid keys it also returns when they stop being valid, if ever.
+func (a *Authenticator) lookup(ctx context.Context, apiKey string) (Principal, *time.Time, error) {
+	id, secret := apikey.LegacyID(apiKey), apiKey
+	if key, err := apikey.Parse(apiKey); err == nil {
+		id, secret = key.ID, key.Secret
+	}
+
+	u, err := a.keys.ByKeyID(ctx, id)
+	if errors.Is(err, apikey.ErrUserNotFound) && apikey.IsLegacy(apiKey) {
+		// A key stored in plaintext by an older version is hashed on first
+		// use, so existing clients keep working unchanged
+		u, err = a.keys.MigrateLegacyKey(ctx, apiKey)
+	}
+	if errors.Is(err, apikey.ErrUserNotFound) {
+		return Principal{}, nil, ErrInvalidKey
+	}
+	if err != nil {
+		return Principal{}, nil, ErrUnavailable
+	}
+	return check(u, id, secret, a.keys.Hasher(), time.Now())
+}
+
+// check verifies secret against the user's key with this id and enforces
+// revocation and expiry
+func check(u apikey.User, id, secret string, hasher *apikey.Hasher, now time.Time) (Principal, *time.Time, error) {
+	key, ok := u.Key(id)
+	if !ok || !hasher.Verify(key.Salt, key.Hash, secret) {
+		return Principal{}, nil, ErrInvalidKey
+	}
+	switch key.EffectiveStatus(now) {
+	case apikey.StatusRevoked:
+		return Principal{}, nil, ErrRevokedKey
+	case apikey.StatusExpired:
+		return Principal{}, nil, ErrExpiredKey
+	}
+	principal := Principal{ID: u.ID.Hex(), Email: u.Email, KeyID: key.ID, KeyName: key.Name, Scopes: key.Scopes, Tier: key.TierName()}
+	return principal, key.ExpiresAt, nil
+}
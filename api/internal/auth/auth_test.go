@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/apikey"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// fakeKeys holds users with hashed keys and users still holding a plaintext
+// key from an older version, and counts the legacy migrations
+type fakeKeys struct {
+	hasher     *apikey.Hasher
+	users      []apikey.User
+	plaintext  map[string]apikey.User
+	migrations int
+}
+
+func (f *fakeKeys) ByKeyID(ctx context.Context, id string) (apikey.User, error) {
+	for _, u := range f.users {
+		if _, ok := u.Key(id); ok {
+			return u, nil
+		}
+	}
+	return apikey.User{}, apikey.ErrUserNotFound
+}
+
+func (f *fakeKeys) MigrateLegacyKey(ctx context.Context, apiKey string) (apikey.User, error) {
+	u, ok := f.plaintext[apiKey]
+	if !ok {
+		return apikey.User{}, apikey.ErrUserNotFound
+	}
+	delete(f.plaintext, apiKey)
+	u.Keys = []apikey.StoredKey{apikey.LegacyKey(f.hasher, apiKey, time.Now())}
+	f.users = append(f.users, u)
+	f.migrations++
+	return u, nil
+}
+
+func (f *fakeKeys) Hasher() *apikey.Hasher {
+	return f.hasher
+}
+
+func TestLegacyKeyMigration(t *testing.T) {
+	hasher, err := apikey.NewHasher("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := strings.Repeat("0f", 32)
+	user := apikey.User{ID: bson.NewObjectID(), Email: "dev@example.com"}
+	keys := &fakeKeys{hasher: hasher, plaintext: map[string]apikey.User{legacy: user}}
+	// Without a cache every request reaches the store
+	a := &Authenticator{keys: keys, cache: newKeyCache(CacheConfig{})}
+
+	for i := range 2 {
+		principal, err := a.Authenticate(t.Context(), legacy)
+		if err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+		if principal.ID != user.ID.Hex() || principal.KeyID != apikey.LegacyID(legacy) || principal.KeyName != apikey.DefaultKeyName {
+			t.Errorf("request %d: principal = %+v", i+1, principal)
+		}
+		if keys.migrations != 1 {
+			t.Errorf("request %d: %d migrations, want 1", i+1, keys.migrations)
+		}
+	}
+
+	// The plaintext is gone and only the hash of the key is stored
+	if len(keys.plaintext) != 0 {
+		t.Error("the plaintext key is still stored")
+	}
+	stored, ok := keys.users[0].Key(apikey.LegacyID(legacy))
+	if !ok || string(stored.Hash) == legacy || !hasher.Verify(stored.Salt, stored.Hash, legacy) {
+		t.Errorf("migrated key = %+v", stored)
+	}
+
+	// Another legacy key is still unknown
+	if _, err := a.Authenticate(t.Context(), strings.Repeat("f0", 32)); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("unknown legacy key = %v, want ErrInvalidKey", err)
+	}
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// CacheConfig bounds the cache of checked keys
+type CacheConfig struct {
+	// Size is the most keys remembered; the least recently used are evicted
+	Size int
+	// TTL is how long a valid key is trusted without asking the database
+	TTL time.Duration
+	// NegativeTTL is how long an unknown key is rejected without asking the
+	// database. It is short so that a key used right after it was issued works.
+	NegativeTTL time.Duration
+	// Clock returns the current time; time.Now when nil
+	Clock func() time.Time
+}
+
+// DefaultCacheConfig keeps revocations made outside this process, e.g. with
+// the CLI, effective within a minute
+var DefaultCacheConfig = CacheConfig{
+	Size:        10000,
+	TTL:         time.Minute,
+	NegativeTTL: 10 * time.Second,
+}
+
+// cacheEntry is the outcome of checking one key
+type cacheEntry struct {
+	digest    [sha256.Size]byte
+	principal Principal
+	err       error
+	expires   time.Time
+}
+
+// keyCache is a bounded LRU cache from API key to principal. Keys are held
+// as SHA-256 digests so the cache never keeps usable keys in memory.
+type keyCache struct {
+	config  CacheConfig
+	mutex   sync.Mutex
+	order   *list.List
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+func newKeyCache(config CacheConfig) *keyCache {
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+	return &keyCache{
+		config:  config,
+		order:   list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// get returns the cached outcome for apiKey, if there is a fresh one
+func (c *keyCache) get(apiKey string) (cacheEntry, bool) {
+	if c.config.Size <= 0 {
+		return cacheEntry{}, false
+	}
+	digest := sha256.Sum256([]byte(apiKey))
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[digest]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if c.config.Clock().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, digest)
+		return cacheEntry{}, false
+	}
+	c.order.MoveToFront(element)
+	return *entry, true
+}
+
+// put remembers a valid key until at most until, or a rejected one with its error
+func (c *keyCache) put(apiKey string, principal Principal, err error, until *time.Time) {
+	if c.config.Size <= 0 {
+		return
+	}
+	ttl := c.config.TTL
+	if err != nil {
+		ttl = c.config.NegativeTTL
+	}
+	entry := &cacheEntry{
+		digest:    sha256.Sum256([]byte(apiKey)),
+		principal: principal,
+		err:       err,
+		expires:   c.config.Clock().Add(ttl),
+	}
+	// An expiring key must not outlive its expiry in the cache
+	if err == nil && until != nil && until.Before(entry.expires) {
+		entry.expires = *until
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[entry.digest]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[entry.digest] = c.order.PushFront(entry)
+	for c.order.Len() > c.config.Size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).digest)
+	}
+}
+
+// invalidate forgets every key of the user with this id
+func (c *keyCache) invalidate(userID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for digest, element := range c.entries {
+		if element.Value.(*cacheEntry).principal.ID == userID {
+			c.order.Remove(element)
+			delete(c.entries, digest)
+		}
+	}
+}
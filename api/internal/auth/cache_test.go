@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock the tests move by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestCache(clock *fakeClock, size int) *keyCache {
+	return newKeyCache(CacheConfig{Size: size, TTL: time.Minute, NegativeTTL: 10 * time.Second, Clock: clock.Now})
+}
+
+func TestCacheTTL(t *testing.T) {
+	clock := newFakeClock()
+	c := newTestCache(clock, 10)
+	c.put("valid", Principal{ID: "u1"}, nil, nil)
+	c.put("unknown", Principal{}, ErrInvalidKey, nil)
+
+	tests := []struct {
+		after   time.Duration
+		valid   bool
+		unknown bool
+	}{
+		{0, true, true},
+		{10 * time.Second, true, true},
+		{10*time.Second + 1, true, false},
+		{time.Minute, true, false},
+		{time.Minute + 1, false, false},
+	}
+	start := clock.now
+	for _, tt := range tests {
+		clock.now = start.Add(tt.after)
+		if _, ok := c.get("valid"); ok != tt.valid {
+			t.Errorf("valid key cached after %v = %v, want %v", tt.after, ok, tt.valid)
+		}
+		entry, ok := c.get("unknown")
+		if ok != tt.unknown {
+			t.Errorf("unknown key cached after %v = %v, want %v", tt.after, ok, tt.unknown)
+		}
+		if ok && entry.err != ErrInvalidKey {
+			t.Errorf("unknown key cached with %v, want ErrInvalidKey", entry.err)
+		}
+	}
+	if c.order.Len() != 0 || len(c.entries) != 0 {
+		t.Errorf("expired entries are kept: %d in order, %d in entries", c.order.Len(), len(c.entries))
+	}
+}
+
+func TestCacheCapsAtKeyExpiry(t *testing.T) {
+	clock := newFakeClock()
+	c := newTestCache(clock, 10)
+	soon := clock.now.Add(20 * time.Second)
+	later := clock.now.Add(time.Hour)
+	c.put("expiring", Principal{ID: "u1"}, nil, &soon)
+	c.put("long-lived", Principal{ID: "u2"}, nil, &later)
+	// The expiry of a key applies to valid keys only
+	c.put("revoked", Principal{}, ErrRevokedKey, &later)
+
+	clock.Advance(20*time.Second + 1)
+	if _, ok := c.get("expiring"); ok {
+		t.Error("key is cached past its expiry")
+	}
+	if _, ok := c.get("long-lived"); !ok {
+		t.Error("key expiring after the TTL is not cached for the TTL")
+	}
+	if _, ok := c.get("revoked"); ok {
+		t.Error("rejected key is cached past the negative TTL")
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	clock := newFakeClock()
+	c := newTestCache(clock, 2)
+	c.put("a", Principal{ID: "a"}, nil, nil)
+	c.put("b", Principal{ID: "b"}, nil, nil)
+	// Reading a makes b the least recently used
+	c.get("a")
+	c.put("c", Principal{ID: "c"}, nil, nil)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := c.get(key); ok != want {
+			t.Errorf("%s cached = %v, want %v", key, ok, want)
+		}
+	}
+
+	// Replacing an entry does not grow the cache
+	c.put("a", Principal{ID: "a2"}, nil, nil)
+	if entry, _ := c.get("a"); entry.principal.ID != "a2" || c.order.Len() != 2 {
+		t.Errorf("replaced entry = %+v with %d entries", entry.principal, c.order.Len())
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	c := newTestCache(newFakeClock(), 0)
+	c.put("valid", Principal{ID: "u1"}, nil, nil)
+	if _, ok := c.get("valid"); ok {
+		t.Error("cache of size 0 keeps keys")
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	c := newTestCache(newFakeClock(), 10)
+	c.put("first", Principal{ID: "u1", KeyID: "k1"}, nil, nil)
+	c.put("second", Principal{ID: "u1", KeyID: "k2"}, nil, nil)
+	c.put("other", Principal{ID: "u2"}, nil, nil)
+
+	c.invalidate("u1")
+	for key, want := range map[string]bool{"first": false, "second": false, "other": true} {
+		if _, ok := c.get(key); ok != want {
+			t.Errorf("%s cached after invalidating u1 = %v, want %v", key, ok, want)
+		}
+	}
+	if c.order.Len() != 1 {
+		t.Errorf("order holds %d entries, want 1", c.order.Len())
+	}
+}
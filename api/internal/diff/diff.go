@@ -0,0 +1,219 @@
+// Package diff compares two versions of a package field by field, the way a
+// reviewer reads a version bump: installers are matched by architecture, type,
+// scope and locale rather than by position, and list values by content.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+)
+
+// Operations of a field change
+const (
+	OpAdded   = "added"
+	OpRemoved = "removed"
+	OpChanged = "changed"
+)
+
+// FieldChange is one difference between two versions. Empty values count as
+// absent, so a field that gets its first value is added rather than changed.
+type FieldChange struct {
+	Path string      `json:"path"`
+	Op   string      `json:"op"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// skipped are fields that differ between any two versions by definition
+var skipped = map[string]bool{"version": true}
+
+// Versions returns the differences from one version to another, fields in
+// alphabetical order and list members in the order they appear
+func Versions(from, to models.Version) ([]FieldChange, error) {
+	a, err := tree(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := tree(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []FieldChange{}
+	compare("", a, b, &changes)
+	return changes, nil
+}
+
+// tree converts a version to plain maps and slices via its JSON form, so the
+// diff follows the field names clients already see
+func tree(v models.Version) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var t interface{}
+	err = json.Unmarshal(raw, &t)
+	return t, err
+}
+
+// empty reports values that are treated as missing
+func empty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		for _, value := range v {
+			if !empty(value) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// prune drops empty fields from an added or removed object
+func prune(v interface{}) interface{} {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	kept := make(map[string]interface{}, len(obj))
+	for k, value := range obj {
+		if !empty(value) {
+			kept[k] = prune(value)
+		}
+	}
+	return kept
+}
+
+func compare(path string, a, b interface{}, changes *[]FieldChange) {
+	switch {
+	case empty(a) && empty(b):
+		return
+	case empty(a):
+		*changes = append(*changes, FieldChange{Path: path, Op: OpAdded, To: prune(b)})
+		return
+	case empty(b):
+		*changes = append(*changes, FieldChange{Path: path, Op: OpRemoved, From: prune(a)})
+		return
+	}
+
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			compareMaps(path, a, b, changes)
+			return
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			compareLists(path, a, b, changes)
+			return
+		}
+	default:
+		if a == b {
+			return
+		}
+	}
+	*changes = append(*changes, FieldChange{Path: path, Op: OpChanged, From: a, To: b})
+}
+
+func join(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func compareMaps(path string, a, b map[string]interface{}, changes *[]FieldChange) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if path == "" && skipped[k] {
+			continue
+		}
+		compare(join(path, k), a[k], b[k], changes)
+	}
+}
+
+// compareLists matches objects by their identifying fields and reports
+// scalars, such as tags or Windows features, as added or removed members
+func compareLists(path string, a, b []interface{}, changes *[]FieldChange) {
+	left, leftOrder := keyed(a)
+	right, rightOrder := keyed(b)
+
+	for _, k := range leftOrder {
+		member := path + "[" + k + "]"
+		if _, ok := right[k]; !ok {
+			*changes = append(*changes, FieldChange{Path: member, Op: OpRemoved, From: prune(left[k])})
+			continue
+		}
+		if _, isObject := left[k].(map[string]interface{}); isObject {
+			compare(member, left[k], right[k], changes)
+		}
+	}
+	for _, k := range rightOrder {
+		if _, ok := left[k]; !ok {
+			*changes = append(*changes, FieldChange{Path: path + "[" + k + "]", Op: OpAdded, To: prune(right[k])})
+		}
+	}
+}
+
+// keyed indexes list members by key, numbering repeated keys
+func keyed(list []interface{}) (map[string]interface{}, []string) {
+	members := make(map[string]interface{}, len(list))
+	order := make([]string, 0, len(list))
+	for _, item := range list {
+		k := key(item)
+		base := k
+		for n := 2; members[k] != nil; n++ {
+			k = fmt.Sprintf("%s#%d", base, n)
+		}
+		members[k] = item
+		order = append(order, k)
+	}
+	return members, order
+}
+
+// key identifies a list member: locales by tag, dependencies by package and
+// installers by the fields winget uses to pick one
+func key(item interface{}) string {
+	obj, ok := item.(map[string]interface{})
+	if !ok {
+		return fmt.Sprint(item)
+	}
+	if locale, ok := obj["locale"].(string); ok && obj["packageName"] != nil {
+		return locale
+	}
+	if id, ok := obj["packageIdentifier"].(string); ok {
+		return id
+	}
+
+	var parts []string
+	for _, field := range []string{"architecture", "type", "scope", "locale"} {
+		if v, ok := obj[field].(string); ok && v != "" {
+			parts = append(parts, v)
+		}
+	}
+	if len(parts) == 0 {
+		return "?"
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,97 @@
+package diff
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+)
+
+// MIMEDiff is the media type of the unified rendering
+const MIMEDiff = "text/x-diff"
+
+// Register adds the version diff endpoint below group, the API base path
+func Register(group *gin.RouterGroup, st *store.Store) {
+	group.GET("/packages/:identifier/diff", handler(st))
+}
+
+// findVersion returns the version of pkg with exactly this version string
+func findVersion(pkg models.Package, version string) (models.Version, bool) {
+	for _, v := range pkg.Versions {
+		if v.Version == version {
+			return v, true
+		}
+	}
+	return models.Version{}, false
+}
+
+// wantsUnified reports whether the text rendering was asked for, via
+// format=unified or an Accept header preferring text/x-diff
+func wantsUnified(c *gin.Context) bool {
+	if format := c.Query("format"); format != "" {
+		return format == "unified"
+	}
+	return c.NegotiateFormat(gin.MIMEJSON, MIMEDiff) == MIMEDiff
+}
+
+func handler(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fromVersion := strings.TrimSpace(c.Query("from"))
+		toVersion := strings.TrimSpace(c.Query("to"))
+		if fromVersion == "" {
+			server.MissingParam(c, "from")
+			return
+		}
+		if toVersion == "" {
+			server.MissingParam(c, "to")
+			return
+		}
+
+		pkg, err := st.Package(c.Request.Context(), c.Param("identifier"))
+		if errors.Is(err, store.ErrNotFound) {
+			server.NotFound(c, "No package with identifier "+c.Param("identifier"))
+			return
+		}
+		if err != nil {
+			server.StoreUnavailable(c, "Failed to look up the package")
+			return
+		}
+
+		from, ok := findVersion(pkg, fromVersion)
+		if !ok {
+			server.NotFound(c, pkg.Identifier+" has no version "+fromVersion)
+			return
+		}
+		to, ok := findVersion(pkg, toVersion)
+		if !ok {
+			server.NotFound(c, pkg.Identifier+" has no version "+toVersion)
+			return
+		}
+
+		if wantsUnified(c) {
+			text, err := Unified(pkg.Identifier, from, to)
+			if err != nil {
+				server.AbortWithProblem(c, server.NewProblem(http.StatusInternalServerError, server.CodeInternal, "Failed to render the diff"))
+				return
+			}
+			c.Data(http.StatusOK, MIMEDiff+"; charset=utf-8", []byte(text))
+			return
+		}
+
+		changes, err := Versions(from, to)
+		if err != nil {
+			server.AbortWithProblem(c, server.NewProblem(http.StatusInternalServerError, server.CodeInternal, "Failed to compare the versions"))
+			return
+		}
+		server.JSON(c, http.StatusOK, gin.H{
+			"identifier": pkg.Identifier,
+			"from":       from.Version,
+			"to":         to.Version,
+			"changes":    changes,
+		}, gin.H{"count": len(changes)})
+	}
+}
@@ -0,0 +1,39 @@
+package diff
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandlerRequiresVersions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Missing versions are rejected before the store is asked
+	router.GET("/packages/:identifier/diff", handler(nil))
+
+	tests := []struct {
+		query, param string
+	}{
+		{"", "from"},
+		{"?to=2.44.0", "from"},
+		{"?from=%20&to=2.44.0", "from"},
+		{"?from=2.43.0", "to"},
+		{"?from=2.43.0&to=", "to"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/packages/Git.Git/diff"+tt.query, nil))
+
+		var problem map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("diff%s: body is not a problem: %v", tt.query, err)
+		}
+		if w.Code != http.StatusBadRequest || problem["param"] != tt.param {
+			t.Errorf("diff%s = %d for param %v, want 400 for %s", tt.query, w.Code, problem["param"], tt.param)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+)
+
+// contextLines is how many unchanged lines surround each hunk
+const contextLines = 3
+
+// Unified renders the difference between two versions as a unified diff of
+// their flattened "path: value" lines, using the same paths as Versions
+func Unified(identifier string, from, to models.Version) (string, error) {
+	a, err := tree(from)
+	if err != nil {
+		return "", err
+	}
+	b, err := tree(to)
+	if err != nil {
+		return "", err
+	}
+
+	var left, right []string
+	flatten("", a, &left)
+	flatten("", b, &right)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s %s\n+++ %s %s\n", identifier, from.Version, identifier, to.Version)
+	writeHunks(&out, left, right)
+	return out.String(), nil
+}
+
+// flatten writes one line per non-empty scalar value
+func flatten(path string, v interface{}, lines *[]string) {
+	if empty(v) {
+		return
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			if !(path == "" && skipped[k]) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			flatten(join(path, k), v[k], lines)
+		}
+	case []interface{}:
+		members, order := keyed(v)
+		for _, k := range order {
+			if _, isObject := members[k].(map[string]interface{}); isObject {
+				flatten(path+"["+k+"]", members[k], lines)
+			} else {
+				*lines = append(*lines, path+"[]: "+k)
+			}
+		}
+	case string:
+		*lines = append(*lines, path+": "+v)
+	default:
+		raw, _ := json.Marshal(v)
+		*lines = append(*lines, path+": "+string(raw))
+	}
+}
+
+// edit is one line of the edit script: ' ' kept, '-' removed, '+' added
+type edit struct {
+	op   byte
+	line string
+	a, b int // line numbers in each side before this edit, 0 based
+}
+
+// script computes a shortest edit script from the longest common subsequence.
+// Flattened manifests are a few hundred lines, so the quadratic table is cheap.
+func script(a, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		default:
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		}
+	}
+	return edits
+}
+
+// writeHunks groups changed lines with their context into @@ hunks
+func writeHunks(out *strings.Builder, a, b []string) {
+	edits := script(a, b)
+	for start := 0; start < len(edits); {
+		// Find the next change and open a hunk a few lines before it
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			return
+		}
+		from := max(first-contextLines, start)
+
+		// Extend the hunk until a run of unchanged lines is long enough to split on
+		end, kept := first, 0
+		for end < len(edits) && kept <= 2*contextLines {
+			if edits[end].op == ' ' {
+				kept++
+			} else {
+				kept = 0
+			}
+			end++
+		}
+		if kept > contextLines {
+			end -= kept - contextLines
+		}
+
+		var aLen, bLen int
+		for _, e := range edits[from:end] {
+			if e.op != '+' {
+				aLen++
+			}
+			if e.op != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(edits[from].a, aLen), hunkRange(edits[from].b, bLen))
+		for _, e := range edits[from:end] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			out.WriteByte('\n')
+		}
+		start = end
+	}
+}
+
+// hunkRange formats a hunk side the way diff -u does
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
@@ -0,0 +1,186 @@
+// Package events pushes change feed entries to connected clients as
+// Server-Sent Events. The ingest job appends to the change feed in the same
+// run that updates the packages collection; a single broker per API instance
+// follows the feed and fans new entries out to every open stream.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	logs "github.com/iamBijoyKar/winget-pkg/api/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Config tunes the broker and the streams it serves
+type Config struct {
+	// PollInterval is how often the change feed is checked for new entries
+	PollInterval time.Duration
+	// Heartbeat is how often an idle stream gets a comment to keep proxies from closing it
+	Heartbeat time.Duration
+	// MaxPerKey is how many streams one API key may keep open at a time
+	MaxPerKey int
+	// Buffer is how many events a stream may fall behind before it is closed;
+	// the client then reconnects with Last-Event-ID and catches up from the feed
+	Buffer int
+}
+
+// DefaultConfig suits dashboards that want changes within a few seconds
+var DefaultConfig = Config{
+	PollInterval: 2 * time.Second,
+	Heartbeat:    15 * time.Second,
+	MaxPerKey:    3,
+	Buffer:       256,
+}
+
+// pageSize is how many changes are read from the feed at a time
+const pageSize = 500
+
+// subscriber is one open stream
+type subscriber struct {
+	events chan models.Change
+}
+
+// changeSource is the part of *store.Store the broker reads the change feed from
+type changeSource interface {
+	Changes(ctx context.Context, after int64, limit int) ([]models.Change, error)
+	RecentChanges(ctx context.Context, filter bson.M, limit int) ([]models.Change, error)
+}
+
+// Broker follows the change feed and hands new entries to subscribers
+type Broker struct {
+	st     changeSource
+	config Config
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	connections map[string]int
+}
+
+// New creates a broker reading from the change feed of st
+func New(st *store.Store, config Config) *Broker {
+	return &Broker{
+		st:          st,
+		config:      config,
+		subscribers: make(map[*subscriber]struct{}),
+		connections: make(map[string]int),
+	}
+}
+
+// Start follows the change feed until ctx is cancelled. It begins at the
+// newest entry; clients asking for older ones read them from the store.
+func (b *Broker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(b.config.PollInterval)
+		defer ticker.Stop()
+
+		last, started := int64(0), false
+		for {
+			if !started {
+				latest, err := b.st.RecentChanges(ctx, bson.M{}, 1)
+				if err == nil {
+					started = true
+					if len(latest) > 0 {
+						last = latest[0].Sequence
+					}
+				} else if ctx.Err() == nil {
+					logs.PrintError("Change stream could not find the feed position: %v", err)
+				}
+			} else if next, err := b.poll(ctx, last); err != nil && ctx.Err() == nil {
+				logs.PrintError("Change stream poll failed: %v", err)
+			} else {
+				last = next
+			}
+
+			select {
+			case <-ctx.Done():
+				b.closeAll()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// poll publishes every change after last and returns the new position
+func (b *Broker) poll(ctx context.Context, last int64) (int64, error) {
+	for {
+		changes, err := b.st.Changes(ctx, last, pageSize)
+		if err != nil {
+			return last, err
+		}
+		for _, change := range changes {
+			b.publish(change)
+			last = change.Sequence
+		}
+		if len(changes) < pageSize {
+			return last, nil
+		}
+	}
+}
+
+// publish hands change to every subscriber. Subscribers whose buffer is full
+// are dropped rather than slowing down everyone else.
+func (b *Broker) publish(change models.Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		select {
+		case sub.events <- change:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// subscribe registers a new stream
+func (b *Broker) subscribe() *subscriber {
+	sub := &subscriber{events: make(chan models.Change, b.config.Buffer)}
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// unsubscribe removes a stream that ended
+func (b *Broker) unsubscribe(sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (b *Broker) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// acquire reserves a connection slot for key, reporting false at the limit
+func (b *Broker) acquire(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.connections[key] >= b.config.MaxPerKey {
+		return false
+	}
+	b.connections[key]++
+	return true
+}
+
+// release frees a slot taken by acquire
+func (b *Broker) release(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.connections[key]--; b.connections[key] <= 0 {
+		delete(b.connections, key)
+	}
+}
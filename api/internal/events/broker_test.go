@@ -0,0 +1,347 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/auth"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// fakeFeed is a change feed held in memory, oldest first
+type fakeFeed struct {
+	mu      sync.Mutex
+	changes []models.Change
+}
+
+func newFakeFeed(n int) *fakeFeed {
+	f := &fakeFeed{}
+	for range n {
+		f.append("updated")
+	}
+	return f
+}
+
+// append adds a change to the feed and returns it
+func (f *fakeFeed) append(kind string) models.Change {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	change := models.Change{Sequence: int64(len(f.changes) + 1), Type: kind, Identifier: "Git.Git"}
+	f.changes = append(f.changes, change)
+	return change
+}
+
+func (f *fakeFeed) Changes(ctx context.Context, after int64, limit int) ([]models.Change, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	changes := []models.Change{}
+	for _, c := range f.changes {
+		if c.Sequence > after && len(changes) < limit {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+func (f *fakeFeed) RecentChanges(ctx context.Context, filter bson.M, limit int) ([]models.Change, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	changes := []models.Change{}
+	for i := len(f.changes) - 1; i >= 0 && len(changes) < limit; i-- {
+		changes = append(changes, f.changes[i])
+	}
+	return changes, nil
+}
+
+// newTestBroker serves the change stream of a broker over feed, taking the
+// key id from the X-Key header in place of the auth middleware
+func newTestBroker(t *testing.T, feed *fakeFeed, config Config) (*Broker, *httptest.Server) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	b := &Broker{
+		st:          feed,
+		config:      config,
+		subscribers: make(map[*subscriber]struct{}),
+		connections: make(map[string]int),
+	}
+	router := gin.New()
+	group := router.Group("/stream", func(c *gin.Context) {
+		c.Set("principal", auth.Principal{KeyID: c.GetHeader("X-Key")})
+	})
+	b.Register(group)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return b, srv
+}
+
+// stream is an open change stream read line by line
+type stream struct {
+	resp   *http.Response
+	lines  *bufio.Scanner
+	cancel context.CancelFunc
+}
+
+// open connects to the change stream as key with the given query and request headers
+func open(t *testing.T, srv *httptest.Server, key, query string, headers map[string]string) *stream {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stream/changes?"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Key", key)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	s := &stream{resp: resp, lines: bufio.NewScanner(resp.Body), cancel: cancel}
+	t.Cleanup(s.close)
+	return s
+}
+
+func (s *stream) close() {
+	s.cancel()
+	s.resp.Body.Close()
+}
+
+// next returns the next event id or comment, skipping the other lines
+func (s *stream) next(t *testing.T) string {
+	t.Helper()
+	for s.lines.Scan() {
+		line := s.lines.Text()
+		if id, ok := strings.CutPrefix(line, "id: "); ok {
+			return id
+		}
+		if strings.HasPrefix(line, ":") || strings.HasPrefix(line, "retry:") {
+			return line
+		}
+	}
+	t.Fatalf("stream ended: %v", s.lines.Err())
+	return ""
+}
+
+// expect reads the stream until want, failing on anything else but retry
+func (s *stream) expect(t *testing.T, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		got := s.next(t)
+		for strings.HasPrefix(got, "retry:") {
+			got = s.next(t)
+		}
+		if got != w {
+			t.Fatalf("stream sent %q, want %q", got, w)
+		}
+	}
+}
+
+// subscribers waits until the broker has n open streams
+func subscribers(t *testing.T, b *Broker, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b.mu.Lock()
+		got := len(b.subscribers)
+		b.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("broker has %d streams, want %d", got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+var testConfig = Config{PollInterval: time.Hour, Heartbeat: time.Hour, MaxPerKey: 2, Buffer: 16}
+
+func TestStreamResumesAfterLastEventID(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		headers map[string]string
+		want    []string
+	}{
+		{"header", "", map[string]string{"Last-Event-ID": "2"}, []string{"3", "4", "5"}},
+		{"query", "last_event_id=3", nil, []string{"4", "5"}},
+		// The header of a reconnect wins over the query of the first connection
+		{"header and query", "last_event_id=1", map[string]string{"Last-Event-ID": "4"}, []string{"5"}},
+		{"up to date", "", map[string]string{"Last-Event-ID": "5"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := newFakeFeed(5)
+			b, srv := newTestBroker(t, feed, testConfig)
+			s := open(t, srv, "k1", tt.query, tt.headers)
+			s.expect(t, tt.want...)
+			subscribers(t, b, 1)
+
+			// Live changes follow the backlog without repeating it
+			b.publish(feed.changes[4])
+			change := feed.append("added")
+			b.publish(change)
+			s.expect(t, "6")
+			s.close()
+			subscribers(t, b, 0)
+		})
+	}
+}
+
+func TestStreamWithoutLastEventIDStartsNow(t *testing.T) {
+	feed := newFakeFeed(3)
+	b, srv := newTestBroker(t, feed, testConfig)
+	s := open(t, srv, "k1", "", nil)
+	subscribers(t, b, 1)
+
+	b.publish(feed.append("removed"))
+	if got := s.next(t); !strings.HasPrefix(got, "retry:") {
+		t.Fatalf("stream starts with %q, want the retry delay", got)
+	}
+	s.expect(t, "4")
+	for s.lines.Scan() {
+		line := s.lines.Text()
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var change models.Change
+			if err := json.Unmarshal([]byte(data), &change); err != nil || change.Type != "removed" {
+				t.Errorf("data = %s, %v", data, err)
+			}
+			break
+		}
+		if line != "event: package.removed" {
+			t.Errorf("unexpected line %q", line)
+		}
+	}
+}
+
+func TestStreamHeartbeat(t *testing.T) {
+	config := testConfig
+	config.Heartbeat = 10 * time.Millisecond
+	_, srv := newTestBroker(t, newFakeFeed(0), config)
+	s := open(t, srv, "k1", "", nil)
+	s.expect(t, ": heartbeat", ": heartbeat")
+}
+
+func TestStreamLimitPerKey(t *testing.T) {
+	b, srv := newTestBroker(t, newFakeFeed(0), testConfig)
+	first := open(t, srv, "k1", "", nil)
+	open(t, srv, "k1", "", nil)
+	subscribers(t, b, 2)
+
+	s := open(t, srv, "k1", "", nil)
+	var problem struct {
+		Code  string `json:"code"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.NewDecoder(s.resp.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if s.resp.StatusCode != http.StatusTooManyRequests || problem.Code != server.CodeLimitReached || problem.Limit != 2 {
+		t.Errorf("third stream = %d %+v, want 429 %s with limit 2", s.resp.StatusCode, problem, server.CodeLimitReached)
+	}
+
+	// Other keys have their own limit, and a closed stream frees its slot
+	open(t, srv, "k2", "", nil)
+	subscribers(t, b, 3)
+	first.close()
+	subscribers(t, b, 2)
+	if s := open(t, srv, "k1", "", nil); s.resp.StatusCode != http.StatusOK {
+		t.Errorf("stream after one closed = %d, want 200", s.resp.StatusCode)
+	}
+}
+
+func TestStreamRejectsBadLastEventID(t *testing.T) {
+	_, srv := newTestBroker(t, newFakeFeed(0), testConfig)
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		code   string
+		field  string
+	}{
+		{"header", "abc", "", server.CodeInvalidHeader, "header"},
+		{"negative header", "-1", "", server.CodeInvalidHeader, "header"},
+		{"query", "", "abc", server.CodeInvalidParam, "param"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/stream/changes?last_event_id="+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.header != "" {
+				req.Header.Set("Last-Event-ID", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			var problem map[string]any
+			if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != http.StatusBadRequest || problem["code"] != tt.code || problem[tt.field] == nil {
+				t.Errorf("GET = %d %v, want 400 %s naming the %s", resp.StatusCode, problem, tt.code, tt.field)
+			}
+		})
+	}
+}
+
+func TestPollPublishesNewChanges(t *testing.T) {
+	feed := newFakeFeed(pageSize + 3)
+	b := &Broker{st: feed, config: Config{Buffer: pageSize + 10}, subscribers: make(map[*subscriber]struct{})}
+	sub := b.subscribe()
+	slow := &subscriber{events: make(chan models.Change, 1)}
+	b.subscribers[slow] = struct{}{}
+
+	// Pages are read until the feed is exhausted
+	last, err := b.poll(t.Context(), 1)
+	if err != nil || last != pageSize+3 {
+		t.Fatalf("poll = %d, %v, want %d", last, err, pageSize+3)
+	}
+	if len(sub.events) != pageSize+2 {
+		t.Errorf("subscriber got %d changes, want %d", len(sub.events), pageSize+2)
+	}
+	// A subscriber that fell behind is closed so it reconnects and catches up
+	if _, ok := b.subscribers[slow]; ok {
+		t.Error("a full subscriber stays subscribed")
+	}
+	<-slow.events
+	if _, ok := <-slow.events; ok {
+		t.Error("a dropped subscriber's channel is still open")
+	}
+}
+
+func TestIsStreamPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/api/v1/stream/changes", true},
+		{"/api/v1/stream/", true},
+		{"/api/v1/publishers/stream/packages", false},
+		{"/api/v1/streams/changes", false},
+		{"/api/v2/stream/changes", false},
+		{"/stream/changes", false},
+	}
+	for _, tt := range tests {
+		for _, mount := range []string{"/api/v1/stream", "/api/v1/stream/"} {
+			if got := IsStreamPath(mount, tt.path); got != tt.want {
+				t.Errorf("IsStreamPath(%q, %q) = %v, want %v", mount, tt.path, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/auth"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+	logs "github.com/iamBijoyKar/winget-pkg/api/internal/utils"
+)
+
+// retryMs is the reconnection delay suggested to EventSource clients
+const retryMs = 5000
+
+// Register adds the change stream to group
+func (b *Broker) Register(group *gin.RouterGroup) {
+	group.GET("/changes", b.changesHandler)
+}
+
+// IsStreamPath reports whether path is an event stream of a broker registered
+// under mount. Browsers' EventSource cannot send custom headers, so these
+// accept the API key as a query parameter. Other routes that merely contain
+// a "stream" segment do not.
+func IsStreamPath(mount, path string) bool {
+	return strings.HasPrefix(path, strings.TrimSuffix(mount, "/")+"/")
+}
+
+// owner identifies the caller set by the auth middleware
+func owner(c *gin.Context) string {
+	principal, _ := c.MustGet("principal").(auth.Principal)
+	return principal.KeyID
+}
+
+// lastEventIDHeader is sent by EventSource clients when they reconnect
+const lastEventIDHeader = "Last-Event-ID"
+
+// lastEventID reads the resume position from the Last-Event-ID header sent by
+// reconnecting clients, or from last_event_id for the first connection. On
+// error it also returns the header or parameter that was invalid.
+func lastEventID(c *gin.Context) (after int64, resume bool, source string, err error) {
+	source = lastEventIDHeader
+	value := strings.TrimSpace(c.GetHeader(lastEventIDHeader))
+	if value == "" {
+		source = "last_event_id"
+		value = strings.TrimSpace(c.Query("last_event_id"))
+	}
+	if value == "" {
+		return 0, false, "", nil
+	}
+	after, err = strconv.ParseInt(value, 10, 64)
+	if err != nil || after < 0 {
+		return 0, false, source, fmt.Errorf("invalid event id %q", value)
+	}
+	return after, true, "", nil
+}
+
+// writeEvent sends one change as an SSE event named after the change type
+func writeEvent(w io.Writer, change models.Change) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: package.%s\ndata: %s\n\n", change.Sequence, change.Type, data)
+	return err
+}
+
+func (b *Broker) changesHandler(c *gin.Context) {
+	after, resume, source, err := lastEventID(c)
+	if err != nil && source == lastEventIDHeader {
+		server.InvalidHeader(c, source, "must be the id of an event received earlier")
+		return
+	}
+	if err != nil {
+		server.InvalidParam(c, source, "must be the id of an event received earlier")
+		return
+	}
+
+	key := owner(c)
+	if !b.acquire(key) {
+		server.AbortWithProblem(c, server.NewProblem(http.StatusTooManyRequests, server.CodeLimitReached,
+			"An API key can keep at most "+strconv.Itoa(b.config.MaxPerKey)+" change streams open").
+			With("limit", b.config.MaxPerKey))
+		return
+	}
+	defer b.release(key)
+
+	// Subscribe before catching up so nothing published meanwhile is missed;
+	// events already sent from the store are skipped by sequence below
+	sub := b.subscribe()
+	defer b.unsubscribe(sub)
+
+	ctx := c.Request.Context()
+	var backlog []models.Change
+	if resume {
+		backlog, err = b.st.Changes(ctx, after, pageSize)
+		if err != nil {
+			server.StoreUnavailable(c, "Failed to read the change feed")
+			return
+		}
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Keep reverse proxies such as nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", retryMs)
+	c.Writer.Flush()
+
+	for len(backlog) > 0 {
+		for _, change := range backlog {
+			if err := writeEvent(c.Writer, change); err != nil {
+				return
+			}
+			after = change.Sequence
+		}
+		c.Writer.Flush()
+		if len(backlog) < pageSize {
+			break
+		}
+		if backlog, err = b.st.Changes(ctx, after, pageSize); err != nil {
+			if ctx.Err() == nil {
+				logs.PrintError("Change stream catch-up failed: %v", err)
+			}
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(b.config.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case change, ok := <-sub.events:
+			if !ok {
+				// Fell too far behind or the server is stopping; the client
+				// reconnects with Last-Event-ID and resumes from the store
+				return
+			}
+			if change.Sequence <= after {
+				continue
+			}
+			if err := writeEvent(c.Writer, change); err != nil {
+				return
+			}
+			after = change.Sequence
+		}
+		c.Writer.Flush()
+	}
+}
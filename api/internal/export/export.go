@@ -0,0 +1,291 @@
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	logs "github.com/iamBijoyKar/winget-pkg/api/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// FormatNDJSON is one package per line, gzip compressed
+	FormatNDJSON = "ndjson.gz"
+	// FormatSQLite is a SQLite database with packages, versions and installers tables
+	FormatSQLite = "sqlite"
+
+	manifestName = "manifest.json"
+	// keepSnapshots is how many snapshots stay on disk so interrupted
+	// downloads of the previous snapshot can still be resumed
+	keepSnapshots = 2
+)
+
+// File describes one downloadable snapshot file
+type File struct {
+	Format string `json:"format"`
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes a generated snapshot
+type Manifest struct {
+	Snapshot     string    `json:"snapshot"`
+	CreatedAt    time.Time `json:"createdAt"`
+	SourceCommit string    `json:"sourceCommit"`
+	PackageCount int       `json:"packageCount"`
+	Files        []File    `json:"files"`
+}
+
+// File returns the snapshot file with the given name
+func (m *Manifest) File(name string) (File, bool) {
+	for _, f := range m.Files {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return File{}, false
+}
+
+// catalog is the part of *store.Store the exporter reads
+type catalog interface {
+	SourceCommit(ctx context.Context) (string, error)
+	Stream(ctx context.Context, filter bson.M, fn func(models.Package) error) error
+}
+
+// Exporter periodically writes catalog snapshots to a directory
+type Exporter struct {
+	st  catalog
+	dir string
+	// now names snapshots; time.Now outside tests
+	now func() time.Time
+
+	mu      sync.RWMutex
+	current *Manifest
+	// previous snapshots still on disk, newest first
+	previous []*Manifest
+}
+
+// New creates an exporter writing to dir and picks up the last snapshot
+// written there, so a restart keeps serving it until the next run
+func New(st *store.Store, dir string) (*Exporter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	e := &Exporter{st: st, dir: dir, now: time.Now}
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err == nil {
+		var m Manifest
+		if err := json.Unmarshal(data, &m); err == nil {
+			e.current = &m
+		}
+	}
+	return e, nil
+}
+
+// Current returns the latest snapshot, or nil before the first one is written
+func (e *Exporter) Current() *Manifest {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.current
+}
+
+// lookup finds a file of the current or a retained previous snapshot
+func (e *Exporter) lookup(name string) (File, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.current != nil {
+		if f, ok := e.current.File(name); ok {
+			return f, true
+		}
+	}
+	for _, m := range e.previous {
+		if f, ok := m.File(name); ok {
+			return f, true
+		}
+	}
+	return File{}, false
+}
+
+// Start generates a snapshot now if the current one is older than interval
+// and then once every interval until ctx is cancelled
+func (e *Exporter) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		if m := e.Current(); m == nil || time.Since(m.CreatedAt) >= interval {
+			e.run(ctx)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.run(ctx)
+			}
+		}
+	}()
+}
+
+func (e *Exporter) run(ctx context.Context) {
+	start := time.Now()
+	m, err := e.Generate(ctx)
+	if err != nil {
+		logs.PrintError("Export failed: %v", err)
+		return
+	}
+	logs.PrintInfo("Export %s written: %d packages in %v", m.Snapshot, m.PackageCount, time.Since(start).Round(time.Millisecond))
+}
+
+// Generate writes a new snapshot and makes it the current one
+func (e *Exporter) Generate(ctx context.Context) (*Manifest, error) {
+	now := e.now().UTC()
+	commit, err := e.st.SourceCommit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Snapshot:     now.Format("20060102T150405Z"),
+		CreatedAt:    now,
+		SourceCommit: commit,
+	}
+	ndjsonPath := filepath.Join(e.dir, "packages-"+m.Snapshot+"."+FormatNDJSON)
+	sqlitePath := filepath.Join(e.dir, "packages-"+m.Snapshot+"."+FormatSQLite)
+
+	nd, err := newNDJSONWriter(ndjsonPath + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	db, err := newSQLiteWriter(sqlitePath + ".tmp")
+	if err != nil {
+		nd.Abort()
+		return nil, err
+	}
+
+	err = e.st.Stream(ctx, bson.M{}, func(p models.Package) error {
+		m.PackageCount++
+		if err := nd.Write(p); err != nil {
+			return err
+		}
+		return db.Write(p)
+	})
+	if err == nil {
+		err = nd.Close()
+	} else {
+		nd.Abort()
+	}
+	if err == nil {
+		err = db.Close()
+	} else {
+		db.Abort()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range []struct{ format, path string }{
+		{FormatNDJSON, ndjsonPath},
+		{FormatSQLite, sqlitePath},
+	} {
+		if err := os.Rename(f.path+".tmp", f.path); err != nil {
+			return nil, err
+		}
+		file, err := describe(f.format, f.path)
+		if err != nil {
+			return nil, err
+		}
+		m.Files = append(m.Files, file)
+	}
+
+	if err := e.publish(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// publish writes the manifest, swaps in the new snapshot and removes
+// snapshots that fell out of the retention window
+func (e *Exporter) publish(m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(e.dir, manifestName+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(e.dir, manifestName)); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	if e.current != nil {
+		e.previous = append([]*Manifest{e.current}, e.previous...)
+	}
+	e.current = m
+	if len(e.previous) > keepSnapshots-1 {
+		e.previous = e.previous[:keepSnapshots-1]
+	}
+	keep := map[string]bool{manifestName: true}
+	for _, s := range append([]*Manifest{e.current}, e.previous...) {
+		for _, f := range s.Files {
+			keep[f.Name] = true
+		}
+	}
+	e.mu.Unlock()
+
+	return e.prune(keep)
+}
+
+// prune deletes snapshot files that are no longer referenced
+func (e *Exporter) prune(keep map[string]bool) error {
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "packages-") && !keep[name] {
+			if err := os.Remove(filepath.Join(e.dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// describe computes the size and checksum of a written snapshot file
+func describe(format, path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return File{}, err
+	}
+	return File{
+		Format: format,
+		Name:   filepath.Base(path),
+		Size:   size,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
@@ -0,0 +1,144 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// fakeCatalog streams a fixed list of packages
+type fakeCatalog struct {
+	commit   string
+	packages []models.Package
+}
+
+func (f *fakeCatalog) SourceCommit(ctx context.Context) (string, error) {
+	return f.commit, nil
+}
+
+func (f *fakeCatalog) Stream(ctx context.Context, filter bson.M, fn func(models.Package) error) error {
+	for _, p := range f.packages {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newGenerator returns an exporter over two packages whose clock moves an
+// hour per snapshot
+func newGenerator(t *testing.T) *Exporter {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := &fakeCatalog{commit: "abc123", packages: []models.Package{
+		{Identifier: "Git.Git", Name: "Git", Publisher: "The Git Development Community", LatestVersion: "2.44.0"},
+		{Identifier: "Mozilla.Firefox", Name: "Firefox", Publisher: "Mozilla", LatestVersion: "125.0"},
+	}}
+	return &Exporter{st: st, dir: t.TempDir(), now: func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	}}
+}
+
+func TestGenerate(t *testing.T) {
+	e := newGenerator(t)
+	m, err := e.Generate(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Snapshot != "20260101T010000Z" || m.SourceCommit != "abc123" || m.PackageCount != 2 {
+		t.Errorf("manifest = %+v", m)
+	}
+	var names []string
+	for _, f := range m.Files {
+		names = append(names, f.Name)
+		// The manifest describes the file on disk
+		got, err := describe(f.Format, filepath.Join(e.dir, f.Name))
+		if err != nil || got != f {
+			t.Errorf("%s on disk = %+v, %v, want %+v", f.Name, got, err, f)
+		}
+	}
+	want := []string{"packages-20260101T010000Z.ndjson.gz", "packages-20260101T010000Z.sqlite"}
+	if !slices.Equal(names, want) {
+		t.Errorf("files = %q, want %q", names, want)
+	}
+
+	file, err := os.Open(filepath.Join(e.dir, want[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var identifiers []string
+	for lines := bufio.NewScanner(gz); lines.Scan(); {
+		var p models.Package
+		if err := json.Unmarshal(lines.Bytes(), &p); err != nil {
+			t.Fatalf("line %q: %v", lines.Text(), err)
+		}
+		identifiers = append(identifiers, p.Identifier)
+	}
+	if !slices.Equal(identifiers, []string{"Git.Git", "Mozilla.Firefox"}) {
+		t.Errorf("ndjson packages = %q", identifiers)
+	}
+
+	// A restart serves the snapshot written before it
+	restarted, err := New(nil, e.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current := restarted.Current(); current == nil || current.Snapshot != m.Snapshot {
+		t.Errorf("after a restart the current snapshot is %+v, want %s", current, m.Snapshot)
+	}
+}
+
+func TestPruneKeepsNewestSnapshots(t *testing.T) {
+	e := newGenerator(t)
+	var snapshots []*Manifest
+	for range keepSnapshots + 2 {
+		m, err := e.Generate(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshots = append(snapshots, m)
+	}
+
+	// Only the files of the newest keepSnapshots snapshots are left, and
+	// downloads of the previous one can still be resumed
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left []string
+	for _, entry := range entries {
+		left = append(left, entry.Name())
+	}
+	want := []string{manifestName}
+	for _, m := range snapshots[len(snapshots)-keepSnapshots:] {
+		for _, f := range m.Files {
+			want = append(want, f.Name)
+		}
+	}
+	slices.Sort(want)
+	if !slices.Equal(left, want) {
+		t.Errorf("directory holds %q, want %q", left, want)
+	}
+
+	for i, m := range snapshots {
+		_, ok := e.lookup(m.Files[0].Name)
+		if kept := i >= len(snapshots)-keepSnapshots; ok != kept {
+			t.Errorf("snapshot %s served = %v, want %v", m.Snapshot, ok, kept)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+)
+
+var contentTypes = map[string]string{
+	FormatNDJSON: "application/gzip",
+	FormatSQLite: "application/vnd.sqlite3",
+}
+
+// manifestResponse is the manifest with download links for each file
+type manifestResponse struct {
+	*Manifest
+	Files []fileResponse `json:"files"`
+}
+
+type fileResponse struct {
+	File
+	URL string `json:"url"`
+}
+
+// Register adds the export endpoints to group. The snapshot files are served
+// with Range support, so they must be excluded from response compression.
+func (e *Exporter) Register(group *gin.RouterGroup) {
+	group.GET("", e.manifestHandler)
+	group.GET("/:file", e.fileHandler)
+}
+
+func (e *Exporter) manifestHandler(c *gin.Context) {
+	m := e.Current()
+	if m == nil {
+		c.Header("Retry-After", "60")
+		server.StoreUnavailable(c, "The first export snapshot is still being generated")
+		return
+	}
+
+	base := strings.TrimSuffix(c.Request.URL.Path, "/")
+	if format := c.Query("format"); format != "" {
+		for _, f := range m.Files {
+			if f.Format == format {
+				c.Redirect(http.StatusFound, base+"/"+f.Name)
+				return
+			}
+		}
+		server.InvalidParam(c, "format", "must be one of "+FormatNDJSON+", "+FormatSQLite)
+		return
+	}
+
+	resp := manifestResponse{Manifest: m}
+	for _, f := range m.Files {
+		resp.Files = append(resp.Files, fileResponse{File: f, URL: base + "/" + f.Name})
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	server.JSON(c, http.StatusOK, resp, nil)
+}
+
+func (e *Exporter) fileHandler(c *gin.Context) {
+	name := c.Param("file")
+	f, ok := e.lookup(name)
+	if !ok {
+		server.NotFound(c, "No export file named "+name)
+		return
+	}
+
+	file, err := os.Open(filepath.Join(e.dir, f.Name))
+	if err != nil {
+		server.NotFound(c, "Export file "+name+" is no longer available")
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		server.StoreUnavailable(c, "Failed to read export file")
+		return
+	}
+
+	// Snapshot files never change once written, so the checksum doubles as a
+	// strong ETag and If-Range lets clients resume interrupted downloads.
+	// They are only served to API keys, so shared caches must not keep them.
+	c.Header("ETag", `"`+f.SHA256+`"`)
+	c.Header("Content-Type", contentTypes[f.Format])
+	c.Header("Content-Disposition", `attachment; filename="`+f.Name+`"`)
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	http.ServeContent(c.Writer, c.Request, f.Name, info.ModTime(), file)
+}
@@ -0,0 +1,111 @@
+package export
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testSnapshot = "20260101T000000Z"
+	testFile     = "packages-" + testSnapshot + "." + FormatNDJSON
+	testContent  = "0123456789abcdef"
+)
+
+// newTestExporter returns an exporter serving one snapshot file, named like
+// Generate names them, from a temporary directory
+func newTestExporter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, testFile)
+	if err := os.WriteFile(path, []byte(testContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := describe(FormatNDJSON, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(Manifest{
+		Snapshot:  testSnapshot,
+		CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Files:     []File{file},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifestName), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := New(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	e.Register(router.Group("/export"))
+	return router
+}
+
+func TestSnapshotsAreNotShared(t *testing.T) {
+	router := newTestExporter(t)
+	for _, path := range []string{"/export", "/export/" + testFile} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", path, w.Code)
+		}
+		// Snapshots are served to API keys only, so shared caches must not
+		// keep them
+		if got := w.Header().Get("Cache-Control"); !strings.HasPrefix(got, "private,") {
+			t.Errorf("GET %s has Cache-Control %q, want private", path, got)
+		}
+	}
+}
+
+func TestRangeResumesDownload(t *testing.T) {
+	router := newTestExporter(t)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export/"+testFile, nil))
+	etag := w.Header().Get("ETag")
+	if w.Body.String() != testContent || etag == "" {
+		t.Fatalf("GET = %q with ETag %q", w.Body.String(), etag)
+	}
+
+	tests := []struct {
+		name    string
+		ifRange string
+		code    int
+		body    string
+	}{
+		{"range", "", http.StatusPartialContent, testContent[10:]},
+		{"same snapshot", etag, http.StatusPartialContent, testContent[10:]},
+		// The file changed since the client started, so it starts over
+		{"stale", `"0000"`, http.StatusOK, testContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/export/"+testFile, nil)
+			req.Header.Set("Range", "bytes=10-")
+			if tt.ifRange != "" {
+				req.Header.Set("If-Range", tt.ifRange)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.code || w.Body.String() != tt.body {
+				t.Errorf("GET = %d %q, want %d %q", w.Code, w.Body.String(), tt.code, tt.body)
+			}
+			if want := "bytes 10-15/16"; tt.code == http.StatusPartialContent && w.Header().Get("Content-Range") != want {
+				t.Errorf("Content-Range = %q, want %q", w.Header().Get("Content-Range"), want)
+			}
+		})
+	}
+}
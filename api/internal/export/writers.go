@@ -0,0 +1,178 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+// ndjsonWriter writes one JSON package per line into a gzip file
+type ndjsonWriter struct {
+	path string
+	file *os.File
+	buf  *bufio.Writer
+	gz   *gzip.Writer
+	enc  *json.Encoder
+}
+
+func newNDJSONWriter(path string) (*ndjsonWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bufio.NewWriter(file)
+	gz := gzip.NewWriter(buf)
+	return &ndjsonWriter{path: path, file: file, buf: buf, gz: gz, enc: json.NewEncoder(gz)}, nil
+}
+
+func (w *ndjsonWriter) Write(p models.Package) error {
+	return w.enc.Encode(p)
+}
+
+func (w *ndjsonWriter) Close() error {
+	if err := w.gz.Close(); err != nil {
+		w.Abort()
+		return err
+	}
+	if err := w.buf.Flush(); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.file.Close()
+}
+
+// Abort closes and removes a partially written file
+func (w *ndjsonWriter) Abort() {
+	w.file.Close()
+	os.Remove(w.path)
+}
+
+const sqliteSchema = `
+CREATE TABLE packages (
+	identifier        TEXT PRIMARY KEY,
+	name              TEXT NOT NULL,
+	publisher         TEXT NOT NULL,
+	author            TEXT NOT NULL,
+	short_description TEXT NOT NULL,
+	description       TEXT NOT NULL,
+	moniker           TEXT NOT NULL,
+	tags              TEXT NOT NULL,
+	license           TEXT NOT NULL,
+	homepage          TEXT NOT NULL,
+	locale            TEXT NOT NULL,
+	latest_version    TEXT NOT NULL
+);
+CREATE TABLE versions (
+	identifier     TEXT NOT NULL REFERENCES packages(identifier),
+	version        TEXT NOT NULL,
+	channel        TEXT NOT NULL,
+	release_date   TEXT NOT NULL,
+	default_locale TEXT NOT NULL,
+	PRIMARY KEY (identifier, version, channel)
+);
+CREATE TABLE installers (
+	identifier   TEXT NOT NULL,
+	version      TEXT NOT NULL,
+	architecture TEXT NOT NULL,
+	type         TEXT NOT NULL,
+	scope        TEXT NOT NULL,
+	locale       TEXT NOT NULL,
+	url          TEXT NOT NULL,
+	sha256       TEXT NOT NULL
+);
+CREATE INDEX packages_name ON packages(name);
+CREATE INDEX packages_publisher ON packages(publisher);
+CREATE INDEX installers_package ON installers(identifier, version);
+`
+
+// sqliteWriter loads packages into a fresh SQLite database in one transaction
+type sqliteWriter struct {
+	path       string
+	db         *sql.DB
+	tx         *sql.Tx
+	packages   *sql.Stmt
+	versions   *sql.Stmt
+	installers *sql.Stmt
+}
+
+func newSQLiteWriter(path string) (*sqliteWriter, error) {
+	os.Remove(path)
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &sqliteWriter{path: path, db: db}
+	if err := w.prepare(); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *sqliteWriter) prepare() error {
+	if _, err := w.db.Exec(sqliteSchema); err != nil {
+		return err
+	}
+
+	var err error
+	if w.tx, err = w.db.Begin(); err != nil {
+		return err
+	}
+	if w.packages, err = w.tx.Prepare(`INSERT OR REPLACE INTO packages VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`); err != nil {
+		return err
+	}
+	if w.versions, err = w.tx.Prepare(`INSERT OR IGNORE INTO versions VALUES (?, ?, ?, ?, ?)`); err != nil {
+		return err
+	}
+	w.installers, err = w.tx.Prepare(`INSERT INTO installers VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	return err
+}
+
+func (w *sqliteWriter) Write(p models.Package) error {
+	_, err := w.packages.Exec(p.Identifier, p.Name, p.Publisher, p.Author, p.ShortDescription,
+		p.Description, p.Moniker, strings.Join(p.Tags, ";"), p.License, p.Homepage, p.Locale, p.LatestVersion)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range p.Versions {
+		if _, err := w.versions.Exec(p.Identifier, v.Version, v.Channel, v.ReleaseDate, v.DefaultLocale); err != nil {
+			return err
+		}
+		for _, i := range v.Installers {
+			if _, err := w.installers.Exec(p.Identifier, v.Version, i.Architecture, i.Type, i.Scope, i.Locale, i.URL, i.Sha256); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *sqliteWriter) Close() error {
+	if err := w.tx.Commit(); err != nil {
+		w.Abort()
+		return err
+	}
+	if _, err := w.db.Exec(`VACUUM`); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.db.Close()
+}
+
+// Abort discards the transaction and removes the partially written database
+func (w *sqliteWriter) Abort() {
+	if w.tx != nil {
+		w.tx.Rollback()
+	}
+	w.db.Close()
+	os.Remove(w.path)
+}
@@ -0,0 +1,208 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// size is the number of entries in each feed
+const size = 50
+
+// Register adds the Atom/RSS feeds below group, the API base path
+func Register(group *gin.RouterGroup, st *store.Store) {
+	group.GET("/feeds/new-packages", newPackagesHandler(st))
+	group.GET("/feeds/updates", updatesHandler(st))
+	group.GET("/packages/:identifier/feed", packageHandler(st))
+}
+
+// IsFeedPath reports whether path is one of the feeds. Feed readers cannot
+// send custom headers, so these accept the API key as a query parameter.
+func IsFeedPath(path string) bool {
+	return strings.Contains(path, "/feeds/") || strings.HasSuffix(path, "/feed")
+}
+
+// baseURL is the scheme and host the request was made to
+func baseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+func newPackagesHandler(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		changes, err := st.RecentChanges(c.Request.Context(), bson.M{"type": models.ChangeAdded}, size)
+		if err != nil {
+			server.StoreUnavailable(c, "Failed to read the change feed")
+			return
+		}
+
+		f := newFeed(c, "New winget packages", "Packages added to the winget catalog")
+		addEntries(c, &f, changes)
+		render(c, f, version(f.ID, changes))
+	}
+}
+
+func updatesHandler(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := bson.M{"versions.added.0": bson.M{"$exists": true}}
+		title := "New winget package versions"
+
+		if publisher := strings.TrimSpace(c.Query("publisher")); publisher != "" {
+			names, err := st.PublisherVariants(c.Request.Context(), store.NormalizePublisher(publisher))
+			if err != nil {
+				server.StoreUnavailable(c, "Failed to look up the publisher")
+				return
+			}
+			if len(names) == 0 {
+				server.NotFound(c, "No publisher matches "+publisher)
+				return
+			}
+			filter["publisher"] = bson.M{"$in": names}
+			title = "New versions from " + names[0]
+		}
+
+		changes, err := st.RecentChanges(c.Request.Context(), filter, size)
+		if err != nil {
+			server.StoreUnavailable(c, "Failed to read the change feed")
+			return
+		}
+
+		f := newFeed(c, title, "New versions of winget packages")
+		addEntries(c, &f, changes)
+		render(c, f, version(f.ID, changes))
+	}
+}
+
+func packageHandler(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pkg, err := st.Package(c.Request.Context(), c.Param("identifier"))
+		if errors.Is(err, store.ErrNotFound) {
+			server.NotFound(c, "No package with identifier "+c.Param("identifier"))
+			return
+		}
+		if err != nil {
+			server.StoreUnavailable(c, "Failed to look up the package")
+			return
+		}
+
+		changes, err := st.RecentChanges(c.Request.Context(), bson.M{"identifier": pkg.Identifier}, size)
+		if err != nil {
+			server.StoreUnavailable(c, "Failed to read the change feed")
+			return
+		}
+
+		f := newFeed(c, pkg.Name+" releases", pkg.ShortDescription)
+		f.Link = packageLink(c, pkg.Identifier)
+		if pkg.UpdatedAt != nil {
+			f.Updated = *pkg.UpdatedAt
+		}
+		addEntries(c, &f, changes)
+		render(c, f, version(f.ID, changes))
+	}
+}
+
+// newFeed starts a feed identified by the request URL without the format.
+// The API key is never echoed back into the document.
+func newFeed(c *gin.Context, title, subtitle string) Feed {
+	query := c.Request.URL.Query()
+	query.Del("api_key")
+	self := baseURL(c) + c.Request.URL.Path
+	if encoded := query.Encode(); encoded != "" {
+		self += "?" + encoded
+	}
+	query.Del("format")
+	id := baseURL(c) + c.Request.URL.Path
+	if encoded := query.Encode(); encoded != "" {
+		id += "?" + encoded
+	}
+
+	return Feed{
+		ID:       id,
+		Title:    title,
+		Subtitle: subtitle,
+		Self:     self,
+		Link:     baseURL(c) + "/api/v1/changes",
+		Updated:  time.Unix(0, 0).UTC(),
+	}
+}
+
+func packageLink(c *gin.Context, identifier string) string {
+	return baseURL(c) + "/api/v1/packageidentifier?identifier=" + url.QueryEscape(identifier)
+}
+
+// addEntries turns changes, newest first, into feed entries
+func addEntries(c *gin.Context, f *Feed, changes []models.Change) {
+	for _, change := range changes {
+		name := change.Name
+		if name == "" {
+			name = change.Identifier
+		}
+		f.Entries = append(f.Entries, Entry{
+			ID:      fmt.Sprintf("%s/api/v1/changes#%d", baseURL(c), change.Sequence),
+			Title:   entryTitle(name, change),
+			Link:    packageLink(c, change.Identifier),
+			Author:  change.Publisher,
+			Summary: entrySummary(change),
+			Updated: change.Timestamp,
+		})
+		if change.Timestamp.After(f.Updated) {
+			f.Updated = change.Timestamp
+		}
+	}
+}
+
+func entryTitle(name string, change models.Change) string {
+	switch change.Type {
+	case models.ChangeAdded:
+		if len(change.Versions.Added) > 0 {
+			return name + " " + change.Versions.Added[0] + " added"
+		}
+		return name + " added"
+	case models.ChangeRemoved:
+		return name + " removed"
+	}
+	if len(change.Versions.Added) > 0 {
+		return name + " " + change.Versions.Added[0] + " released"
+	}
+	return name + " updated"
+}
+
+func entrySummary(change models.Change) string {
+	var parts []string
+	for _, list := range []struct {
+		label    string
+		versions []string
+	}{
+		{"New versions", change.Versions.Added},
+		{"Updated manifests", change.Versions.Updated},
+		{"Removed versions", change.Versions.Removed},
+	} {
+		if len(list.versions) > 0 {
+			parts = append(parts, list.label+": "+strings.Join(list.versions, ", "))
+		}
+	}
+	return change.Identifier + ". " + strings.Join(parts, ". ")
+}
+
+// version fingerprints a feed by its URL and entries for the ETag
+func version(id string, changes []models.Change) string {
+	h := sha256.New()
+	h.Write([]byte(id))
+	for _, change := range changes {
+		fmt.Fprintf(h, ",%d", change.Sequence)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
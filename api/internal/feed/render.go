@@ -0,0 +1,196 @@
+package feed
+
+import (
+	"encoding/xml"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+)
+
+const (
+	// MIMEAtom is the Atom 1.0 syndication format
+	MIMEAtom = "application/atom+xml"
+	// MIMERSS is the RSS 2.0 syndication format
+	MIMERSS = "application/rss+xml"
+)
+
+// Feed is a format independent feed, rendered as Atom or RSS
+type Feed struct {
+	ID       string
+	Title    string
+	Subtitle string
+	Self     string
+	Link     string
+	Updated  time.Time
+	Entries  []Entry
+}
+
+// Entry is one item of a feed
+type Entry struct {
+	ID      string
+	Title   string
+	Link    string
+	Author  string
+	Summary string
+	Updated time.Time
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  *atomPerson `xml:"author,omitempty"`
+	Summary string      `xml:"summary"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Self          atomLink  `xml:"atom:link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	Author      string  `xml:"dc:creator,omitempty"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// negotiate picks Atom or RSS from the format query parameter, falling back
+// to the Accept header and then to Atom
+func negotiate(c *gin.Context) string {
+	switch c.Query("format") {
+	case "rss":
+		return MIMERSS
+	case "atom":
+		return MIMEAtom
+	}
+	if c.NegotiateFormat(MIMEAtom, MIMERSS) == MIMERSS {
+		return MIMERSS
+	}
+	return MIMEAtom
+}
+
+func (f Feed) atom() atomFeed {
+	out := atomFeed{
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Subtitle,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Href: f.Self, Type: MIMEAtom},
+			{Rel: "alternate", Href: f.Link},
+		},
+		Entries: []atomEntry{},
+	}
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Updated: e.Updated.UTC().Format(time.RFC3339),
+			Link:    atomLink{Rel: "alternate", Href: e.Link},
+			Summary: e.Summary,
+		}
+		if e.Author != "" {
+			entry.Author = &atomPerson{Name: e.Author}
+		}
+		out.Entries = append(out.Entries, entry)
+	}
+	return out
+}
+
+func (f Feed) rss() rssFeed {
+	out := rssFeed{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Self:          atomLink{Rel: "self", Href: f.Self, Type: MIMERSS},
+			Description:   f.Subtitle,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			Items:         []rssItem{},
+		},
+	}
+	for _, e := range f.Entries {
+		out.Channel.Items = append(out.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			GUID:        rssGUID{Value: e.ID},
+			Author:      e.Author,
+			Description: e.Summary,
+			PubDate:     e.Updated.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return out
+}
+
+// render writes f in the negotiated format. version identifies the feed
+// content, so unchanged feeds are answered with 304 Not Modified.
+func render(c *gin.Context, f Feed, version string) {
+	format := negotiate(c)
+	etag := `W/"` + version + `-` + map[string]string{MIMEAtom: "atom", MIMERSS: "rss"}[format] + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", f.Updated.UTC().Format(http.TimeFormat))
+	// Feeds are only served to API keys, so shared caches must not keep them
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Writer.Header().Add("Vary", "Accept")
+	if match := c.GetHeader("If-None-Match"); match != "" && match == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	var doc interface{} = f.atom()
+	if format == MIMERSS {
+		doc = f.rss()
+	}
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		server.AbortWithProblem(c, server.NewProblem(http.StatusInternalServerError, server.CodeInternal, "Failed to render the feed"))
+		return
+	}
+	c.Data(http.StatusOK, format+"; charset=utf-8", append([]byte(xml.Header), body...))
+}
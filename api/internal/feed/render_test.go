@@ -0,0 +1,42 @@
+package feed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRenderHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := Feed{ID: "urn:test", Title: "Test", Updated: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	router := gin.New()
+	router.GET("/feed", func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		render(c, f, "1")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/feed", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /feed = %d", w.Code)
+	}
+	if got := w.Header().Values("Vary"); !slices.Equal(got, []string{"Accept-Encoding", "Accept"}) {
+		t.Errorf("Vary = %q, want Accept-Encoding kept and Accept added", got)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "private, max-age=300" {
+		t.Errorf("Cache-Control = %q, want private", got)
+	}
+
+	// Revalidation answers with the same headers
+	req := httptest.NewRequest(http.MethodGet, "/feed", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Header().Get("Cache-Control") != "private, max-age=300" {
+		t.Errorf("revalidation = %d with Cache-Control %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+}
@@ -0,0 +1,93 @@
+package gql
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+)
+
+// request is a GraphQL request, sent as a JSON body or as query parameters
+type request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// abortWithError answers with a GraphQL error document
+func abortWithError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"errors": []gin.H{{
+			"message":    message,
+			"extensions": gin.H{"code": code},
+		}},
+	})
+}
+
+// Handler executes GraphQL queries after checking their depth and complexity,
+// so one request cannot fetch far more than the rate limiter intends. charge
+// takes the cost of the query from the caller's rate limit, or answers for
+// itself and returns false when the caller is limited. Rejected requests cost
+// server.SearchCost, so they are not free to send.
+func Handler(schema graphql.Schema, charge func(c *gin.Context, cost int) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reject := func(code, message string) {
+			if charge(c, server.SearchCost) {
+				abortWithError(c, 400, code, message)
+			}
+		}
+
+		var req request
+		if c.Request.Method == "POST" {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				reject("graphql.bad_request", "Invalid GraphQL request body: "+err.Error())
+				return
+			}
+		} else {
+			req.Query = c.Query("query")
+			req.OperationName = c.Query("operationName")
+			if vars := c.Query("variables"); vars != "" {
+				if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+					reject("graphql.bad_request", "Parameter 'variables' must be a JSON object")
+					return
+				}
+			}
+		}
+		if req.Query == "" {
+			reject("graphql.bad_request", "A GraphQL query is required")
+			return
+		}
+
+		doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+		if err != nil {
+			reject("graphql.parse_error", err.Error())
+			return
+		}
+		cost, err := Analyze(doc, req.Variables)
+		if err == nil {
+			err = cost.Check()
+		}
+		if err != nil {
+			reject("graphql.too_complex", err.Error())
+			return
+		}
+		if !charge(c, cost.Requests()) {
+			return
+		}
+		c.Header("X-GraphQL-Depth", strconv.Itoa(cost.Depth))
+		c.Header("X-GraphQL-Complexity", strconv.Itoa(cost.Complexity))
+		c.Header("X-GraphQL-Lookups", strconv.Itoa(cost.Lookups))
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			OperationName:  req.OperationName,
+			Context:        WithLocales(c.Request.Context(), c.GetStringSlice("locales")),
+		})
+		c.JSON(200, result)
+	}
+}
@@ -0,0 +1,59 @@
+package gql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+)
+
+func TestHandlerCharges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	schema, err := NewSchema(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var charged []int
+	allow := true
+	router := gin.New()
+	router.GET("/graphql", Handler(schema, func(c *gin.Context, cost int) bool {
+		charged = append(charged, cost)
+		if !allow {
+			c.AbortWithStatus(http.StatusTooManyRequests)
+		}
+		return allow
+	}))
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(query), nil))
+		return w
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		cost   int
+	}{
+		// No field queries the store, so the query costs the minimum
+		{`{__typename}`, http.StatusOK, server.SearchCost},
+		{`{publishers(first:100){packages(first:0){identifier}}}`, http.StatusBadRequest, server.SearchCost},
+		{`{`, http.StatusBadRequest, server.SearchCost},
+	}
+	for _, tt := range tests {
+		charged = nil
+		w := get(tt.query)
+		if w.Code != tt.status || len(charged) != 1 || charged[0] != tt.cost {
+			t.Errorf("%s = %d charging %v, want %d charging [%d]", tt.query, w.Code, charged, tt.status, tt.cost)
+		}
+	}
+
+	// Limited callers get the answer of charge and nothing runs
+	allow = false
+	if w := get(`{__typename}`); w.Code != http.StatusTooManyRequests || w.Body.Len() != 0 {
+		t.Errorf("limited query = %d %q, want a bare 429", w.Code, w.Body.String())
+	}
+}
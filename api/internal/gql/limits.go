@@ -0,0 +1,243 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+)
+
+const (
+	// listDefault is the page size of package and publisher lists without a first argument
+	listDefault = 20
+	// versionsDefault is the number of versions returned without a first argument
+	versionsDefault = 10
+	// maxListSize caps the first argument of every list field
+	maxListSize = 100
+
+	// MaxDepth is the deepest field nesting a query may select
+	MaxDepth = 8
+	// MaxComplexity is the highest estimated number of resolved fields per query
+	MaxComplexity = 2000
+	// MaxLookups is the most store queries one query may run
+	MaxLookups = 25
+
+	// lookupCost is the complexity of a field that queries the store, whatever
+	// its first argument, so first: 0 does not make a lookup cheap
+	lookupCost = 10
+	// complexityPerRequest is how much complexity one request of the rate
+	// limit pays for
+	complexityPerRequest = 100
+)
+
+// rootLookups are the Query fields resolved by querying the store
+var rootLookups = map[string]bool{
+	"package":    true,
+	"search":     true,
+	"publishers": true,
+	"publisher":  true,
+}
+
+// nestedLookups are the fields below the Query type resolved by querying the
+// store: the packages of a publisher
+var nestedLookups = map[string]bool{
+	"packages": true,
+}
+
+// listSizes estimates how many items a list field without a first argument returns
+var listSizes = map[string]int{
+	"search":     listDefault,
+	"publishers": listDefault,
+	"packages":   listDefault,
+	"versions":   versionsDefault,
+	"locales":    5,
+	"installers": 5,
+}
+
+// Cost is the static analysis of a query document
+type Cost struct {
+	Depth      int
+	Complexity int
+	// Lookups is the most store queries the query may run
+	Lookups int
+}
+
+// analyzer walks a query, resolving fragments and variables
+type analyzer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	defaults  map[string]ast.Value // Default values of the operation's variables
+	visiting  map[string]bool
+}
+
+// Analyze computes the depth and estimated complexity of the operations in doc.
+// Each field costs one and each store lookup lookupCost, and the cost of a
+// list field's children is multiplied by the number of items it may return.
+func Analyze(doc *ast.Document, variables map[string]interface{}) (Cost, error) {
+	a := &analyzer{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		visiting:  make(map[string]bool),
+	}
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			a.fragments[frag.Name.Value] = frag
+		}
+	}
+
+	var total Cost
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		a.defaults = make(map[string]ast.Value)
+		for _, v := range op.VariableDefinitions {
+			if v.DefaultValue != nil {
+				a.defaults[v.Variable.Name.Value] = v.DefaultValue
+			}
+		}
+		cost, err := a.selectionSet(op.SelectionSet, true)
+		if err != nil {
+			return Cost{}, err
+		}
+		if cost.Depth > total.Depth {
+			total.Depth = cost.Depth
+		}
+		total.Complexity += cost.Complexity
+		total.Lookups += cost.Lookups
+	}
+	return total, nil
+}
+
+// Check returns an error when the query exceeds the depth or complexity limits
+func (c Cost) Check() error {
+	if c.Depth > MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", c.Depth, MaxDepth)
+	}
+	if c.Complexity > MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", c.Complexity, MaxComplexity)
+	}
+	if c.Lookups > MaxLookups {
+		return fmt.Errorf("query runs up to %d lookups, more than the limit of %d", c.Lookups, MaxLookups)
+	}
+	return nil
+}
+
+// Requests returns how many requests of the rate limit the query costs: one
+// per complexityPerRequest, and at least server.SearchCost per lookup
+func (c Cost) Requests() int {
+	return max(server.SearchCost, c.Lookups*server.SearchCost, (c.Complexity+complexityPerRequest-1)/complexityPerRequest)
+}
+
+// selectionSet analyzes the fields of set; root tells whether they are fields
+// of the Query type
+func (a *analyzer) selectionSet(set *ast.SelectionSet, root bool) (Cost, error) {
+	var cost Cost
+	if set == nil {
+		return cost, nil
+	}
+
+	for _, selection := range set.Selections {
+		var child Cost
+		var err error
+
+		switch sel := selection.(type) {
+		case *ast.Field:
+			child, err = a.field(sel, root)
+		case *ast.InlineFragment:
+			child, err = a.selectionSet(sel.SelectionSet, root)
+		case *ast.FragmentSpread:
+			name := sel.Name.Value
+			frag, ok := a.fragments[name]
+			if !ok {
+				return Cost{}, fmt.Errorf("unknown fragment %q", name)
+			}
+			if a.visiting[name] {
+				return Cost{}, fmt.Errorf("fragment %q spreads itself", name)
+			}
+			a.visiting[name] = true
+			child, err = a.selectionSet(frag.SelectionSet, root)
+			a.visiting[name] = false
+		}
+		if err != nil {
+			return Cost{}, err
+		}
+
+		if child.Depth > cost.Depth {
+			cost.Depth = child.Depth
+		}
+		cost.Complexity += child.Complexity
+		cost.Lookups += child.Lookups
+	}
+	return cost, nil
+}
+
+func (a *analyzer) field(field *ast.Field, root bool) (Cost, error) {
+	children, err := a.selectionSet(field.SelectionSet, false)
+	if err != nil {
+		return Cost{}, err
+	}
+
+	items, err := a.listSize(field)
+	if err != nil {
+		return Cost{}, err
+	}
+	cost := Cost{
+		Depth:      children.Depth + 1,
+		Complexity: 1 + items*children.Complexity,
+		Lookups:    items * children.Lookups,
+	}
+	if name := field.Name.Value; (root && rootLookups[name]) || (!root && nestedLookups[name]) {
+		cost.Complexity += lookupCost - 1
+		cost.Lookups++
+	}
+	return cost, nil
+}
+
+// listSize returns how many items the field may resolve to
+func (a *analyzer) listSize(field *ast.Field) (int, error) {
+	size, isList := listSizes[field.Name.Value]
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		n, ok := a.intValue(arg.Value)
+		if !ok {
+			continue
+		}
+		if n < 0 {
+			return 0, fmt.Errorf("%s(first: %d) must not be negative", field.Name.Value, n)
+		}
+		if n > maxListSize {
+			return 0, fmt.Errorf("%s(first: %d) exceeds the maximum page size of %d", field.Name.Value, n, maxListSize)
+		}
+		size, isList = n, true
+	}
+	if !isList {
+		return 1, nil
+	}
+	return size, nil
+}
+
+func (a *analyzer) intValue(value ast.Value) (int, bool) {
+	switch v := value.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(v.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch n := a.variables[v.Name.Value].(type) {
+		case float64:
+			return int(n), true
+		case int:
+			return n, true
+		case nil:
+			// Variables left out of the request take their default
+			if def, ok := a.defaults[v.Name.Value]; ok {
+				return a.intValue(def)
+			}
+		}
+	}
+	return 0, false
+}
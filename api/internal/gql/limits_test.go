@@ -0,0 +1,118 @@
+package gql
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestAnalyzeFirst(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		err       string // Part of the expected error, none when empty
+	}{
+		{name: "literal", query: `{search(query:"a",first:50){identifier}}`},
+		{name: "literal over the maximum", query: `{search(query:"a",first:101){identifier}}`, err: "maximum page size"},
+		{
+			name:      "variable over the maximum",
+			query:     `query($n:Int){search(query:"a",first:$n){identifier}}`,
+			variables: map[string]interface{}{"n": float64(100000)},
+			err:       "maximum page size",
+		},
+		{name: "default over the maximum", query: `query($n:Int=100000){search(query:"a",first:$n){identifier}}`, err: "maximum page size"},
+		{name: "negative default", query: `query($n:Int=-1){publishers(first:$n){key}}`, err: "must not be negative"},
+		{
+			name:      "variable overriding the default",
+			query:     `query($n:Int=100000){search(query:"a",first:$n){identifier}}`,
+			variables: map[string]interface{}{"n": float64(10)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Analyze(doc, tt.variables)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("Analyze = %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("Analyze = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	items := make([]int, 300)
+	for i := range items {
+		items[i] = i
+	}
+
+	got, err := window(items, map[string]interface{}{"first": 1000, "offset": 10})
+	if err != nil || len(got) != maxListSize || got[0] != 10 {
+		t.Errorf("window(first: 1000, offset: 10) = %d items, %v, want %d from 10", len(got), err, maxListSize)
+	}
+	if got, err := window(items, map[string]interface{}{"first": 5, "offset": 400}); err != nil || len(got) != 0 {
+		t.Errorf("window past the end = %v, %v", got, err)
+	}
+	if _, err := window(items, map[string]interface{}{"first": 5, "offset": -1}); err == nil {
+		t.Error("window accepted a negative offset")
+	}
+}
+
+func TestAnalyzeLookups(t *testing.T) {
+	aliases := make([]string, 2000)
+	for i := range aliases {
+		aliases[i] = "s" + strconv.Itoa(i) + `:search(query:"a",first:0){identifier}`
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		lookups  int
+		requests int
+		err      string // Part of the expected error, none when empty
+	}{
+		{name: "one package", query: `{package(identifier:"Git.Git"){name}}`, lookups: 1, requests: 3},
+		{name: "publisher field of a package is no lookup", query: `{package(identifier:"Git.Git"){publisher}}`, lookups: 1, requests: 3},
+		{name: "empty page still looks up", query: `{search(query:"a",first:0){identifier}}`, lookups: 1, requests: 3},
+		{name: "fragment on the query type", query: `{...q} fragment q on Query {publisher(key:"microsoft"){name}}`, lookups: 1, requests: 3},
+		{name: "packages of each publisher", query: `{publishers(first:10){packages(first:5){identifier}}}`, lookups: 11, requests: 33},
+		{name: "aliased empty searches", query: "{" + strings.Join(aliases, " ") + "}", err: "complexity 20000"},
+		{name: "a few aliased searches", query: "{" + strings.Join(aliases[:30], " ") + "}", err: "30 lookups"},
+		{name: "empty pages below a long list", query: `{publishers(first:100){packages(first:0){identifier}}}`, err: "lookups"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			cost, err := Analyze(doc, nil)
+			if err == nil {
+				err = cost.Check()
+			}
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Analyze = %+v, %v, want an error containing %q", cost, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Analyze = %v", err)
+			}
+			if cost.Lookups != tt.lookups || cost.Requests() != tt.requests {
+				t.Errorf("Analyze = %d lookups costing %d requests, want %d costing %d", cost.Lookups, cost.Requests(), tt.lookups, tt.requests)
+			}
+			if cost.Complexity < cost.Lookups*lookupCost {
+				t.Errorf("complexity %d is below %d per lookup", cost.Complexity, lookupCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+)
+
+// localesKey carries the caller's preferred locales through the resolver context
+type localesKey struct{}
+
+// WithLocales attaches the preferred locales used to localize packages
+func WithLocales(ctx context.Context, prefs []string) context.Context {
+	return context.WithValue(ctx, localesKey{}, prefs)
+}
+
+func localize(ctx context.Context, packages []models.Package) []models.Package {
+	prefs, _ := ctx.Value(localesKey{}).([]string)
+	for i := range packages {
+		packages[i].Localize(prefs)
+	}
+	return packages
+}
+
+// paging reads the first/offset arguments of a list. Analyze already refuses
+// queries asking for more than maxListSize items, but first is capped here as
+// well so no query the analysis misjudges can page through everything.
+func paging(args map[string]interface{}) (first, offset int, err error) {
+	first, _ = args["first"].(int)
+	offset, _ = args["offset"].(int)
+	if first < 0 {
+		return 0, 0, fmt.Errorf("first: %d must not be negative", first)
+	}
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("offset: %d must not be negative", offset)
+	}
+	return min(first, maxListSize), offset, nil
+}
+
+// window applies first/offset arguments to a list
+func window[T any](items []T, args map[string]interface{}) ([]T, error) {
+	first, offset, err := paging(args)
+	if err != nil {
+		return nil, err
+	}
+	if offset >= len(items) {
+		return []T{}, nil
+	}
+	items = items[offset:]
+	if first < len(items) {
+		items = items[:first]
+	}
+	return items, nil
+}
+
+func pagingArgs(defaultFirst int) graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultFirst},
+		"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+	}
+}
+
+func stringList() *graphql.List { return graphql.NewList(graphql.NewNonNull(graphql.String)) }
+
+// NewSchema builds the GraphQL schema on top of the same store as the REST handlers
+func NewSchema(st *store.Store) (graphql.Schema, error) {
+	localeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Locale",
+		Fields: graphql.Fields{
+			"locale":              &graphql.Field{Type: graphql.String},
+			"publisher":           &graphql.Field{Type: graphql.String},
+			"publisherUrl":        &graphql.Field{Type: graphql.String},
+			"publisherSupportUrl": &graphql.Field{Type: graphql.String},
+			"privacyUrl":          &graphql.Field{Type: graphql.String},
+			"author":              &graphql.Field{Type: graphql.String},
+			"packageName":         &graphql.Field{Type: graphql.String},
+			"packageUrl":          &graphql.Field{Type: graphql.String},
+			"license":             &graphql.Field{Type: graphql.String},
+			"licenseUrl":          &graphql.Field{Type: graphql.String},
+			"copyright":           &graphql.Field{Type: graphql.String},
+			"copyrightUrl":        &graphql.Field{Type: graphql.String},
+			"shortDescription":    &graphql.Field{Type: graphql.String},
+			"description":         &graphql.Field{Type: graphql.String},
+			"moniker":             &graphql.Field{Type: graphql.String},
+			"tags":                &graphql.Field{Type: stringList()},
+			"releaseNotes":        &graphql.Field{Type: graphql.String},
+			"releaseNotesUrl":     &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	switchesType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "InstallerSwitches",
+		Fields: graphql.Fields{
+			"silent":             &graphql.Field{Type: graphql.String},
+			"silentWithProgress": &graphql.Field{Type: graphql.String},
+			"interactive":        &graphql.Field{Type: graphql.String},
+			"installLocation":    &graphql.Field{Type: graphql.String},
+			"log":                &graphql.Field{Type: graphql.String},
+			"upgrade":            &graphql.Field{Type: graphql.String},
+			"custom":             &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	packageDependencyType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PackageDependency",
+		Fields: graphql.Fields{
+			"packageIdentifier": &graphql.Field{Type: graphql.String},
+			"minimumVersion":    &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	dependenciesType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "InstallerDependencies",
+		Fields: graphql.Fields{
+			"windowsFeatures":      &graphql.Field{Type: stringList()},
+			"windowsLibraries":     &graphql.Field{Type: stringList()},
+			"packageDependencies":  &graphql.Field{Type: graphql.NewList(packageDependencyType)},
+			"externalDependencies": &graphql.Field{Type: stringList()},
+		},
+	})
+
+	installerType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Installer",
+		Fields: graphql.Fields{
+			"architecture":      &graphql.Field{Type: graphql.String},
+			"type":              &graphql.Field{Type: graphql.String},
+			"url":               &graphql.Field{Type: graphql.String},
+			"sha256":            &graphql.Field{Type: graphql.String},
+			"scope":             &graphql.Field{Type: graphql.String},
+			"locale":            &graphql.Field{Type: graphql.String},
+			"productCode":       &graphql.Field{Type: graphql.String},
+			"minimumOSVersion":  &graphql.Field{Type: graphql.String},
+			"upgradeBehavior":   &graphql.Field{Type: graphql.String},
+			"packageFamilyName": &graphql.Field{Type: graphql.String},
+			"commands":          &graphql.Field{Type: stringList()},
+			"switches":          &graphql.Field{Type: switchesType},
+			"dependencies":      &graphql.Field{Type: dependenciesType},
+		},
+	})
+
+	versionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Version",
+		Fields: graphql.Fields{
+			"version":       &graphql.Field{Type: graphql.String},
+			"channel":       &graphql.Field{Type: graphql.String},
+			"releaseDate":   &graphql.Field{Type: graphql.String},
+			"defaultLocale": &graphql.Field{Type: graphql.String},
+			"locales":       &graphql.Field{Type: graphql.NewList(localeType)},
+			"installers":    &graphql.Field{Type: graphql.NewList(installerType)},
+		},
+	})
+
+	packageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Package",
+		Fields: graphql.Fields{
+			"identifier":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name":             &graphql.Field{Type: graphql.String},
+			"publisher":        &graphql.Field{Type: graphql.String},
+			"author":           &graphql.Field{Type: graphql.String},
+			"shortDescription": &graphql.Field{Type: graphql.String},
+			"description":      &graphql.Field{Type: graphql.String},
+			"moniker":          &graphql.Field{Type: graphql.String},
+			"tags":             &graphql.Field{Type: stringList()},
+			"license":          &graphql.Field{Type: graphql.String},
+			"homepage":         &graphql.Field{Type: graphql.String},
+			"locale":           &graphql.Field{Type: graphql.String},
+			"latestVersion":    &graphql.Field{Type: graphql.String},
+			"versions": &graphql.Field{
+				Type: graphql.NewList(versionType),
+				Args: pagingArgs(versionsDefault),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					pkg := p.Source.(models.Package)
+					return window(pkg.Versions, p.Args)
+				},
+			},
+			"version": &graphql.Field{
+				Type: versionType,
+				Args: graphql.FieldConfigArgument{
+					"version": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					pkg := p.Source.(models.Package)
+					for _, v := range pkg.Versions {
+						if v.Version == p.Args["version"].(string) {
+							return v, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
+	publisherType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Publisher",
+		Fields: graphql.Fields{
+			"key":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name":         &graphql.Field{Type: graphql.String},
+			"variants":     &graphql.Field{Type: stringList()},
+			"packageCount": &graphql.Field{Type: graphql.Int},
+			"packages": &graphql.Field{
+				Type: graphql.NewList(packageType),
+				Args: pagingArgs(listDefault),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					pub := p.Source.(models.Publisher)
+					first, offset, err := paging(p.Args)
+					if err != nil || first == 0 {
+						return []models.Package{}, err
+					}
+					packages, _, err := st.PackagesByPublisher(p.Context, pub.Key, offset, first)
+					if err != nil {
+						return nil, err
+					}
+					return localize(p.Context, packages), nil
+				},
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"package": &graphql.Field{
+				Type:        packageType,
+				Description: "A package by its exact identifier",
+				Args: graphql.FieldConfigArgument{
+					"identifier": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					pkg, err := st.Package(p.Context, p.Args["identifier"].(string))
+					if errors.Is(err, store.ErrNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					return localize(p.Context, []models.Package{pkg})[0], nil
+				},
+			},
+			"search": &graphql.Field{
+				Type:        graphql.NewList(packageType),
+				Description: "Packages whose name, publisher, short description or author contain the query",
+				Args: graphql.FieldConfigArgument{
+					"query":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: listDefault},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					// Only the requested page is loaded from the store
+					first, offset, err := paging(p.Args)
+					if err != nil || first == 0 {
+						return []models.Package{}, err
+					}
+					packages, _, err := st.FindPage(p.Context, store.SearchFilter(p.Args["query"].(string)), offset, first)
+					if err != nil {
+						return nil, err
+					}
+					return localize(p.Context, packages), nil
+				},
+			},
+			"publishers": &graphql.Field{
+				Type:        graphql.NewList(publisherType),
+				Description: "The publisher directory, spelling variants merged under a normalized key",
+				Args:        pagingArgs(listDefault),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					first, offset, err := paging(p.Args)
+					if err != nil || first == 0 {
+						return []models.Publisher{}, err
+					}
+					publishers, _, err := st.ListPublishers(p.Context, offset, first)
+					return publishers, err
+				},
+			},
+			"publisher": &graphql.Field{
+				Type:        publisherType,
+				Description: "A publisher by name or normalized key",
+				Args: graphql.FieldConfigArgument{
+					"key": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					pub, err := st.Publisher(p.Context, store.NormalizePublisher(p.Args["key"].(string)))
+					if errors.Is(err, store.ErrNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					return pub, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
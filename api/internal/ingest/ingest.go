@@ -0,0 +1,277 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Summary reports what an ingest run changed
+type Summary struct {
+	Manifests int
+	Added     int
+	Updated   int
+	Removed   int
+	Changes   []models.Change
+}
+
+// catalog is the part of the store an ingest run writes through
+type catalog interface {
+	VersionStates(ctx context.Context) ([]store.VersionState, error)
+	PutVersion(ctx context.Context, doc bson.M, hash string, created, updated time.Time) error
+	DeleteVersion(ctx context.Context, identifier, version string, at time.Time) error
+	AppendChanges(ctx context.Context, changes []models.Change) error
+	SetSourceCommit(ctx context.Context, commit string) error
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Ingester syncs the packages collection with a winget-pkgs checkout
+type Ingester struct {
+	st catalog
+}
+
+// New creates an ingester writing through st
+func New(st *store.Store) *Ingester {
+	return &Ingester{st: st}
+}
+
+// packageDelta collects the version level changes of one package
+type packageDelta struct {
+	wasLive   bool
+	isLive    bool
+	name      string
+	publisher string
+	tags      []string
+	versions  models.ChangeVersions
+	puts      []put
+	removed   []store.VersionState
+}
+
+// put is a version to store, created at created
+type put struct {
+	manifest Manifest
+	created  time.Time
+}
+
+// packagePlan is what a run writes for one package: versions to store and
+// remove, and the change event they make
+type packagePlan struct {
+	identifier string
+	puts       []put
+	removed    []store.VersionState
+	// change indexes the package's event in the summary, -1 when only
+	// baseline hashes are written
+	change int
+}
+
+// plan is what a run writes, package by package
+type plan struct {
+	packages []packagePlan
+	summary  Summary
+}
+
+// Run stores new and changed versions, marks versions that disappeared from
+// the checkout as removed and appends one change event per affected package.
+// Each package's versions are written in one transaction with its change
+// event, so a failed run leaves no package half written and no event without
+// its versions. The source commit only moves once every package is written;
+// after a failure the next run picks up the packages left over. On error the
+// summary covers the packages written before it.
+func (in *Ingester) Run(ctx context.Context, manifests []Manifest, commit string) (Summary, error) {
+	now := time.Now().UTC()
+	done := Summary{Manifests: len(manifests)}
+
+	states, err := in.st.VersionStates(ctx)
+	if err != nil {
+		return done, err
+	}
+	p := planRun(states, manifests, now, commit)
+
+	for _, pkg := range p.packages {
+		var changes []models.Change
+		if pkg.change >= 0 {
+			changes = p.summary.Changes[pkg.change : pkg.change+1]
+		}
+		err := in.st.WithTransaction(ctx, func(ctx context.Context) error {
+			for _, v := range pkg.puts {
+				if err := in.st.PutVersion(ctx, v.manifest.Doc, v.manifest.Hash, v.created, now); err != nil {
+					return err
+				}
+			}
+			for _, s := range pkg.removed {
+				if err := in.st.DeleteVersion(ctx, s.Identifier, s.Version, now); err != nil {
+					return err
+				}
+			}
+			return in.st.AppendChanges(ctx, changes)
+		})
+		if err != nil {
+			return done, fmt.Errorf("%s: %w", pkg.identifier, err)
+		}
+		for _, c := range changes {
+			done.Added += len(c.Versions.Added)
+			done.Updated += len(c.Versions.Updated)
+			done.Removed += len(c.Versions.Removed)
+			done.Changes = append(done.Changes, c)
+		}
+	}
+
+	if commit != "" {
+		if err := in.st.SetSourceCommit(ctx, commit); err != nil {
+			return done, err
+		}
+	}
+	return done, nil
+}
+
+// planRun compares the stored versions with the checkout. Versions stored
+// without a content hash, by imports that predate hashing, get one without a
+// change event: their content is the baseline later runs compare against.
+func planRun(states []store.VersionState, manifests []Manifest, now time.Time, commit string) plan {
+	p := plan{summary: Summary{Manifests: len(manifests)}}
+	stored := make(map[string]store.VersionState, len(states))
+	deltas := make(map[string]*packageDelta)
+	delta := func(identifier string) *packageDelta {
+		d, ok := deltas[identifier]
+		if !ok {
+			d = &packageDelta{}
+			deltas[identifier] = d
+		}
+		return d
+	}
+	for _, s := range states {
+		stored[s.Identifier+"\x00"+s.Version] = s
+		d := delta(s.Identifier)
+		d.name, d.publisher, d.tags = s.Name, s.Publisher, s.Tags
+		if s.DeletedAt == nil {
+			d.wasLive = true
+		}
+	}
+
+	seen := make(map[string]bool, len(manifests))
+	for _, m := range manifests {
+		seen[m.key()] = true
+		d := delta(m.Identifier)
+		d.isLive = true
+		d.name, _ = m.Doc["PackageName"].(string)
+		d.publisher, _ = m.Doc["Publisher"].(string)
+		d.tags = stringList(m.Doc["Tags"])
+
+		prev, exists := stored[m.key()]
+		live := exists && prev.DeletedAt == nil
+		if live && prev.ContentHash == m.Hash {
+			continue
+		}
+
+		created := now
+		if exists && prev.CreatedAt != nil {
+			created = *prev.CreatedAt
+		}
+		d.puts = append(d.puts, put{manifest: m, created: created})
+
+		switch {
+		case live && prev.ContentHash == "":
+			// A baseline, not an update
+		case live:
+			d.versions.Updated = append(d.versions.Updated, m.Version)
+			p.summary.Updated++
+		default:
+			d.versions.Added = append(d.versions.Added, m.Version)
+			p.summary.Added++
+		}
+	}
+
+	for key, s := range stored {
+		if s.DeletedAt != nil || seen[key] {
+			continue
+		}
+		d := delta(s.Identifier)
+		d.removed = append(d.removed, s)
+		d.versions.Removed = append(d.versions.Removed, s.Version)
+		p.summary.Removed++
+	}
+
+	p.summary.Changes = changesFrom(deltas, now, commit)
+	events := make(map[string]int, len(p.summary.Changes))
+	for i, c := range p.summary.Changes {
+		events[c.Identifier] = i
+	}
+
+	identifiers := make([]string, 0, len(deltas))
+	for identifier, d := range deltas {
+		if len(d.puts)+len(d.removed) > 0 {
+			identifiers = append(identifiers, identifier)
+		}
+	}
+	sort.Strings(identifiers)
+	for _, identifier := range identifiers {
+		d := deltas[identifier]
+		pkg := packagePlan{identifier: identifier, puts: d.puts, removed: d.removed, change: -1}
+		if i, ok := events[identifier]; ok {
+			pkg.change = i
+		}
+		p.packages = append(p.packages, pkg)
+	}
+	return p
+}
+
+// changesFrom turns per package deltas into change events ordered by identifier
+func changesFrom(deltas map[string]*packageDelta, at time.Time, commit string) []models.Change {
+	identifiers := make([]string, 0, len(deltas))
+	for identifier, d := range deltas {
+		v := d.versions
+		if len(v.Added)+len(v.Updated)+len(v.Removed) > 0 {
+			identifiers = append(identifiers, identifier)
+		}
+	}
+	sort.Strings(identifiers)
+
+	changes := make([]models.Change, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		d := deltas[identifier]
+		change := models.Change{
+			Type:         models.ChangeUpdated,
+			Identifier:   identifier,
+			Name:         d.name,
+			Publisher:    d.publisher,
+			Tags:         d.tags,
+			Versions:     d.versions,
+			Timestamp:    at,
+			SourceCommit: commit,
+		}
+		if change.Tags == nil {
+			change.Tags = []string{}
+		}
+		switch {
+		case !d.wasLive && d.isLive:
+			change.Type = models.ChangeAdded
+		case d.wasLive && !d.isLive:
+			change.Type = models.ChangeRemoved
+		}
+		for _, list := range []*[]string{&change.Versions.Added, &change.Versions.Updated, &change.Versions.Removed} {
+			if *list == nil {
+				*list = []string{}
+			}
+			sort.Slice(*list, func(i, j int) bool { return models.CompareVersions((*list)[i], (*list)[j]) > 0 })
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// stringList reads a YAML list of strings from a merged manifest document
+func stringList(v interface{}) []string {
+	items, _ := v.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
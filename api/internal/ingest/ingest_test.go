@@ -0,0 +1,223 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func manifest(identifier, version, hash string) Manifest {
+	return Manifest{
+		Identifier: identifier,
+		Version:    version,
+		Doc:        bson.M{"PackageIdentifier": identifier, "PackageVersion": version, "PackageName": identifier},
+		Hash:       hash,
+	}
+}
+
+func TestPlanRun(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := now.Add(-24 * time.Hour)
+	states := []store.VersionState{
+		{Identifier: "Git.Git", Version: "2.44.0", ContentHash: "a", CreatedAt: &created},
+		{Identifier: "Git.Git", Version: "2.43.0", ContentHash: "b", CreatedAt: &created},
+		{Identifier: "Mozilla.Firefox", Version: "125.0", CreatedAt: &created},
+		{Identifier: "Old.Tool", Version: "1.0", ContentHash: "c", CreatedAt: &created},
+		{Identifier: "Back.Again", Version: "1.0", ContentHash: "d", CreatedAt: &created, DeletedAt: &created},
+	}
+	manifests := []Manifest{
+		manifest("Git.Git", "2.44.0", "a"),
+		manifest("Git.Git", "2.43.0", "b2"),
+		manifest("Git.Git", "2.45.0", "e"),
+		manifest("Mozilla.Firefox", "125.0", "f"),
+		manifest("Back.Again", "1.0", "d"),
+	}
+
+	p := planRun(states, manifests, now, "abc123")
+
+	var puts []string
+	var removed []store.VersionState
+	for _, pkg := range p.packages {
+		removed = append(removed, pkg.removed...)
+		for _, v := range pkg.puts {
+			puts = append(puts, v.manifest.Identifier+" "+v.manifest.Version)
+			if v.manifest.Version != "2.45.0" && !v.created.Equal(created) {
+				t.Errorf("%s %s keeps created %v, want %v", v.manifest.Identifier, v.manifest.Version, v.created, created)
+			}
+		}
+	}
+	// Packages are written in identifier order; Firefox was stored without a
+	// hash and gets one
+	want := []string{"Back.Again 1.0", "Git.Git 2.43.0", "Git.Git 2.45.0", "Mozilla.Firefox 125.0"}
+	if !reflect.DeepEqual(puts, want) {
+		t.Errorf("puts = %q, want %q", puts, want)
+	}
+	if len(removed) != 1 || removed[0].Identifier != "Old.Tool" {
+		t.Errorf("removed = %+v, want Old.Tool", removed)
+	}
+	for _, pkg := range p.packages {
+		if hasChange := pkg.change >= 0; hasChange != (pkg.identifier != "Mozilla.Firefox") {
+			t.Errorf("%s plans change %d", pkg.identifier, pkg.change)
+		} else if hasChange && p.summary.Changes[pkg.change].Identifier != pkg.identifier {
+			t.Errorf("%s plans the change of %s", pkg.identifier, p.summary.Changes[pkg.change].Identifier)
+		}
+	}
+
+	s := p.summary
+	if s.Manifests != 5 || s.Added != 2 || s.Updated != 1 || s.Removed != 1 {
+		t.Errorf("summary = %d manifests, %d added, %d updated, %d removed, want 5, 2, 1, 1", s.Manifests, s.Added, s.Updated, s.Removed)
+	}
+
+	// The baseline hash of Firefox makes no event
+	changes := map[string]models.Change{}
+	for _, c := range s.Changes {
+		changes[c.Identifier] = c
+		if c.SourceCommit != "abc123" || !c.Timestamp.Equal(now) {
+			t.Errorf("change of %s from commit %q at %v", c.Identifier, c.SourceCommit, c.Timestamp)
+		}
+	}
+	if len(changes) != 3 {
+		t.Fatalf("got changes for %d packages, want Back.Again, Git.Git and Old.Tool: %+v", len(changes), s.Changes)
+	}
+	if c := changes["Git.Git"]; c.Type != models.ChangeUpdated ||
+		!reflect.DeepEqual(c.Versions.Added, []string{"2.45.0"}) || !reflect.DeepEqual(c.Versions.Updated, []string{"2.43.0"}) {
+		t.Errorf("Git.Git change = %+v", c)
+	}
+	if c := changes["Old.Tool"]; c.Type != models.ChangeRemoved || !reflect.DeepEqual(c.Versions.Removed, []string{"1.0"}) {
+		t.Errorf("Old.Tool change = %+v", c)
+	}
+	if c := changes["Back.Again"]; c.Type != models.ChangeAdded || !reflect.DeepEqual(c.Versions.Added, []string{"1.0"}) {
+		t.Errorf("Back.Again change = %+v", c)
+	}
+}
+
+func TestPlanRunUnchanged(t *testing.T) {
+	now := time.Now().UTC()
+	states := []store.VersionState{{Identifier: "Git.Git", Version: "2.44.0", ContentHash: "a"}}
+
+	p := planRun(states, []Manifest{manifest("Git.Git", "2.44.0", "a")}, now, "")
+	if len(p.packages) != 0 || len(p.summary.Changes) != 0 {
+		t.Errorf("unchanged checkout plans %d packages and %d changes", len(p.packages), len(p.summary.Changes))
+	}
+}
+
+// fakeCatalog keeps the writes of committed transactions and drops those of
+// failed ones. PutVersion fails for the identifier in failOn.
+type fakeCatalog struct {
+	states  []store.VersionState
+	failOn  string
+	pending []string
+	written []string
+	changes []models.Change
+	commit  string
+}
+
+func (f *fakeCatalog) VersionStates(ctx context.Context) ([]store.VersionState, error) {
+	return f.states, nil
+}
+
+func (f *fakeCatalog) PutVersion(ctx context.Context, doc bson.M, hash string, created, updated time.Time) error {
+	identifier, _ := doc["PackageIdentifier"].(string)
+	if identifier == f.failOn {
+		return errors.New("write conflict")
+	}
+	f.pending = append(f.pending, "put "+identifier+" "+doc["PackageVersion"].(string))
+	return nil
+}
+
+func (f *fakeCatalog) DeleteVersion(ctx context.Context, identifier, version string, at time.Time) error {
+	f.pending = append(f.pending, "delete "+identifier+" "+version)
+	return nil
+}
+
+func (f *fakeCatalog) AppendChanges(ctx context.Context, changes []models.Change) error {
+	for _, c := range changes {
+		f.pending = append(f.pending, "change "+c.Identifier)
+	}
+	return nil
+}
+
+func (f *fakeCatalog) SetSourceCommit(ctx context.Context, commit string) error {
+	f.commit = commit
+	return nil
+}
+
+func (f *fakeCatalog) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	f.pending = nil
+	if err := fn(ctx); err != nil {
+		f.pending = nil
+		return err
+	}
+	for _, w := range f.pending {
+		f.written = append(f.written, w)
+		if identifier, ok := strings.CutPrefix(w, "change "); ok {
+			f.changes = append(f.changes, models.Change{Sequence: int64(len(f.changes) + 1), Identifier: identifier})
+		}
+	}
+	f.pending = nil
+	return nil
+}
+
+func TestRunWritesPackageByPackage(t *testing.T) {
+	st := &fakeCatalog{states: []store.VersionState{{Identifier: "Old.Tool", Version: "1.0", ContentHash: "c"}}}
+	in := &Ingester{st: st}
+	manifests := []Manifest{manifest("Git.Git", "2.44.0", "a"), manifest("Mozilla.Firefox", "125.0", "b")}
+
+	summary, err := in.Run(t.Context(), manifests, "abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"put Git.Git 2.44.0", "change Git.Git", "put Mozilla.Firefox 125.0", "change Mozilla.Firefox", "delete Old.Tool 1.0", "change Old.Tool"}
+	if !reflect.DeepEqual(st.written, want) {
+		t.Errorf("written = %q, want %q", st.written, want)
+	}
+	if st.commit != "abc123" || summary.Added != 2 || summary.Removed != 1 || len(summary.Changes) != 3 {
+		t.Errorf("commit %q, summary %+v", st.commit, summary)
+	}
+}
+
+func TestRunPartialFailure(t *testing.T) {
+	st := &fakeCatalog{failOn: "Mozilla.Firefox"}
+	in := &Ingester{st: st}
+	manifests := []Manifest{
+		manifest("Git.Git", "2.44.0", "a"),
+		manifest("Mozilla.Firefox", "125.0", "b"),
+		manifest("Zed.Zed", "1.0", "c"),
+	}
+
+	summary, err := in.Run(t.Context(), manifests, "abc123")
+	if err == nil || !strings.Contains(err.Error(), "Mozilla.Firefox") {
+		t.Fatalf("Run = %v, want the error of Mozilla.Firefox", err)
+	}
+	// Packages before the failure are written with their events, the failed
+	// one leaves neither behind and the source commit stays where it was
+	want := []string{"put Git.Git 2.44.0", "change Git.Git"}
+	if !reflect.DeepEqual(st.written, want) {
+		t.Errorf("written = %q, want %q", st.written, want)
+	}
+	if st.commit != "" {
+		t.Errorf("source commit moved to %q after a failed run", st.commit)
+	}
+	if summary.Added != 1 || len(summary.Changes) != 1 || summary.Changes[0].Identifier != "Git.Git" {
+		t.Errorf("summary = %+v, want Git.Git only", summary)
+	}
+
+	// The next run writes what is left
+	st.failOn = ""
+	st.states = []store.VersionState{{Identifier: "Git.Git", Version: "2.44.0", ContentHash: "a"}}
+	st.written = nil
+	if _, err := in.Run(t.Context(), manifests, "abc123"); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"put Mozilla.Firefox 125.0", "change Mozilla.Firefox", "put Zed.Zed 1.0", "change Zed.Zed"}
+	if !reflect.DeepEqual(st.written, want) || st.commit != "abc123" {
+		t.Errorf("retry wrote %q and moved the commit to %q", st.written, st.commit)
+	}
+}
@@ -0,0 +1,150 @@
+package ingest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest is one package version merged from its multi-file YAML manifest
+type Manifest struct {
+	Identifier string
+	Version    string
+	Doc        bson.M
+	// Hash of the merged document, used to detect changed versions
+	Hash string
+}
+
+// key identifies a package version across runs
+func (m Manifest) key() string {
+	return m.Identifier + "\x00" + m.Version
+}
+
+// LoadManifests reads every version directory below root, which may be a
+// winget-pkgs checkout or its manifests directory
+func LoadManifests(root string) ([]Manifest, error) {
+	if info, err := os.Stat(filepath.Join(root, "manifests")); err == nil && info.IsDir() {
+		root = filepath.Join(root, "manifests")
+	}
+
+	dirs := make(map[string][]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".yaml") {
+			dir := filepath.Dir(path)
+			dirs[dir] = append(dirs[dir], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []Manifest
+	for dir, files := range dirs {
+		sort.Strings(files)
+		m, err := mergeFiles(files)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		manifests = append(manifests, m)
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].key() < manifests[j].key() })
+	return manifests, nil
+}
+
+// mergeFiles folds the version, locale and installer files of one version
+// into the single document shape stored in the packages collection
+func mergeFiles(files []string) (Manifest, error) {
+	doc := bson.M{}
+	var locales []interface{}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Manifest{}, err
+		}
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return Manifest{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		fields, ok := convert(&node).(bson.M)
+		if !ok {
+			return Manifest{}, fmt.Errorf("%s: not a manifest", filepath.Base(path))
+		}
+
+		manifestType, _ := fields["ManifestType"].(string)
+		delete(fields, "ManifestType")
+		if manifestType == "locale" {
+			locales = append(locales, fields)
+			continue
+		}
+		for k, v := range fields {
+			doc[k] = v
+		}
+	}
+	if len(locales) > 0 {
+		doc["Locales"] = locales
+	}
+
+	identifier, _ := doc["PackageIdentifier"].(string)
+	version, _ := doc["PackageVersion"].(string)
+	if identifier == "" || version == "" {
+		return Manifest{}, fmt.Errorf("missing PackageIdentifier or PackageVersion")
+	}
+
+	// json.Marshal sorts map keys, so the hash does not depend on file order
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return Manifest{}, err
+	}
+	sum := sha256.Sum256(raw)
+	return Manifest{Identifier: identifier, Version: version, Doc: doc, Hash: hex.EncodeToString(sum[:])}, nil
+}
+
+// convert turns a YAML node into BSON friendly values. Scalars stay strings
+// apart from booleans and nulls, so versions such as 1.10 are not read as numbers.
+func convert(node *yaml.Node) interface{} {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return convert(node.Content[0])
+	case yaml.MappingNode:
+		m := bson.M{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			m[node.Content[i].Value] = convert(node.Content[i+1])
+		}
+		return m
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			list = append(list, convert(item))
+		}
+		return list
+	case yaml.AliasNode:
+		return convert(node.Alias)
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return nil
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err == nil {
+			return b
+		}
+	}
+	return node.Value
+}
@@ -0,0 +1,92 @@
+package locale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parse reads an Accept-Language header (or a comma separated locale list)
+// and returns the language tags ordered by preference
+func Parse(header string) []string {
+	type weighted struct {
+		tag    string
+		q      float64
+		offset int
+	}
+
+	var tags []weighted
+	for i, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: Canonical(tag), q: q, offset: i})
+	}
+
+	// Highest quality first, keeping header order for equal weights
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	prefs := make([]string, 0, len(tags))
+	for _, t := range tags {
+		prefs = append(prefs, t.tag)
+	}
+	return prefs
+}
+
+// Canonical formats a language tag the way winget manifests spell them ("en-US")
+func Canonical(tag string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"), "-")
+	for i, p := range parts {
+		if i == 0 {
+			parts[i] = strings.ToLower(p)
+		} else if len(p) == 2 {
+			parts[i] = strings.ToUpper(p)
+		} else if len(p) == 4 {
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		} else {
+			parts[i] = strings.ToLower(p)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// base returns the primary language subtag ("en" for "en-US")
+func base(tag string) string {
+	if i := strings.Index(tag, "-"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// Match picks the best available locale for the given preferences.
+// An exact tag wins, then a locale sharing the primary language.
+func Match(prefs, available []string) (string, bool) {
+	for _, pref := range prefs {
+		for _, tag := range available {
+			if strings.EqualFold(pref, tag) {
+				return tag, true
+			}
+		}
+		for _, tag := range available {
+			if strings.EqualFold(base(pref), base(tag)) {
+				return tag, true
+			}
+		}
+	}
+	return "", false
+}
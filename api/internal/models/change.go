@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Change types reported by the change feed
+const (
+	ChangeAdded   = "added"
+	ChangeUpdated = "updated"
+	ChangeRemoved = "removed"
+)
+
+// Change is one entry of the change feed: what happened to a package during
+// an ingest run. Sequence numbers increase strictly in feed order.
+type Change struct {
+	Sequence     int64          `bson:"_id" json:"sequence"`
+	Type         string         `bson:"type" json:"type"`
+	Identifier   string         `bson:"identifier" json:"identifier"`
+	Name         string         `bson:"name" json:"name"`
+	Publisher    string         `bson:"publisher" json:"publisher"`
+	Tags         []string       `bson:"tags" json:"tags"`
+	Versions     ChangeVersions `bson:"versions" json:"versions"`
+	Timestamp    time.Time      `bson:"timestamp" json:"timestamp"`
+	SourceCommit string         `bson:"sourceCommit" json:"sourceCommit"`
+}
+
+// ChangeVersions lists the versions touched by a change
+type ChangeVersions struct {
+	Added   []string `bson:"added" json:"added"`
+	Updated []string `bson:"updated" json:"updated"`
+	Removed []string `bson:"removed" json:"removed"`
+}
@@ -0,0 +1,305 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/locale"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Manifest is a merged winget manifest as stored in the packages collection,
+// one document per package version. The default locale fields live at the top
+// level and other locale manifests in the Locales array. Installer fields at
+// the top level are defaults for every entry in Installers.
+type Manifest struct {
+	ManifestLocale `bson:",inline"`
+
+	PackageIdentifier string              `bson:"PackageIdentifier"`
+	PackageVersion    string              `bson:"PackageVersion"`
+	Channel           string              `bson:"Channel"`
+	DefaultLocale     string              `bson:"DefaultLocale"`
+	ReleaseDate       interface{}         `bson:"ReleaseDate"`
+	Locales           []ManifestLocale    `bson:"Locales"`
+	Installers        []ManifestInstaller `bson:"Installers"`
+
+	InstallerType     string               `bson:"InstallerType"`
+	InstallerLocale   string               `bson:"InstallerLocale"`
+	Scope             string               `bson:"Scope"`
+	MinimumOSVersion  string               `bson:"MinimumOSVersion"`
+	UpgradeBehavior   string               `bson:"UpgradeBehavior"`
+	InstallerSwitches ManifestSwitches     `bson:"InstallerSwitches"`
+	Dependencies      ManifestDependencies `bson:"Dependencies"`
+	PackageFamilyName string               `bson:"PackageFamilyName"`
+	Commands          []string             `bson:"Commands"`
+
+	// Set by the ingest job; documents loaded before it ran have none
+	CreatedAt *time.Time `bson:"createdAt,omitempty"`
+	UpdatedAt *time.Time `bson:"updatedAt,omitempty"`
+}
+
+// ManifestLocale is a stored locale manifest
+type ManifestLocale struct {
+	PackageLocale       string   `bson:"PackageLocale"`
+	Publisher           string   `bson:"Publisher"`
+	PublisherUrl        string   `bson:"PublisherUrl"`
+	PublisherSupportUrl string   `bson:"PublisherSupportUrl"`
+	PrivacyUrl          string   `bson:"PrivacyUrl"`
+	Author              string   `bson:"Author"`
+	PackageName         string   `bson:"PackageName"`
+	PackageUrl          string   `bson:"PackageUrl"`
+	License             string   `bson:"License"`
+	LicenseUrl          string   `bson:"LicenseUrl"`
+	Copyright           string   `bson:"Copyright"`
+	CopyrightUrl        string   `bson:"CopyrightUrl"`
+	ShortDescription    string   `bson:"ShortDescription"`
+	Description         string   `bson:"Description"`
+	Moniker             string   `bson:"Moniker"`
+	Tags                []string `bson:"Tags"`
+	ReleaseNotes        string   `bson:"ReleaseNotes"`
+	ReleaseNotesUrl     string   `bson:"ReleaseNotesUrl"`
+}
+
+// ManifestInstaller is a stored installer entry
+type ManifestInstaller struct {
+	Architecture      string               `bson:"Architecture"`
+	InstallerType     string               `bson:"InstallerType"`
+	InstallerUrl      string               `bson:"InstallerUrl"`
+	InstallerSha256   string               `bson:"InstallerSha256"`
+	Scope             string               `bson:"Scope"`
+	InstallerLocale   string               `bson:"InstallerLocale"`
+	ProductCode       string               `bson:"ProductCode"`
+	MinimumOSVersion  string               `bson:"MinimumOSVersion"`
+	UpgradeBehavior   string               `bson:"UpgradeBehavior"`
+	InstallerSwitches ManifestSwitches     `bson:"InstallerSwitches"`
+	Dependencies      ManifestDependencies `bson:"Dependencies"`
+	PackageFamilyName string               `bson:"PackageFamilyName"`
+	Commands          []string             `bson:"Commands"`
+}
+
+// ManifestSwitches are the stored installer switches
+type ManifestSwitches struct {
+	Silent             string `bson:"Silent"`
+	SilentWithProgress string `bson:"SilentWithProgress"`
+	Interactive        string `bson:"Interactive"`
+	InstallLocation    string `bson:"InstallLocation"`
+	Log                string `bson:"Log"`
+	Upgrade            string `bson:"Upgrade"`
+	Custom             string `bson:"Custom"`
+}
+
+// ManifestDependencies are the stored installer dependencies
+type ManifestDependencies struct {
+	WindowsFeatures      []string `bson:"WindowsFeatures"`
+	WindowsLibraries     []string `bson:"WindowsLibraries"`
+	ExternalDependencies []string `bson:"ExternalDependencies"`
+	PackageDependencies  []struct {
+		PackageIdentifier string `bson:"PackageIdentifier"`
+		MinimumVersion    string `bson:"MinimumVersion"`
+	} `bson:"PackageDependencies"`
+}
+
+// FromManifests groups version manifests into packages, keeping the order in
+// which identifiers first appear and sorting each package's versions newest first
+func FromManifests(manifests []Manifest) []Package {
+	packages := []Package{}
+	index := make(map[string]int)
+	for _, m := range manifests {
+		i, ok := index[m.PackageIdentifier]
+		if !ok {
+			i = len(packages)
+			index[m.PackageIdentifier] = i
+			packages = append(packages, Package{Identifier: m.PackageIdentifier, Versions: []Version{}})
+		}
+		packages[i].Versions = append(packages[i].Versions, m.Version())
+		packages[i].track(m.CreatedAt, m.UpdatedAt)
+	}
+
+	for i := range packages {
+		p := &packages[i]
+		sort.SliceStable(p.Versions, func(a, b int) bool {
+			return CompareVersions(p.Versions[a].Version, p.Versions[b].Version) > 0
+		})
+		p.LatestVersion = p.Versions[0].Version
+		p.Localize(nil)
+	}
+	return packages
+}
+
+// track widens the package's created and updated times to cover a version
+func (p *Package) track(created, updated *time.Time) {
+	if created != nil && (p.CreatedAt == nil || created.Before(*p.CreatedAt)) {
+		p.CreatedAt = created
+	}
+	if updated != nil && (p.UpdatedAt == nil || updated.After(*p.UpdatedAt)) {
+		p.UpdatedAt = updated
+	}
+}
+
+// Version converts a stored manifest into its response model
+func (m Manifest) Version() Version {
+	defaultLocale := m.DefaultLocale
+	if defaultLocale == "" {
+		defaultLocale = m.PackageLocale
+	}
+	if defaultLocale == "" {
+		defaultLocale = "en-US"
+	}
+	defaultLocale = locale.Canonical(defaultLocale)
+
+	v := Version{
+		Version:       m.PackageVersion,
+		Channel:       m.Channel,
+		ReleaseDate:   formatDate(m.ReleaseDate),
+		DefaultLocale: defaultLocale,
+		Locales:       []Locale{m.ManifestLocale.toLocale(defaultLocale)},
+		Installers:    []Installer{},
+	}
+	for _, l := range m.Locales {
+		if l.PackageLocale == "" || locale.Canonical(l.PackageLocale) == defaultLocale {
+			continue
+		}
+		v.Locales = append(v.Locales, l.toLocale(locale.Canonical(l.PackageLocale)))
+	}
+	for _, inst := range m.Installers {
+		v.Installers = append(v.Installers, m.installer(inst))
+	}
+	return v
+}
+
+func (l ManifestLocale) toLocale(tag string) Locale {
+	return Locale{
+		Locale:              tag,
+		Publisher:           l.Publisher,
+		PublisherURL:        l.PublisherUrl,
+		PublisherSupportURL: l.PublisherSupportUrl,
+		PrivacyURL:          l.PrivacyUrl,
+		Author:              l.Author,
+		PackageName:         l.PackageName,
+		PackageURL:          l.PackageUrl,
+		License:             l.License,
+		LicenseURL:          l.LicenseUrl,
+		Copyright:           l.Copyright,
+		CopyrightURL:        l.CopyrightUrl,
+		ShortDescription:    l.ShortDescription,
+		Description:         l.Description,
+		Moniker:             l.Moniker,
+		Tags:                nonNil(l.Tags),
+		ReleaseNotes:        l.ReleaseNotes,
+		ReleaseNotesURL:     l.ReleaseNotesUrl,
+	}
+}
+
+// installer merges an installer entry with the manifest level defaults
+func (m Manifest) installer(inst ManifestInstaller) Installer {
+	pick := func(value, fallback string) string {
+		if value != "" {
+			return value
+		}
+		return fallback
+	}
+
+	switches := inst.InstallerSwitches
+	defaults := m.InstallerSwitches
+	deps := inst.Dependencies
+	if len(deps.WindowsFeatures)+len(deps.WindowsLibraries)+len(deps.ExternalDependencies)+len(deps.PackageDependencies) == 0 {
+		deps = m.Dependencies
+	}
+
+	commands := inst.Commands
+	if len(commands) == 0 {
+		commands = m.Commands
+	}
+
+	packageDeps := []PackageDependency{}
+	for _, d := range deps.PackageDependencies {
+		packageDeps = append(packageDeps, PackageDependency{PackageIdentifier: d.PackageIdentifier, MinimumVersion: d.MinimumVersion})
+	}
+
+	return Installer{
+		Architecture:      inst.Architecture,
+		Type:              pick(inst.InstallerType, m.InstallerType),
+		URL:               inst.InstallerUrl,
+		Sha256:            inst.InstallerSha256,
+		Scope:             pick(inst.Scope, m.Scope),
+		Locale:            pick(inst.InstallerLocale, m.InstallerLocale),
+		ProductCode:       inst.ProductCode,
+		MinimumOSVersion:  pick(inst.MinimumOSVersion, m.MinimumOSVersion),
+		UpgradeBehavior:   pick(inst.UpgradeBehavior, m.UpgradeBehavior),
+		PackageFamilyName: pick(inst.PackageFamilyName, m.PackageFamilyName),
+		Commands:          nonNil(commands),
+		Switches: InstallerSwitches{
+			Silent:             pick(switches.Silent, defaults.Silent),
+			SilentWithProgress: pick(switches.SilentWithProgress, defaults.SilentWithProgress),
+			Interactive:        pick(switches.Interactive, defaults.Interactive),
+			InstallLocation:    pick(switches.InstallLocation, defaults.InstallLocation),
+			Log:                pick(switches.Log, defaults.Log),
+			Upgrade:            pick(switches.Upgrade, defaults.Upgrade),
+			Custom:             pick(switches.Custom, defaults.Custom),
+		},
+		Dependencies: InstallerDependencies{
+			WindowsFeatures:      nonNil(deps.WindowsFeatures),
+			WindowsLibraries:     nonNil(deps.WindowsLibraries),
+			PackageDependencies:  packageDeps,
+			ExternalDependencies: nonNil(deps.ExternalDependencies),
+		},
+	}
+}
+
+// formatDate renders a stored release date, which may be a string or a BSON date
+func formatDate(v interface{}) string {
+	switch d := v.(type) {
+	case string:
+		return d
+	case bson.DateTime:
+		return d.Time().UTC().Format("2006-01-02")
+	case time.Time:
+		return d.UTC().Format("2006-01-02")
+	}
+	return ""
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// CompareVersions orders winget version strings, comparing dot separated
+// parts numerically when possible ("1.10" > "1.9")
+func CompareVersions(a, b string) int {
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		x, y := "0", "0"
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		nx, errX := strconv.Atoi(x)
+		ny, errY := strconv.Atoi(y)
+		switch {
+		case x == y:
+			continue
+		case errX == nil && errY == nil:
+			if nx != ny {
+				if nx < ny {
+					return -1
+				}
+				return 1
+			}
+		case x == "":
+			return -1
+		case y == "":
+			return 1
+		default:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/locale"
+)
+
+// Package is a winget package with its versions, newest first. The top level
+// descriptive fields come from the latest version in the selected locale.
+type Package struct {
+	Identifier       string    `json:"identifier"`
+	Name             string    `json:"name"`
+	Publisher        string    `json:"publisher"`
+	Author           string    `json:"author"`
+	ShortDescription string    `json:"shortDescription"`
+	Description      string    `json:"description"`
+	Moniker          string    `json:"moniker"`
+	Tags             []string  `json:"tags"`
+	License          string    `json:"license"`
+	Homepage         string    `json:"homepage"`
+	Locale           string    `json:"locale"`
+	LatestVersion    string    `json:"latestVersion"`
+	Versions         []Version `json:"versions"`
+	// First and last time the ingest job saw a version of the package change
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// Version is one published version of a package
+type Version struct {
+	Version       string      `json:"version"`
+	Channel       string      `json:"channel"`
+	ReleaseDate   string      `json:"releaseDate"`
+	DefaultLocale string      `json:"defaultLocale"`
+	Locales       []Locale    `json:"locales"`
+	Installers    []Installer `json:"installers"`
+}
+
+// Locale holds the localized manifest fields of a version
+type Locale struct {
+	Locale              string   `json:"locale"`
+	Publisher           string   `json:"publisher"`
+	PublisherURL        string   `json:"publisherUrl"`
+	PublisherSupportURL string   `json:"publisherSupportUrl"`
+	PrivacyURL          string   `json:"privacyUrl"`
+	Author              string   `json:"author"`
+	PackageName         string   `json:"packageName"`
+	PackageURL          string   `json:"packageUrl"`
+	License             string   `json:"license"`
+	LicenseURL          string   `json:"licenseUrl"`
+	Copyright           string   `json:"copyright"`
+	CopyrightURL        string   `json:"copyrightUrl"`
+	ShortDescription    string   `json:"shortDescription"`
+	Description         string   `json:"description"`
+	Moniker             string   `json:"moniker"`
+	Tags                []string `json:"tags"`
+	ReleaseNotes        string   `json:"releaseNotes"`
+	ReleaseNotesURL     string   `json:"releaseNotesUrl"`
+}
+
+// Installer is a single installer entry of a version
+type Installer struct {
+	Architecture      string                `json:"architecture"`
+	Type              string                `json:"type"`
+	URL               string                `json:"url"`
+	Sha256            string                `json:"sha256"`
+	Scope             string                `json:"scope"`
+	Locale            string                `json:"locale"`
+	ProductCode       string                `json:"productCode"`
+	MinimumOSVersion  string                `json:"minimumOSVersion"`
+	UpgradeBehavior   string                `json:"upgradeBehavior"`
+	PackageFamilyName string                `json:"packageFamilyName"`
+	Commands          []string              `json:"commands"`
+	Switches          InstallerSwitches     `json:"switches"`
+	Dependencies      InstallerDependencies `json:"dependencies"`
+}
+
+// InstallerSwitches are the command line switches passed to an installer
+type InstallerSwitches struct {
+	Silent             string `json:"silent"`
+	SilentWithProgress string `json:"silentWithProgress"`
+	Interactive        string `json:"interactive"`
+	InstallLocation    string `json:"installLocation"`
+	Log                string `json:"log"`
+	Upgrade            string `json:"upgrade"`
+	Custom             string `json:"custom"`
+}
+
+// InstallerDependencies lists what must be present before installing
+type InstallerDependencies struct {
+	WindowsFeatures      []string            `json:"windowsFeatures"`
+	WindowsLibraries     []string            `json:"windowsLibraries"`
+	PackageDependencies  []PackageDependency `json:"packageDependencies"`
+	ExternalDependencies []string            `json:"externalDependencies"`
+}
+
+// PackageDependency is another winget package required by an installer
+type PackageDependency struct {
+	PackageIdentifier string `json:"packageIdentifier"`
+	MinimumVersion    string `json:"minimumVersion"`
+}
+
+// Publisher is a publisher directory entry, merged across spelling variants
+type Publisher struct {
+	Key          string   `json:"key"`
+	Name         string   `json:"name"`
+	Variants     []string `json:"variants"`
+	PackageCount int      `json:"packageCount"`
+}
+
+// LocaleFor returns the version's manifest fields for the best matching
+// locale. Fields missing from a locale manifest fall back to the default locale.
+func (v Version) LocaleFor(prefs []string) Locale {
+	var fallback Locale
+	available := make([]string, 0, len(v.Locales))
+	for _, l := range v.Locales {
+		available = append(available, l.Locale)
+		if l.Locale == v.DefaultLocale {
+			fallback = l
+		}
+	}
+
+	selected, ok := locale.Match(prefs, available)
+	if !ok || selected == v.DefaultLocale {
+		return fallback
+	}
+	for _, l := range v.Locales {
+		if l.Locale == selected {
+			return l.withFallback(fallback)
+		}
+	}
+	return fallback
+}
+
+// withFallback fills empty fields of l from the default locale manifest
+func (l Locale) withFallback(d Locale) Locale {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&l.Publisher, d.Publisher)
+	fill(&l.PublisherURL, d.PublisherURL)
+	fill(&l.PublisherSupportURL, d.PublisherSupportURL)
+	fill(&l.PrivacyURL, d.PrivacyURL)
+	fill(&l.Author, d.Author)
+	fill(&l.PackageName, d.PackageName)
+	fill(&l.PackageURL, d.PackageURL)
+	fill(&l.License, d.License)
+	fill(&l.LicenseURL, d.LicenseURL)
+	fill(&l.Copyright, d.Copyright)
+	fill(&l.CopyrightURL, d.CopyrightURL)
+	fill(&l.ShortDescription, d.ShortDescription)
+	fill(&l.Description, d.Description)
+	fill(&l.Moniker, d.Moniker)
+	fill(&l.ReleaseNotes, d.ReleaseNotes)
+	fill(&l.ReleaseNotesURL, d.ReleaseNotesURL)
+	if len(l.Tags) == 0 {
+		l.Tags = d.Tags
+	}
+	return l
+}
+
+// Localize sets the package level fields from the latest version in the best
+// matching locale and returns the locale that was used
+func (p *Package) Localize(prefs []string) string {
+	if len(p.Versions) == 0 {
+		return p.Locale
+	}
+	l := p.Versions[0].LocaleFor(prefs)
+	p.Name = l.PackageName
+	p.Publisher = l.Publisher
+	p.Author = l.Author
+	p.ShortDescription = l.ShortDescription
+	p.Description = l.Description
+	p.Moniker = l.Moniker
+	p.Tags = l.Tags
+	p.License = l.License
+	p.Homepage = l.PackageURL
+	p.Locale = l.Locale
+	return p.Locale
+}
@@ -0,0 +1,252 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+)
+
+// Document is the OpenAPI 3.1 description of every route served under the base URL
+//
+//go:embed openapi.json
+var Document []byte
+
+// Spec is the subset of the OpenAPI document used for route checks and request validation
+type Spec struct {
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components struct {
+		Parameters map[string]Parameter `json:"parameters"`
+	} `json:"components"`
+}
+
+// Operation is a single method on a path
+type Operation struct {
+	OperationID string      `json:"operationId"`
+	Parameters  []Parameter `json:"parameters"`
+}
+
+// Parameter is an operation parameter, or a reference to a shared one
+type Parameter struct {
+	Ref      string `json:"$ref"`
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+	Schema   Schema `json:"schema"`
+}
+
+// Schema holds the parameter constraints the validator understands
+type Schema struct {
+	Type    string        `json:"type"`
+	Minimum *float64      `json:"minimum"`
+	Maximum *float64      `json:"maximum"`
+	Enum    []interface{} `json:"enum"`
+}
+
+// Load parses the embedded document and resolves shared parameters
+func Load() (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(Document, &spec); err != nil {
+		return nil, fmt.Errorf("parse openapi.json: %w", err)
+	}
+
+	for path, methods := range spec.Paths {
+		for method, op := range methods {
+			for i, param := range op.Parameters {
+				if param.Ref == "" {
+					continue
+				}
+				name := strings.TrimPrefix(param.Ref, "#/components/parameters/")
+				shared, ok := spec.Components.Parameters[name]
+				if !ok {
+					return nil, fmt.Errorf("%s %s: unknown parameter %s", strings.ToUpper(method), path, param.Ref)
+				}
+				op.Parameters[i] = shared
+			}
+			methods[method] = op
+		}
+	}
+	return &spec, nil
+}
+
+// Handler serves the OpenAPI document
+func Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(200, "application/json", Document)
+	}
+}
+
+var ginParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+// specPath turns a Gin route below prefix into its OpenAPI path template
+// ("/api/v1/publishers/:publisher/packages" -> "/publishers/{publisher}/packages")
+func specPath(route, prefix string) string {
+	path := strings.TrimPrefix(route, prefix)
+	return ginParam.ReplaceAllString(path, "{$1}")
+}
+
+var templateParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Queries lists the query parameters each route reads, keyed by method and
+// Gin path ("GET /api/v1/search"). Every route needs an entry, empty when it
+// reads none, so a new route cannot be checked against an omission.
+type Queries map[string][]string
+
+// CheckRoutes compares the routes registered under prefix with the spec and
+// describes every route missing from the spec, every documented operation
+// without a route and every path or query parameter that differs between the
+// two. Query parameters are taken from queries, and routes queries has no
+// entry for are reported too.
+func (s *Spec) CheckRoutes(routes gin.RoutesInfo, queries Queries, prefix string) []string {
+	var problems []string
+	registered := make(map[string]bool)
+
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, prefix) {
+			continue
+		}
+		path := specPath(route.Path, prefix)
+		method := strings.ToLower(route.Method)
+		registered[method+" "+path] = true
+		registered[route.Method+" "+route.Path] = true
+
+		op, ok := s.Paths[path][method]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s %s is registered but not documented", route.Method, route.Path))
+			continue
+		}
+
+		var declared []string
+		for _, m := range templateParam.FindAllStringSubmatch(path, -1) {
+			declared = append(declared, m[1])
+		}
+		if documented := op.parameters("path"); !sameNames(declared, documented) {
+			problems = append(problems, fmt.Sprintf("%s %s declares path parameters [%s] but the spec documents [%s]",
+				route.Method, route.Path, strings.Join(declared, ", "), strings.Join(documented, ", ")))
+		}
+
+		read, listed := queries[route.Method+" "+route.Path]
+		if !listed {
+			problems = append(problems, fmt.Sprintf("%s %s is registered but its query parameters are not listed", route.Method, route.Path))
+			continue
+		}
+		if documented := op.parameters("query"); !sameNames(read, documented) {
+			problems = append(problems, fmt.Sprintf("%s %s reads query parameters [%s] but the spec documents [%s]",
+				route.Method, route.Path, strings.Join(sorted(read), ", "), strings.Join(documented, ", ")))
+		}
+	}
+
+	for path, methods := range s.Paths {
+		for method := range methods {
+			if !registered[method+" "+path] {
+				problems = append(problems, fmt.Sprintf("%s %s is documented but not registered", strings.ToUpper(method), path))
+			}
+		}
+	}
+	for route := range queries {
+		if !registered[route] {
+			problems = append(problems, fmt.Sprintf("%s lists query parameters but is not registered", route))
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// parameters returns the sorted names of the operation's parameters in the
+// given location
+func (op Operation) parameters(in string) []string {
+	var names []string
+	for _, param := range op.Parameters {
+		if param.In == in {
+			names = append(names, param.Name)
+		}
+	}
+	return sorted(names)
+}
+
+func sorted(names []string) []string {
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+	return names
+}
+
+func sameNames(a, b []string) bool {
+	return strings.Join(sorted(a), ",") == strings.Join(sorted(b), ",")
+}
+
+// Validate rejects requests whose query parameters break the documented constraints
+func (s *Spec) Validate(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op, ok := s.Paths[specPath(c.FullPath(), prefix)][strings.ToLower(c.Request.Method)]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		for _, param := range op.Parameters {
+			if param.In != "query" {
+				continue
+			}
+			value := strings.TrimSpace(c.Query(param.Name))
+			if value == "" {
+				if param.Required {
+					server.MissingParam(c, param.Name)
+					return
+				}
+				continue
+			}
+			if reason := param.Schema.check(value); reason != "" {
+				server.InvalidParam(c, param.Name, reason)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// check returns why value does not satisfy the schema, or "" if it does
+func (sc Schema) check(value string) string {
+	switch sc.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || (sc.Type == "integer" && n != float64(int64(n))) {
+			return "must be " + article(sc.Type)
+		}
+		if sc.Minimum != nil && n < *sc.Minimum {
+			return fmt.Sprintf("must be at least %v", *sc.Minimum)
+		}
+		if sc.Maximum != nil && n > *sc.Maximum {
+			return fmt.Sprintf("must be at most %v", *sc.Maximum)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be true or false"
+		}
+	}
+
+	if len(sc.Enum) > 0 {
+		var allowed []string
+		for _, e := range sc.Enum {
+			allowed = append(allowed, fmt.Sprint(e))
+			if fmt.Sprint(e) == value {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(allowed, ", ")
+	}
+	return ""
+}
+
+func article(typ string) string {
+	if typ == "integer" {
+		return "an integer"
+	}
+	return "a " + typ
+}
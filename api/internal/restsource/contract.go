@@ -0,0 +1,154 @@
+package restsource
+
+// Types of the WinGet.RestSource contract. Field names follow the contract,
+// which winget clients parse case-sensitively.
+
+// ServerSupportedVersions lists the contract versions this source implements
+var ServerSupportedVersions = []string{"1.0.0", "1.1.0"}
+
+// Information is the body of GET /information
+type Information struct {
+	SourceIdentifier              string   `json:"SourceIdentifier"`
+	ServerSupportedVersions       []string `json:"ServerSupportedVersions"`
+	UnsupportedPackageMatchFields []string `json:"UnsupportedPackageMatchFields"`
+	RequiredPackageMatchFields    []string `json:"RequiredPackageMatchFields"`
+	UnsupportedQueryParameters    []string `json:"UnsupportedQueryParameters"`
+	RequiredQueryParameters       []string `json:"RequiredQueryParameters"`
+}
+
+// SearchRequest is the body of POST /manifestSearch
+type SearchRequest struct {
+	MaximumResults    int                  `json:"MaximumResults"`
+	FetchAllManifests bool                 `json:"FetchAllManifests"`
+	Query             *RequestMatch        `json:"Query"`
+	Inclusions        []PackageMatchFilter `json:"Inclusions"`
+	Filters           []PackageMatchFilter `json:"Filters"`
+}
+
+// RequestMatch is a keyword with the way it has to match
+type RequestMatch struct {
+	KeyWord   string `json:"KeyWord"`
+	MatchType string `json:"MatchType"`
+}
+
+// PackageMatchFilter restricts a request match to one manifest field
+type PackageMatchFilter struct {
+	PackageMatchField string       `json:"PackageMatchField"`
+	RequestMatch      RequestMatch `json:"RequestMatch"`
+}
+
+// SearchResponse is the body of a successful POST /manifestSearch
+type SearchResponse struct {
+	Data                          []SearchResult `json:"Data"`
+	RequiredPackageMatchFields    []string       `json:"RequiredPackageMatchFields"`
+	UnsupportedPackageMatchFields []string       `json:"UnsupportedPackageMatchFields"`
+	ContinuationToken             string         `json:"ContinuationToken,omitempty"`
+}
+
+// SearchResult is one package of a search response
+type SearchResult struct {
+	PackageIdentifier string          `json:"PackageIdentifier"`
+	PackageName       string          `json:"PackageName"`
+	Publisher         string          `json:"Publisher"`
+	Versions          []SearchVersion `json:"Versions"`
+}
+
+// SearchVersion is a version summary of a search result
+type SearchVersion struct {
+	PackageVersion     string   `json:"PackageVersion"`
+	Channel            string   `json:"Channel,omitempty"`
+	PackageFamilyNames []string `json:"PackageFamilyNames"`
+	ProductCodes       []string `json:"ProductCodes"`
+}
+
+// ManifestResponse is the body of GET /packageManifests/{PackageIdentifier}
+type ManifestResponse struct {
+	Data              PackageManifest `json:"Data"`
+	ContinuationToken string          `json:"ContinuationToken,omitempty"`
+}
+
+// PackageManifest is a package with all its version manifests
+type PackageManifest struct {
+	PackageIdentifier string            `json:"PackageIdentifier"`
+	Versions          []VersionManifest `json:"Versions"`
+}
+
+// VersionManifest is the merged manifest of one version
+type VersionManifest struct {
+	PackageVersion string           `json:"PackageVersion"`
+	Channel        string           `json:"Channel,omitempty"`
+	DefaultLocale  LocaleManifest   `json:"DefaultLocale"`
+	Locales        []LocaleManifest `json:"Locales"`
+	Installers     []Installer      `json:"Installers"`
+}
+
+// LocaleManifest holds the localized fields of a version
+type LocaleManifest struct {
+	PackageLocale       string   `json:"PackageLocale"`
+	Publisher           string   `json:"Publisher,omitempty"`
+	PublisherUrl        string   `json:"PublisherUrl,omitempty"`
+	PublisherSupportUrl string   `json:"PublisherSupportUrl,omitempty"`
+	PrivacyUrl          string   `json:"PrivacyUrl,omitempty"`
+	Author              string   `json:"Author,omitempty"`
+	PackageName         string   `json:"PackageName,omitempty"`
+	PackageUrl          string   `json:"PackageUrl,omitempty"`
+	License             string   `json:"License,omitempty"`
+	LicenseUrl          string   `json:"LicenseUrl,omitempty"`
+	Copyright           string   `json:"Copyright,omitempty"`
+	CopyrightUrl        string   `json:"CopyrightUrl,omitempty"`
+	ShortDescription    string   `json:"ShortDescription,omitempty"`
+	Description         string   `json:"Description,omitempty"`
+	Moniker             string   `json:"Moniker,omitempty"`
+	Tags                []string `json:"Tags,omitempty"`
+	ReleaseNotes        string   `json:"ReleaseNotes,omitempty"`
+	ReleaseNotesUrl     string   `json:"ReleaseNotesUrl,omitempty"`
+}
+
+// Installer is an installer entry of a version manifest
+type Installer struct {
+	InstallerIdentifier string             `json:"InstallerIdentifier"`
+	InstallerSha256     string             `json:"InstallerSha256"`
+	InstallerUrl        string             `json:"InstallerUrl"`
+	Architecture        string             `json:"Architecture"`
+	InstallerLocale     string             `json:"InstallerLocale,omitempty"`
+	InstallerType       string             `json:"InstallerType"`
+	Scope               string             `json:"Scope,omitempty"`
+	ProductCode         string             `json:"ProductCode,omitempty"`
+	PackageFamilyName   string             `json:"PackageFamilyName,omitempty"`
+	MinimumOSVersion    string             `json:"MinimumOSVersion,omitempty"`
+	UpgradeBehavior     string             `json:"UpgradeBehavior,omitempty"`
+	Commands            []string           `json:"Commands,omitempty"`
+	InstallerSwitches   *InstallerSwitches `json:"InstallerSwitches,omitempty"`
+	Dependencies        *Dependencies      `json:"Dependencies,omitempty"`
+}
+
+// InstallerSwitches are the switches passed to an installer
+type InstallerSwitches struct {
+	Silent             string `json:"Silent,omitempty"`
+	SilentWithProgress string `json:"SilentWithProgress,omitempty"`
+	Interactive        string `json:"Interactive,omitempty"`
+	InstallLocation    string `json:"InstallLocation,omitempty"`
+	Log                string `json:"Log,omitempty"`
+	Upgrade            string `json:"Upgrade,omitempty"`
+	Custom             string `json:"Custom,omitempty"`
+}
+
+// Dependencies of an installer
+type Dependencies struct {
+	WindowsFeatures      []string            `json:"WindowsFeatures,omitempty"`
+	WindowsLibraries     []string            `json:"WindowsLibraries,omitempty"`
+	PackageDependencies  []PackageDependency `json:"PackageDependencies,omitempty"`
+	ExternalDependencies []string            `json:"ExternalDependencies,omitempty"`
+}
+
+// PackageDependency is another package required by an installer
+type PackageDependency struct {
+	PackageIdentifier string `json:"PackageIdentifier"`
+	MinimumVersion    string `json:"MinimumVersion,omitempty"`
+}
+
+// Error is the error body defined by the contract
+type Error struct {
+	ErrorCode    int    `json:"ErrorCode"`
+	ErrorMessage string `json:"ErrorMessage"`
+}
@@ -0,0 +1,150 @@
+package restsource
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// pageSize is the number of packages returned per manifestSearch page
+const pageSize = 50
+
+// catalog is the part of the store the endpoints read, which tests replace
+// with fixtures
+type catalog interface {
+	FindPage(ctx context.Context, filter bson.M, skip, limit int) ([]models.Package, int, error)
+	Package(ctx context.Context, identifier string) (models.Package, error)
+}
+
+// Register adds the WinGet.RestSource endpoints to group, so winget clients
+// can use the API as a source:
+//
+//	winget source add -n wingetpkg -t Microsoft.Rest -a https://host/api/v1/winget --header "<api key>"
+func Register(group *gin.RouterGroup, st *store.Store, sourceIdentifier string) {
+	register(group, st, sourceIdentifier)
+}
+
+func register(group *gin.RouterGroup, st catalog, sourceIdentifier string) {
+	group.GET("/information", informationHandler(sourceIdentifier))
+	group.POST("/manifestSearch", manifestSearchHandler(st))
+	group.GET("/packageManifests/:PackageIdentifier", packageManifestHandler(st))
+}
+
+// abortWithError answers with the contract's error format
+func abortWithError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, Error{ErrorCode: status, ErrorMessage: message})
+}
+
+func informationHandler(sourceIdentifier string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(200, gin.H{"Data": Information{
+			SourceIdentifier:              sourceIdentifier,
+			ServerSupportedVersions:       ServerSupportedVersions,
+			UnsupportedPackageMatchFields: UnsupportedMatchFields,
+			RequiredPackageMatchFields:    []string{},
+			UnsupportedQueryParameters:    []string{"Market"},
+			RequiredQueryParameters:       []string{},
+		}})
+	}
+}
+
+func manifestSearchHandler(st catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SearchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abortWithError(c, 400, "Invalid search request: "+err.Error())
+			return
+		}
+
+		offset, err := decodeToken(c.GetHeader("ContinuationToken"))
+		if err != nil {
+			abortWithError(c, 400, err.Error())
+			return
+		}
+
+		filter, unsupported, err := req.Filter()
+		if err != nil {
+			abortWithError(c, 400, err.Error())
+			return
+		}
+
+		limit := pageSize
+		if req.MaximumResults > 0 {
+			if offset >= req.MaximumResults {
+				c.Status(204)
+				return
+			}
+			if req.MaximumResults-offset < limit {
+				limit = req.MaximumResults - offset
+			}
+		}
+
+		packages, total, err := st.FindPage(context.TODO(), filter, offset, limit)
+		if err != nil {
+			abortWithError(c, 500, "Failed to search packages")
+			return
+		}
+		if len(packages) == 0 {
+			c.Status(204)
+			return
+		}
+
+		resp := SearchResponse{
+			Data:                          make([]SearchResult, 0, len(packages)),
+			RequiredPackageMatchFields:    []string{},
+			UnsupportedPackageMatchFields: unsupported,
+		}
+		if resp.UnsupportedPackageMatchFields == nil {
+			resp.UnsupportedPackageMatchFields = []string{}
+		}
+		for _, p := range packages {
+			resp.Data = append(resp.Data, searchResult(p))
+		}
+
+		next := offset + len(packages)
+		if next < total && (req.MaximumResults == 0 || next < req.MaximumResults) {
+			resp.ContinuationToken = encodeToken(next)
+		}
+		c.JSON(200, resp)
+	}
+}
+
+func packageManifestHandler(st catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pkg, err := st.Package(context.TODO(), c.Param("PackageIdentifier"))
+		if errors.Is(err, store.ErrNotFound) {
+			abortWithError(c, 404, "Package not found")
+			return
+		}
+		if err != nil {
+			abortWithError(c, 500, "Failed to load package")
+			return
+		}
+
+		manifest := packageManifest(pkg)
+
+		// Optional Version and Channel query parameters narrow the versions
+		version, channel := c.Query("Version"), c.Query("Channel")
+		if version != "" || channel != "" {
+			var versions []VersionManifest
+			for _, v := range manifest.Versions {
+				if (version == "" || strings.EqualFold(v.PackageVersion, version)) &&
+					(channel == "" || strings.EqualFold(v.Channel, channel)) {
+					versions = append(versions, v)
+				}
+			}
+			if len(versions) == 0 {
+				abortWithError(c, 404, "Version not found")
+				return
+			}
+			manifest.Versions = versions
+		}
+
+		c.JSON(200, ManifestResponse{Data: manifest})
+	}
+}
@@ -0,0 +1,460 @@
+package restsource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden responses in testdata")
+
+// fixtureCatalog serves the version documents of testdata/manifests.json,
+// evaluating the subset of MongoDB filters the endpoints build. It only
+// approximates MongoDB; search_test.go checks the filter documents themselves.
+type fixtureCatalog struct {
+	docs []map[string]interface{}
+}
+
+func loadCatalog(t *testing.T) *fixtureCatalog {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "manifests.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cat fixtureCatalog
+	if err := json.Unmarshal(data, &cat.docs); err != nil {
+		t.Fatal(err)
+	}
+	return &cat
+}
+
+// add appends a generated package with a single version
+func (cat *fixtureCatalog) add(identifier, name string) {
+	cat.docs = append(cat.docs, map[string]interface{}{
+		"PackageIdentifier": identifier,
+		"PackageVersion":    "1.0.0",
+		"DefaultLocale":     "en-US",
+		"PackageLocale":     "en-US",
+		"PackageName":       name,
+	})
+}
+
+func (cat *fixtureCatalog) find(filter bson.M) ([]models.Package, error) {
+	var manifests []models.Manifest
+	for _, doc := range cat.docs {
+		if !matches(doc, filter) {
+			continue
+		}
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		var m models.Manifest
+		if err := bson.Unmarshal(raw, &m); err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, m)
+	}
+	packages := models.FromManifests(manifests)
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Identifier < packages[j].Identifier })
+	return packages, nil
+}
+
+// FindPage implements catalog
+func (cat *fixtureCatalog) FindPage(ctx context.Context, filter bson.M, skip, limit int) ([]models.Package, int, error) {
+	packages, err := cat.find(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := len(packages)
+	if skip >= total {
+		return []models.Package{}, total, nil
+	}
+	return packages[skip:min(skip+limit, total)], total, nil
+}
+
+// Package implements catalog
+func (cat *fixtureCatalog) Package(ctx context.Context, identifier string) (models.Package, error) {
+	packages, err := cat.find(store.PackageFilter(identifier))
+	if err != nil {
+		return models.Package{}, err
+	}
+	if len(packages) == 0 {
+		return models.Package{}, store.ErrNotFound
+	}
+	return packages[0], nil
+}
+
+// matches evaluates $and, $or, and field conditions using $regex or $in,
+// where a dotted field matches if any value along its path does
+func matches(doc map[string]interface{}, filter bson.M) bool {
+	for key, cond := range filter {
+		switch key {
+		case "$and":
+			for _, f := range cond.([]bson.M) {
+				if !matches(doc, f) {
+					return false
+				}
+			}
+		case "$or":
+			matched := false
+			for _, f := range cond.([]bson.M) {
+				matched = matched || matches(doc, f)
+			}
+			if !matched {
+				return false
+			}
+		default:
+			if !matchesValue(lookup(doc, strings.Split(key, ".")), cond.(bson.M)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchesValue(values []string, cond bson.M) bool {
+	if in, ok := cond["$in"].([]string); ok {
+		for _, v := range values {
+			for _, want := range in {
+				if v == want {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	expr := cond["$regex"].(string)
+	if cond["$options"] == "i" {
+		expr = "(?i)" + expr
+	}
+	re := regexp.MustCompile(expr)
+	for _, v := range values {
+		if re.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the strings at path in value, descending into arrays
+func lookup(value interface{}, path []string) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		var out []string
+		for _, item := range v {
+			out = append(out, lookup(item, path)...)
+		}
+		return out
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return nil
+		}
+		return lookup(v[path[0]], path[1:])
+	case string:
+		if len(path) == 0 {
+			return []string{v}
+		}
+	}
+	return nil
+}
+
+func newTestRouter(cat catalog) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	register(router.Group("/winget"), cat, "test-source")
+	return router
+}
+
+// serve sends a request to router and returns the response
+func serve(router *gin.Engine, method, target string, body interface{}, header http.Header) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// checkGolden compares a JSON body with testdata/name, rewriting the file
+// when the tests run with -update
+func checkGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err != nil {
+			t.Fatal(err)
+		}
+		indented.WriteByte('\n')
+		if err := os.WriteFile(path, indented.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, expected interface{}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("invalid JSON body: %v", err)
+	}
+	if err := json.Unmarshal(want, &expected); err != nil {
+		t.Fatalf("invalid %s: %v", path, err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("body differs from %s, run with -update to see how:\n%s", path, body)
+	}
+}
+
+func TestInformation(t *testing.T) {
+	w := serve(newTestRouter(loadCatalog(t)), "GET", "/winget/information", nil, nil)
+	if w.Code != 200 {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	checkGolden(t, "information.json", w.Body.Bytes())
+}
+
+// searchIdentifiers returns the identifiers of a search response
+func searchIdentifiers(t *testing.T, w *httptest.ResponseRecorder) []string {
+	t.Helper()
+	if w.Code == 204 {
+		return nil
+	}
+	if w.Code != 200 {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var resp SearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range resp.Data {
+		ids = append(ids, r.PackageIdentifier)
+	}
+	return ids
+}
+
+func TestManifestSearchMatchTypes(t *testing.T) {
+	router := newTestRouter(loadCatalog(t))
+	filter := func(field, keyword, matchType string) SearchRequest {
+		return SearchRequest{Filters: []PackageMatchFilter{{
+			PackageMatchField: field,
+			RequestMatch:      RequestMatch{KeyWord: keyword, MatchType: matchType},
+		}}}
+	}
+
+	tests := []struct {
+		name string
+		req  SearchRequest
+		want []string
+	}{
+		{"exact", filter("PackageIdentifier", "Microsoft.PowerToys", "Exact"), []string{"Microsoft.PowerToys"}},
+		{"exact is case sensitive", filter("PackageIdentifier", "microsoft.powertoys", "Exact"), nil},
+		{"exact is the whole value", filter("PackageIdentifier", "Microsoft.Power", "Exact"), nil},
+		{"case insensitive", filter("PackageIdentifier", "microsoft.powertoys", "CaseInsensitive"), []string{"Microsoft.PowerToys"}},
+		{"case insensitive is the whole value", filter("PackageIdentifier", "microsoft.power", "CaseInsensitive"), nil},
+		{"starts with", filter("PackageIdentifier", "microsoft.power", "StartsWith"), []string{"Microsoft.PowerShell", "Microsoft.PowerToys"}},
+		{"starts with anchors the start", filter("PackageIdentifier", "PowerToys", "StartsWith"), nil},
+		{"substring", filter("PackageName", "fire", "Substring"), []string{"Mozilla.Firefox"}},
+		{"substring quotes the keyword", filter("PackageIdentifier", "Git.", "Substring"), []string{"Git.Git"}},
+		{"moniker", filter("Moniker", "pwsh", "Exact"), []string{"Microsoft.PowerShell"}},
+		{"tag", filter("Tag", "cli", "Exact"), []string{"Git.Git", "Microsoft.PowerShell"}},
+		{"installer command", filter("Command", "git", "Exact"), []string{"Git.Git"}},
+		{"installer product code", filter("ProductCode", "{2B4D6F8A-1C3E-4A5B-9D7F-0E2C4A6B8D1F}", "CaseInsensitive"), []string{"Microsoft.PowerToys"}},
+		{
+			name: "query",
+			req:  SearchRequest{Query: &RequestMatch{KeyWord: "power", MatchType: "Substring"}},
+			want: []string{"Microsoft.PowerShell", "Microsoft.PowerToys"},
+		},
+		{
+			name: "query narrowed by a filter",
+			req: SearchRequest{
+				Query:   &RequestMatch{KeyWord: "power", MatchType: "Substring"},
+				Filters: filter("Tag", "utilities", "Exact").Filters,
+			},
+			want: []string{"Microsoft.PowerToys"},
+		},
+		{
+			name: "inclusions",
+			req: SearchRequest{Inclusions: []PackageMatchFilter{
+				{PackageMatchField: "Moniker", RequestMatch: RequestMatch{KeyWord: "git", MatchType: "Exact"}},
+				{PackageMatchField: "Moniker", RequestMatch: RequestMatch{KeyWord: "firefox", MatchType: "Exact"}},
+			}},
+			want: []string{"Git.Git", "Mozilla.Firefox"},
+		},
+		{"maximum results", SearchRequest{MaximumResults: 2}, []string{"Git.Git", "Microsoft.PowerShell"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := searchIdentifiers(t, serve(router, "POST", "/winget/manifestSearch", tt.req, nil))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("found %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManifestSearchReportsUnsupportedFields(t *testing.T) {
+	router := newTestRouter(loadCatalog(t))
+	req := SearchRequest{Filters: []PackageMatchFilter{
+		{PackageMatchField: "Market", RequestMatch: RequestMatch{KeyWord: "US", MatchType: "Exact"}},
+		{PackageMatchField: "PackageIdentifier", RequestMatch: RequestMatch{KeyWord: "Git.Git", MatchType: "Exact"}},
+	}}
+	w := serve(router, "POST", "/winget/manifestSearch", req, nil)
+	if w.Code != 200 {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var resp SearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.UnsupportedPackageMatchFields, []string{"Market"}) {
+		t.Errorf("UnsupportedPackageMatchFields = %q, want [Market]", resp.UnsupportedPackageMatchFields)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].PackageIdentifier != "Git.Git" {
+		t.Errorf("Data = %+v, want Git.Git alone", resp.Data)
+	}
+}
+
+func TestManifestSearchErrors(t *testing.T) {
+	router := newTestRouter(loadCatalog(t))
+	tests := []struct {
+		name   string
+		body   interface{}
+		header http.Header
+	}{
+		{"unknown match type", SearchRequest{Query: &RequestMatch{KeyWord: "git", MatchType: "Regex"}}, nil},
+		{"malformed body", "not a search request", nil},
+		{"malformed token", SearchRequest{}, http.Header{"Continuationtoken": {"not-a-token"}}},
+		{"negative token", SearchRequest{}, http.Header{"Continuationtoken": {encodeToken(-1)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, "POST", "/winget/manifestSearch", tt.body, tt.header)
+			if w.Code != 400 {
+				t.Fatalf("status %d, want 400: %s", w.Code, w.Body)
+			}
+			var e Error
+			if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.ErrorCode != 400 || e.ErrorMessage == "" {
+				t.Errorf("error body %s does not follow the contract", w.Body)
+			}
+		})
+	}
+}
+
+func TestManifestSearchContinuation(t *testing.T) {
+	cat := loadCatalog(t)
+	for i := range pageSize + 10 {
+		cat.add(fmt.Sprintf("Example.Package%03d", i), "Example package")
+	}
+	router := newTestRouter(cat)
+	req := SearchRequest{Query: &RequestMatch{KeyWord: "Example", MatchType: "StartsWith"}}
+
+	w := serve(router, "POST", "/winget/manifestSearch", req, nil)
+	first := searchIdentifiers(t, w)
+	var resp SearchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(first) != pageSize || resp.ContinuationToken == "" {
+		t.Fatalf("first page has %d results and token %q, want %d and a token", len(first), resp.ContinuationToken, pageSize)
+	}
+	if offset, err := decodeToken(resp.ContinuationToken); err != nil || offset != pageSize {
+		t.Fatalf("token decodes to %d, %v, want %d", offset, err, pageSize)
+	}
+
+	w = serve(router, "POST", "/winget/manifestSearch", req, http.Header{"Continuationtoken": {resp.ContinuationToken}})
+	second := searchIdentifiers(t, w)
+	resp = SearchResponse{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(second) != 10 || resp.ContinuationToken != "" {
+		t.Errorf("last page has %d results and token %q, want 10 and none", len(second), resp.ContinuationToken)
+	}
+	if len(second) > 0 && second[0] != "Example.Package050" {
+		t.Errorf("last page starts at %s, want Example.Package050", second[0])
+	}
+
+	// A token at or past MaximumResults ends the search
+	req.MaximumResults = pageSize
+	w = serve(router, "POST", "/winget/manifestSearch", req, http.Header{"Continuationtoken": {encodeToken(pageSize)}})
+	if w.Code != 204 {
+		t.Errorf("status %d past MaximumResults, want 204", w.Code)
+	}
+}
+
+func TestPackageManifests(t *testing.T) {
+	router := newTestRouter(loadCatalog(t))
+
+	w := serve(router, "GET", "/winget/packageManifests/Microsoft.PowerToys", nil, nil)
+	if w.Code != 200 {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	checkGolden(t, "powertoys.json", w.Body.Bytes())
+
+	versions := func(target string) []string {
+		t.Helper()
+		w := serve(router, "GET", target, nil, nil)
+		if w.Code != 200 {
+			t.Fatalf("%s: status %d: %s", target, w.Code, w.Body)
+		}
+		var resp ManifestResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, v := range resp.Data.Versions {
+			out = append(out, v.PackageVersion)
+		}
+		return out
+	}
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{"/winget/packageManifests/mozilla.firefox", []string{"126.0b5", "125.0.1"}},
+		{"/winget/packageManifests/Microsoft.PowerToys?Version=0.80.0", []string{"0.80.0"}},
+		{"/winget/packageManifests/Mozilla.Firefox?Channel=BETA", []string{"126.0b5"}},
+	}
+	for _, tt := range tests {
+		if got := versions(tt.target); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s has versions %q, want %q", tt.target, got, tt.want)
+		}
+	}
+
+	for _, target := range []string{
+		"/winget/packageManifests/Example.Missing",
+		"/winget/packageManifests/Microsoft.PowerToys?Version=1.0.0",
+		"/winget/packageManifests/Git.Git?Channel=beta",
+	} {
+		w := serve(router, "GET", target, nil, nil)
+		var e Error
+		if w.Code != 404 || json.Unmarshal(w.Body.Bytes(), &e) != nil || e.ErrorCode != 404 {
+			t.Errorf("%s: status %d with %s, want a 404 contract error", target, w.Code, w.Body)
+		}
+	}
+}
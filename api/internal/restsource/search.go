@@ -0,0 +1,255 @@
+package restsource
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// matchFields maps each supported PackageMatchField onto the stored document fields
+var matchFields = map[string][]string{
+	"PackageIdentifier": {"PackageIdentifier"},
+	"PackageName":       {"PackageName", "Locales.PackageName"},
+	"Moniker":           {"Moniker"},
+	"Tag":               {"Tags", "Locales.Tags"},
+	"Command":           {"Commands", "Installers.Commands"},
+	"PackageFamilyName": {"PackageFamilyName", "Installers.PackageFamilyName"},
+	"ProductCode":       {"ProductCode", "Installers.ProductCode"},
+}
+
+// queryFields are searched by the free text Query of a search request
+var queryFields = []string{"PackageIdentifier", "PackageName", "Moniker", "Tag", "Command", "PackageFamilyName", "ProductCode"}
+
+// UnsupportedMatchFields are the contract's match fields this source cannot filter on
+var UnsupportedMatchFields = []string{"NormalizedPackageNameAndPublisher", "Market"}
+
+// pattern builds the regular expression implementing a match type
+func pattern(match RequestMatch) (bson.M, error) {
+	keyword := regexp.QuoteMeta(match.KeyWord)
+	switch match.MatchType {
+	case "Exact":
+		return bson.M{"$regex": "^" + keyword + "$"}, nil
+	case "CaseInsensitive":
+		return bson.M{"$regex": "^" + keyword + "$", "$options": "i"}, nil
+	case "StartsWith":
+		return bson.M{"$regex": "^" + keyword, "$options": "i"}, nil
+	case "", "Substring", "Wildcard", "Fuzzy", "FuzzySubstring":
+		// Fuzzy matching is approximated by a case-insensitive substring match
+		return bson.M{"$regex": keyword, "$options": "i"}, nil
+	}
+	return nil, fmt.Errorf("unsupported MatchType %q", match.MatchType)
+}
+
+// fieldFilter matches one PackageMatchField against a request match
+func fieldFilter(field string, match RequestMatch) (bson.M, bool, error) {
+	docFields, ok := matchFields[field]
+	if !ok {
+		return nil, false, nil
+	}
+	re, err := pattern(match)
+	if err != nil {
+		return nil, true, err
+	}
+	var alternatives []bson.M
+	for _, f := range docFields {
+		alternatives = append(alternatives, bson.M{f: re})
+	}
+	return bson.M{"$or": alternatives}, true, nil
+}
+
+// Filter turns a search request into a MongoDB filter: the Query or any of the
+// Inclusions must match, and every Filter must match. Filters on unsupported
+// fields are ignored and returned so the response can report them.
+func (r SearchRequest) Filter() (bson.M, []string, error) {
+	var unsupported []string
+	var include []bson.M
+
+	if r.Query != nil && r.Query.KeyWord != "" {
+		for _, field := range queryFields {
+			f, _, err := fieldFilter(field, *r.Query)
+			if err != nil {
+				return nil, nil, err
+			}
+			include = append(include, f)
+		}
+	}
+	for _, inc := range r.Inclusions {
+		f, ok, err := fieldFilter(inc.PackageMatchField, inc.RequestMatch)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			unsupported = append(unsupported, inc.PackageMatchField)
+			continue
+		}
+		include = append(include, f)
+	}
+
+	var all []bson.M
+	if len(include) > 0 {
+		all = append(all, bson.M{"$or": include})
+	}
+	for _, flt := range r.Filters {
+		f, ok, err := fieldFilter(flt.PackageMatchField, flt.RequestMatch)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			unsupported = append(unsupported, flt.PackageMatchField)
+			continue
+		}
+		all = append(all, f)
+	}
+
+	if len(all) == 0 {
+		return bson.M{}, unsupported, nil
+	}
+	return bson.M{"$and": all}, unsupported, nil
+}
+
+// encodeToken and decodeToken wrap the result offset in an opaque continuation token
+func encodeToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func decodeToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("malformed continuation token")
+	}
+	var offset int
+	if _, err := fmt.Sscanf(string(raw), "offset:%d", &offset); err != nil || offset < 0 {
+		return 0, fmt.Errorf("malformed continuation token")
+	}
+	return offset, nil
+}
+
+// searchResult summarizes a package for a search response
+func searchResult(p models.Package) SearchResult {
+	result := SearchResult{
+		PackageIdentifier: p.Identifier,
+		PackageName:       p.Name,
+		Publisher:         p.Publisher,
+		Versions:          []SearchVersion{},
+	}
+	for _, v := range p.Versions {
+		sv := SearchVersion{
+			PackageVersion:     v.Version,
+			Channel:            v.Channel,
+			PackageFamilyNames: []string{},
+			ProductCodes:       []string{},
+		}
+		seen := map[string]bool{}
+		for _, inst := range v.Installers {
+			if inst.PackageFamilyName != "" && !seen["pfn:"+inst.PackageFamilyName] {
+				seen["pfn:"+inst.PackageFamilyName] = true
+				sv.PackageFamilyNames = append(sv.PackageFamilyNames, inst.PackageFamilyName)
+			}
+			if inst.ProductCode != "" && !seen["pc:"+inst.ProductCode] {
+				seen["pc:"+inst.ProductCode] = true
+				sv.ProductCodes = append(sv.ProductCodes, inst.ProductCode)
+			}
+		}
+		result.Versions = append(result.Versions, sv)
+	}
+	return result
+}
+
+// packageManifest converts a package into the contract's manifest format
+func packageManifest(p models.Package) PackageManifest {
+	manifest := PackageManifest{PackageIdentifier: p.Identifier, Versions: []VersionManifest{}}
+	for _, v := range p.Versions {
+		vm := VersionManifest{
+			PackageVersion: v.Version,
+			Channel:        v.Channel,
+			Locales:        []LocaleManifest{},
+			Installers:     []Installer{},
+		}
+		for _, l := range v.Locales {
+			if l.Locale == v.DefaultLocale {
+				vm.DefaultLocale = localeManifest(l)
+			} else {
+				vm.Locales = append(vm.Locales, localeManifest(l))
+			}
+		}
+		for i, inst := range v.Installers {
+			vm.Installers = append(vm.Installers, installer(inst, i))
+		}
+		manifest.Versions = append(manifest.Versions, vm)
+	}
+	return manifest
+}
+
+func localeManifest(l models.Locale) LocaleManifest {
+	return LocaleManifest{
+		PackageLocale:       l.Locale,
+		Publisher:           l.Publisher,
+		PublisherUrl:        l.PublisherURL,
+		PublisherSupportUrl: l.PublisherSupportURL,
+		PrivacyUrl:          l.PrivacyURL,
+		Author:              l.Author,
+		PackageName:         l.PackageName,
+		PackageUrl:          l.PackageURL,
+		License:             l.License,
+		LicenseUrl:          l.LicenseURL,
+		Copyright:           l.Copyright,
+		CopyrightUrl:        l.CopyrightURL,
+		ShortDescription:    l.ShortDescription,
+		Description:         l.Description,
+		Moniker:             l.Moniker,
+		Tags:                l.Tags,
+		ReleaseNotes:        l.ReleaseNotes,
+		ReleaseNotesUrl:     l.ReleaseNotesURL,
+	}
+}
+
+func installer(inst models.Installer, index int) Installer {
+	out := Installer{
+		// The contract needs an identifier that is unique within the version
+		InstallerIdentifier: fmt.Sprintf("%s-%s-%d", inst.Architecture, inst.Type, index),
+		InstallerSha256:     inst.Sha256,
+		InstallerUrl:        inst.URL,
+		Architecture:        inst.Architecture,
+		InstallerLocale:     inst.Locale,
+		InstallerType:       inst.Type,
+		Scope:               inst.Scope,
+		ProductCode:         inst.ProductCode,
+		PackageFamilyName:   inst.PackageFamilyName,
+		MinimumOSVersion:    inst.MinimumOSVersion,
+		UpgradeBehavior:     inst.UpgradeBehavior,
+		Commands:            inst.Commands,
+	}
+
+	if sw := inst.Switches; sw != (models.InstallerSwitches{}) {
+		out.InstallerSwitches = &InstallerSwitches{
+			Silent:             sw.Silent,
+			SilentWithProgress: sw.SilentWithProgress,
+			Interactive:        sw.Interactive,
+			InstallLocation:    sw.InstallLocation,
+			Log:                sw.Log,
+			Upgrade:            sw.Upgrade,
+			Custom:             sw.Custom,
+		}
+	}
+
+	deps := inst.Dependencies
+	if len(deps.WindowsFeatures)+len(deps.WindowsLibraries)+len(deps.PackageDependencies)+len(deps.ExternalDependencies) > 0 {
+		out.Dependencies = &Dependencies{
+			WindowsFeatures:      deps.WindowsFeatures,
+			WindowsLibraries:     deps.WindowsLibraries,
+			ExternalDependencies: deps.ExternalDependencies,
+		}
+		for _, d := range deps.PackageDependencies {
+			out.Dependencies.PackageDependencies = append(out.Dependencies.PackageDependencies,
+				PackageDependency{PackageIdentifier: d.PackageIdentifier, MinimumVersion: d.MinimumVersion})
+		}
+	}
+	return out
+}
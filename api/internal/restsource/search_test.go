@@ -0,0 +1,160 @@
+package restsource
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// These tests check the filter documents sent to MongoDB, which the fixture
+// catalog of restsource_test.go only approximates
+
+func TestPattern(t *testing.T) {
+	tests := []struct {
+		match RequestMatch
+		want  bson.M
+	}{
+		{RequestMatch{"Git", "Exact"}, bson.M{"$regex": "^Git$"}},
+		{RequestMatch{"Git", "CaseInsensitive"}, bson.M{"$regex": "^Git$", "$options": "i"}},
+		{RequestMatch{"Git", "StartsWith"}, bson.M{"$regex": "^Git", "$options": "i"}},
+		{RequestMatch{"Git", "Substring"}, bson.M{"$regex": "Git", "$options": "i"}},
+		{RequestMatch{"Git", ""}, bson.M{"$regex": "Git", "$options": "i"}},
+		{RequestMatch{"Git", "Fuzzy"}, bson.M{"$regex": "Git", "$options": "i"}},
+		// Keywords are literal text, not expressions
+		{RequestMatch{"Notepad++", "Exact"}, bson.M{"$regex": `^Notepad\+\+$`}},
+		{RequestMatch{"a.b*(c)", "Substring"}, bson.M{"$regex": `a\.b\*\(c\)`, "$options": "i"}},
+	}
+	for _, tt := range tests {
+		got, err := pattern(tt.match)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pattern(%+v) = %v, %v, want %v", tt.match, got, err, tt.want)
+		}
+	}
+	if _, err := pattern(RequestMatch{"Git", "Regex"}); err == nil {
+		t.Error("pattern accepted an unknown MatchType")
+	}
+}
+
+// TestPatternSemantics runs the generated expressions, which use only syntax
+// PCRE and RE2 read alike, against the values they must and must not match
+func TestPatternSemantics(t *testing.T) {
+	tests := []struct {
+		match   RequestMatch
+		matches []string
+		rejects []string
+	}{
+		{RequestMatch{"Git.Git", "Exact"}, []string{"Git.Git"}, []string{"git.git", "GitXGit", "Git.Git.LFS"}},
+		{RequestMatch{"Git.Git", "CaseInsensitive"}, []string{"Git.Git", "GIT.GIT"}, []string{"GitXGit", "My.Git.Git"}},
+		{RequestMatch{"Mozilla", "StartsWith"}, []string{"Mozilla.Firefox", "mozilla.thunderbird"}, []string{"Not.Mozilla"}},
+		{RequestMatch{"C++", "Substring"}, []string{"Visual C++ Redist", "c++"}, []string{"C", "CC"}},
+	}
+	for _, tt := range tests {
+		cond, err := pattern(tt.match)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expr := cond["$regex"].(string)
+		if cond["$options"] == "i" {
+			expr = "(?i)" + expr
+		}
+		re := regexp.MustCompile(expr)
+		for _, s := range tt.matches {
+			if !re.MatchString(s) {
+				t.Errorf("%+v does not match %q", tt.match, s)
+			}
+		}
+		for _, s := range tt.rejects {
+			if re.MatchString(s) {
+				t.Errorf("%+v matches %q", tt.match, s)
+			}
+		}
+	}
+}
+
+func TestSearchRequestFilter(t *testing.T) {
+	exact := bson.M{"$regex": "^Git$"}
+	substring := bson.M{"$regex": "Git", "$options": "i"}
+	tests := []struct {
+		name        string
+		req         SearchRequest
+		want        bson.M
+		unsupported []string
+	}{
+		{"empty", SearchRequest{}, bson.M{}, nil},
+		{
+			"query searches every query field",
+			SearchRequest{Query: &RequestMatch{"Git", "Substring"}},
+			bson.M{"$and": []bson.M{{"$or": []bson.M{
+				{"$or": []bson.M{{"PackageIdentifier": substring}}},
+				{"$or": []bson.M{{"PackageName": substring}, {"Locales.PackageName": substring}}},
+				{"$or": []bson.M{{"Moniker": substring}}},
+				{"$or": []bson.M{{"Tags": substring}, {"Locales.Tags": substring}}},
+				{"$or": []bson.M{{"Commands": substring}, {"Installers.Commands": substring}}},
+				{"$or": []bson.M{{"PackageFamilyName": substring}, {"Installers.PackageFamilyName": substring}}},
+				{"$or": []bson.M{{"ProductCode": substring}, {"Installers.ProductCode": substring}}},
+			}}}},
+			nil,
+		},
+		{
+			"inclusions are alternatives and filters are all required",
+			SearchRequest{
+				Inclusions: []PackageMatchFilter{
+					{"PackageIdentifier", RequestMatch{"Git", "Exact"}},
+					{"Moniker", RequestMatch{"Git", "Exact"}},
+				},
+				Filters: []PackageMatchFilter{
+					{"Tag", RequestMatch{"Git", "Substring"}},
+					{"Market", RequestMatch{"US", "Exact"}},
+				},
+			},
+			bson.M{"$and": []bson.M{
+				{"$or": []bson.M{
+					{"$or": []bson.M{{"PackageIdentifier": exact}}},
+					{"$or": []bson.M{{"Moniker": exact}}},
+				}},
+				{"$or": []bson.M{{"Tags": substring}, {"Locales.Tags": substring}}},
+			}},
+			[]string{"Market"},
+		},
+		{
+			"only unsupported fields",
+			SearchRequest{Inclusions: []PackageMatchFilter{{"NormalizedPackageNameAndPublisher", RequestMatch{"git", "Exact"}}}},
+			bson.M{},
+			[]string{"NormalizedPackageNameAndPublisher"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unsupported, err := tt.req.Filter()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() =\n%v\nwant\n%v", got, tt.want)
+			}
+			if !reflect.DeepEqual(unsupported, tt.unsupported) {
+				t.Errorf("unsupported = %q, want %q", unsupported, tt.unsupported)
+			}
+			// The document must encode, as the driver sends it
+			if _, err := bson.Marshal(got); err != nil {
+				t.Errorf("filter does not encode: %v", err)
+			}
+		})
+	}
+
+	if _, _, err := (SearchRequest{Filters: []PackageMatchFilter{{"Tag", RequestMatch{"Git", "Regex"}}}}).Filter(); err == nil {
+		t.Error("Filter accepted an unknown MatchType")
+	}
+}
+
+func TestPackageFilter(t *testing.T) {
+	// packageManifests looks packages up with the store's filter
+	got := store.PackageFilter("Notepad++.Notepad++")
+	want := bson.M{"PackageIdentifier": bson.M{"$regex": `^Notepad\+\+\.Notepad\+\+$`, "$options": "i"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PackageFilter = %v, want %v", got, want)
+	}
+}
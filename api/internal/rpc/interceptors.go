@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/iamBijoyKar/winget-pkg/api/apikey"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/auth"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain identifies this API in ErrorInfo details
+const errorDomain = "winget-pkg-api"
+
+// statusError builds a gRPC status carrying the same error code as the HTTP problem documents
+func statusError(c codes.Code, reason, message string, meta map[string]string) error {
+	st, err := status.New(c, message).WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   errorDomain,
+		Metadata: meta,
+	})
+	if err != nil {
+		return status.Error(c, message)
+	}
+	return st.Err()
+}
+
+// firstMetadata returns the first value of a metadata key
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// AuthInterceptor rejects calls without a valid "x-api-key" metadata entry,
+// using the same authenticator as the HTTP authMiddleware. Every method reads
+// packages, so keys need the search:read scope.
+func AuthInterceptor(authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		principal, err := authenticator.Authenticate(ctx, firstMetadata(ctx, "x-api-key"))
+		switch err {
+		case nil:
+			if !principal.HasScope(apikey.ScopeSearchRead) {
+				return nil, statusError(codes.PermissionDenied, server.CodeForbidden, "The API key lacks the "+apikey.ScopeSearchRead+" scope",
+					map[string]string{"scope": apikey.ScopeSearchRead})
+			}
+			return handler(auth.NewContext(ctx, principal), req)
+		case auth.ErrMissingKey:
+			return nil, statusError(codes.Unauthenticated, server.CodeMissingKey, "Send your API key in the x-api-key metadata", nil)
+		case auth.ErrExpiredKey:
+			return nil, statusError(codes.Unauthenticated, server.CodeExpiredKey, "The API key has expired, ask for a new one", nil)
+		case auth.ErrRevokedKey:
+			return nil, statusError(codes.Unauthenticated, server.CodeRevokedKey, "The API key has been revoked", nil)
+		case auth.ErrUnavailable:
+			return nil, statusError(codes.Unavailable, server.CodeStoreUnavailable, "API keys cannot be checked right now", nil)
+		default:
+			return nil, statusError(codes.Unauthenticated, server.CodeInvalidKey, "The x-api-key metadata does not match any registered key", nil)
+		}
+	}
+}
+
+// batchRequest is a request looking up several packages, such as BatchGet
+type batchRequest interface {
+	GetIdentifiers() []string
+}
+
+// itemCost is what a batch call costs: an exact lookup per identifier, and at
+// least one even when the call is rejected for having none
+func itemCost(req interface{}) int {
+	batch, ok := req.(batchRequest)
+	if !ok {
+		return server.DefaultCost
+	}
+	return max(len(batch.GetIdentifiers()), 1) * server.DefaultCost
+}
+
+// RateLimitInterceptor applies the HTTP rate limits and quotas of the
+// caller's tier to gRPC calls and reports them in x-ratelimit-* and x-quota-*
+// response headers. It runs after AuthInterceptor, so every call has a key.
+// Methods are costed by costs, the table shared with the HTTP routes.
+func RateLimitInterceptor(tiers *server.TierLimiter, costs server.Costs) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		principal, _ := auth.FromContext(ctx)
+		cost := costs.Cost(info.FullMethod)
+		if cost == server.ItemCost {
+			cost = itemCost(req)
+		}
+		charge := tiers.Charge(principal.KeyID, principal.Tier, cost)
+
+		// Metadata keys are lowercase, unlike the HTTP header names
+		md := metadata.MD{}
+		for name, value := range charge.Headers {
+			md.Set(name, value)
+		}
+		_ = grpc.SetHeader(ctx, md)
+
+		switch charge.Code {
+		case server.CodeRateLimited:
+			return nil, statusError(codes.ResourceExhausted, server.CodeRateLimited, "Rate limit exceeded", map[string]string{
+				"retryAfter": strconv.Itoa(charge.RetryAfter),
+				"limit":      strconv.Itoa(charge.Limit),
+				"tier":       charge.Tier.Name,
+			})
+		case server.CodeQuotaExceeded:
+			return nil, statusError(codes.ResourceExhausted, server.CodeQuotaExceeded, "Quota exceeded", map[string]string{
+				"retryAfter": strconv.Itoa(charge.RetryAfter),
+				"quota":      charge.Quota,
+				"tier":       charge.Tier.Name,
+			})
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,192 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/auth"
+	pb "github.com/iamBijoyKar/winget-pkg/api/internal/rpc/wingetpkgv1"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// headerStream records the headers an interceptor sets
+type headerStream struct {
+	method string
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return s.method }
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *headerStream) SetTrailer(md metadata.MD) error { return nil }
+
+// call runs interceptor for method as the key with this id and tier
+func call(interceptor grpc.UnaryServerInterceptor, method, keyID, tier string) (*headerStream, bool, error) {
+	return callWith(interceptor, method, nil, keyID, tier)
+}
+
+// callWith is call with the request req
+func callWith(interceptor grpc.UnaryServerInterceptor, method string, req interface{}, keyID, tier string) (*headerStream, bool, error) {
+	stream := &headerStream{method: method}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	ctx = auth.NewContext(ctx, auth.Principal{ID: "u1", KeyID: keyID, Tier: tier})
+	called := false
+	_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	return stream, called, err
+}
+
+// reason returns the code and ErrorInfo reason of a status error
+func reason(err error) (codes.Code, string) {
+	st := status.Convert(err)
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return st.Code(), info.Reason
+		}
+	}
+	return st.Code(), ""
+}
+
+// testCosts are the gRPC entries of the route cost table
+var testCosts = server.Costs{
+	pb.PackageService_Search_FullMethodName:   server.SearchCost,
+	pb.PackageService_BatchGet_FullMethodName: server.ItemCost,
+}
+
+func newTestTiers(t *testing.T) *server.TierLimiter {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tiers := server.NewTierLimiter([]server.Tier{
+		{Name: "free", Limit: 5, Window: time.Minute, Daily: 100, Upgrade: "team"},
+		{Name: "team", Limit: 100, Window: time.Minute, Daily: 2},
+	}, nil, server.FixedWindow, func() time.Time { return now })
+	t.Cleanup(tiers.Stop)
+	return tiers
+}
+
+func TestRateLimitInterceptorCosts(t *testing.T) {
+	interceptor := RateLimitInterceptor(newTestTiers(t), testCosts)
+
+	tests := []struct {
+		method    string
+		cost      string
+		remaining string
+		code      string
+	}{
+		{pb.PackageService_GetPackage_FullMethodName, "1", "5", ""},
+		{pb.PackageService_Search_FullMethodName, "3", "4", ""},
+		// 1 of the 5 requests is left
+		{pb.PackageService_Search_FullMethodName, "3", "1", server.CodeRateLimited},
+	}
+	for _, tt := range tests {
+		stream, called, err := call(interceptor, tt.method, "k1", "free")
+		if c, r := reason(err); r != tt.code || called != (tt.code == "") {
+			t.Fatalf("%s = %v %q, handler called %v, want %q", tt.method, c, r, called, tt.code)
+		}
+		if got := stream.header.Get("x-ratelimit-cost"); len(got) != 1 || got[0] != tt.cost {
+			t.Errorf("%s x-ratelimit-cost = %q, want %s", tt.method, got, tt.cost)
+		}
+		if got := stream.header.Get("x-ratelimit-remaining"); len(got) != 1 || got[0] != tt.remaining {
+			t.Errorf("%s x-ratelimit-remaining = %q, want %s", tt.method, got, tt.remaining)
+		}
+	}
+}
+
+func TestRateLimitInterceptorCostsBatchesPerItem(t *testing.T) {
+	interceptor := RateLimitInterceptor(newTestTiers(t), testCosts)
+	tests := []struct {
+		identifiers []string
+		cost        string
+	}{
+		{[]string{"Git.Git", "Mozilla.Firefox", "Zed.Zed"}, "3"},
+		// Rejected for having no identifiers, but not free
+		{nil, "1"},
+		// More than the limit at once takes the whole limit
+		{make([]string, 20), "5"},
+	}
+	for i, tt := range tests {
+		req := &pb.BatchGetRequest{Identifiers: tt.identifiers}
+		stream, _, _ := callWith(interceptor, pb.PackageService_BatchGet_FullMethodName, req, fmt.Sprintf("k%d", i), "free")
+		if got := stream.header.Get("x-ratelimit-cost"); len(got) != 1 || got[0] != tt.cost {
+			t.Errorf("BatchGet of %d x-ratelimit-cost = %q, want %s", len(tt.identifiers), got, tt.cost)
+		}
+	}
+}
+
+func TestRateLimitInterceptorRejects(t *testing.T) {
+	interceptor := RateLimitInterceptor(newTestTiers(t), testCosts)
+	for range 5 {
+		if _, _, err := call(interceptor, pb.PackageService_GetPackage_FullMethodName, "k1", "free"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream, _, err := call(interceptor, pb.PackageService_GetPackage_FullMethodName, "k1", "free")
+	if c, r := reason(err); c != codes.ResourceExhausted || r != server.CodeRateLimited {
+		t.Errorf("sixth call = %v %q, want ResourceExhausted %q", c, r, server.CodeRateLimited)
+	}
+	if got := stream.header.Get("x-ratelimit-upgrade-suggested"); len(got) != 1 || got[0] != "team" {
+		t.Errorf("x-ratelimit-upgrade-suggested = %q, want team", got)
+	}
+	// Keys are limited separately
+	if _, _, err := call(interceptor, pb.PackageService_GetPackage_FullMethodName, "k2", "free"); err != nil {
+		t.Errorf("call of another key = %v", err)
+	}
+
+	// The quota of the team tier is 2 calls a day
+	for range 2 {
+		if _, _, err := call(interceptor, pb.PackageService_GetPackage_FullMethodName, "k3", "team"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream, called, err := call(interceptor, pb.PackageService_GetPackage_FullMethodName, "k3", "team")
+	if c, r := reason(err); c != codes.ResourceExhausted || r != server.CodeQuotaExceeded || called {
+		t.Errorf("call over the quota = %v %q, handler called %v", c, r, called)
+	}
+	if got := stream.header.Get("x-quota-daily-remaining"); len(got) != 1 || got[0] != "0" {
+		t.Errorf("x-quota-daily-remaining = %q, want 0", got)
+	}
+	if info := status.Convert(err).Details(); len(info) != 1 || info[0].(*errdetails.ErrorInfo).Metadata["quota"] != "daily" {
+		t.Errorf("details = %v, want the daily quota", info)
+	}
+}
+
+func TestAuthInterceptorRejectsOffline(t *testing.T) {
+	// Missing and malformed keys are rejected without the key store
+	interceptor := AuthInterceptor(auth.New(nil, auth.CacheConfig{}))
+	tests := []struct {
+		key    string
+		reason string
+	}{
+		{"", server.CodeMissingKey},
+		{"not-a-key", server.CodeInvalidKey},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.key != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-api-key", tt.key))
+		}
+		called := false
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			return nil, nil
+		})
+		if c, r := reason(err); c != codes.Unauthenticated || r != tt.reason || called {
+			t.Errorf("key %q = %v %q, handler called %v, want Unauthenticated %q", tt.key, c, r, called, tt.reason)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/auth"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/locale"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	pb "github.com/iamBijoyKar/winget-pkg/api/internal/rpc/wingetpkgv1"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+	maxBatchSize    = 100
+)
+
+// PackageService implements wingetpkg.v1.PackageService on top of the store
+type PackageService struct {
+	pb.UnimplementedPackageServiceServer
+	store *store.Store
+}
+
+// NewServer creates a gRPC server with the package service registered behind
+// the authentication and rate limit interceptors. costs are keyed by full
+// method name.
+func NewServer(st *store.Store, authenticator *auth.Authenticator, tiers *server.TierLimiter, costs server.Costs) *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		AuthInterceptor(authenticator),
+		RateLimitInterceptor(tiers, costs),
+	))
+	pb.RegisterPackageServiceServer(srv, &PackageService{store: st})
+	return srv
+}
+
+// preferences returns the requested locales, falling back to the accept-language metadata
+func preferences(ctx context.Context, locales []string) []string {
+	if len(locales) > 0 {
+		return locales
+	}
+	return locale.Parse(firstMetadata(ctx, "accept-language"))
+}
+
+func storeError() error {
+	return statusError(codes.Unavailable, server.CodeStoreUnavailable, "Failed to query packages", nil)
+}
+
+func missingParam(param string) error {
+	return statusError(codes.InvalidArgument, server.CodeMissingParam, "Field '"+param+"' is required", map[string]string{"param": param})
+}
+
+// Search looks for the query in names, publishers, short descriptions and authors
+func (s *PackageService) Search(ctx context.Context, req *pb.SearchRequest) (*pb.SearchResponse, error) {
+	if req.GetQuery() == "" {
+		return nil, missingParam("query")
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	offset := 0
+	if token := req.GetPageToken(); token != "" {
+		n, err := strconv.Atoi(token)
+		if err != nil || n < 0 {
+			return nil, statusError(codes.InvalidArgument, server.CodeInvalidParam, "Malformed page_token", map[string]string{"param": "page_token"})
+		}
+		offset = n
+	}
+
+	packages, err := s.store.Search(ctx, req.GetQuery())
+	if err != nil {
+		return nil, storeError()
+	}
+
+	resp := &pb.SearchResponse{TotalSize: int32(len(packages))}
+	prefs := preferences(ctx, req.GetLocales())
+	for i := offset; i < len(packages) && i < offset+pageSize; i++ {
+		packages[i].Localize(prefs)
+		resp.Packages = append(resp.Packages, toPackage(packages[i]))
+	}
+	if offset+pageSize < len(packages) {
+		resp.NextPageToken = strconv.Itoa(offset + pageSize)
+	}
+	return resp, nil
+}
+
+// GetPackage returns a package by its exact identifier
+func (s *PackageService) GetPackage(ctx context.Context, req *pb.GetPackageRequest) (*pb.GetPackageResponse, error) {
+	if req.GetIdentifier() == "" {
+		return nil, missingParam("identifier")
+	}
+
+	pkg, err := s.store.Package(ctx, req.GetIdentifier())
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, statusError(codes.NotFound, server.CodeNotFound, "Package not found", nil)
+	}
+	if err != nil {
+		return nil, storeError()
+	}
+
+	pkg.Localize(preferences(ctx, req.GetLocales()))
+	return &pb.GetPackageResponse{Package: toPackage(pkg)}, nil
+}
+
+// ListVersions returns the versions of a package, newest first
+func (s *PackageService) ListVersions(ctx context.Context, req *pb.ListVersionsRequest) (*pb.ListVersionsResponse, error) {
+	if req.GetIdentifier() == "" {
+		return nil, missingParam("identifier")
+	}
+
+	pkg, err := s.store.Package(ctx, req.GetIdentifier())
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, statusError(codes.NotFound, server.CodeNotFound, "Package not found", nil)
+	}
+	if err != nil {
+		return nil, storeError()
+	}
+
+	resp := &pb.ListVersionsResponse{}
+	for _, v := range pkg.Versions {
+		resp.Versions = append(resp.Versions, toVersion(v))
+	}
+	return resp, nil
+}
+
+// BatchGet returns several packages by identifier in one call
+func (s *PackageService) BatchGet(ctx context.Context, req *pb.BatchGetRequest) (*pb.BatchGetResponse, error) {
+	if len(req.GetIdentifiers()) == 0 {
+		return nil, missingParam("identifiers")
+	}
+	if len(req.GetIdentifiers()) > maxBatchSize {
+		return nil, statusError(codes.InvalidArgument, server.CodeInvalidParam,
+			"At most "+strconv.Itoa(maxBatchSize)+" identifiers per call", map[string]string{"param": "identifiers"})
+	}
+
+	resp := &pb.BatchGetResponse{}
+	prefs := preferences(ctx, req.GetLocales())
+	for _, id := range req.GetIdentifiers() {
+		pkg, err := s.store.Package(ctx, id)
+		if errors.Is(err, store.ErrNotFound) {
+			resp.NotFound = append(resp.NotFound, id)
+			continue
+		}
+		if err != nil {
+			return nil, storeError()
+		}
+		pkg.Localize(prefs)
+		resp.Packages = append(resp.Packages, toPackage(pkg))
+	}
+	return resp, nil
+}
+
+func toPackage(p models.Package) *pb.Package {
+	out := &pb.Package{
+		Identifier:       p.Identifier,
+		Name:             p.Name,
+		Publisher:        p.Publisher,
+		Author:           p.Author,
+		ShortDescription: p.ShortDescription,
+		Description:      p.Description,
+		Moniker:          p.Moniker,
+		Tags:             p.Tags,
+		License:          p.License,
+		Homepage:         p.Homepage,
+		Locale:           p.Locale,
+		LatestVersion:    p.LatestVersion,
+	}
+	for _, v := range p.Versions {
+		out.Versions = append(out.Versions, toVersion(v))
+	}
+	return out
+}
+
+func toVersion(v models.Version) *pb.Version {
+	out := &pb.Version{
+		Version:       v.Version,
+		Channel:       v.Channel,
+		ReleaseDate:   v.ReleaseDate,
+		DefaultLocale: v.DefaultLocale,
+	}
+	for _, l := range v.Locales {
+		out.Locales = append(out.Locales, &pb.Locale{
+			Locale:              l.Locale,
+			Publisher:           l.Publisher,
+			PublisherUrl:        l.PublisherURL,
+			PublisherSupportUrl: l.PublisherSupportURL,
+			PrivacyUrl:          l.PrivacyURL,
+			Author:              l.Author,
+			PackageName:         l.PackageName,
+			PackageUrl:          l.PackageURL,
+			License:             l.License,
+			LicenseUrl:          l.LicenseURL,
+			Copyright:           l.Copyright,
+			CopyrightUrl:        l.CopyrightURL,
+			ShortDescription:    l.ShortDescription,
+			Description:         l.Description,
+			Moniker:             l.Moniker,
+			Tags:                l.Tags,
+			ReleaseNotes:        l.ReleaseNotes,
+			ReleaseNotesUrl:     l.ReleaseNotesURL,
+		})
+	}
+	for _, inst := range v.Installers {
+		out.Installers = append(out.Installers, toInstaller(inst))
+	}
+	return out
+}
+
+func toInstaller(inst models.Installer) *pb.Installer {
+	out := &pb.Installer{
+		Architecture:      inst.Architecture,
+		Type:              inst.Type,
+		Url:               inst.URL,
+		Sha256:            inst.Sha256,
+		Scope:             inst.Scope,
+		Locale:            inst.Locale,
+		ProductCode:       inst.ProductCode,
+		MinimumOsVersion:  inst.MinimumOSVersion,
+		UpgradeBehavior:   inst.UpgradeBehavior,
+		PackageFamilyName: inst.PackageFamilyName,
+		Commands:          inst.Commands,
+		Switches: &pb.InstallerSwitches{
+			Silent:             inst.Switches.Silent,
+			SilentWithProgress: inst.Switches.SilentWithProgress,
+			Interactive:        inst.Switches.Interactive,
+			InstallLocation:    inst.Switches.InstallLocation,
+			Log:                inst.Switches.Log,
+			Upgrade:            inst.Switches.Upgrade,
+			Custom:             inst.Switches.Custom,
+		},
+		Dependencies: &pb.InstallerDependencies{
+			WindowsFeatures:      inst.Dependencies.WindowsFeatures,
+			WindowsLibraries:     inst.Dependencies.WindowsLibraries,
+			ExternalDependencies: inst.Dependencies.ExternalDependencies,
+		},
+	}
+	for _, d := range inst.Dependencies.PackageDependencies {
+		out.Dependencies.PackageDependencies = append(out.Dependencies.PackageDependencies, &pb.PackageDependency{
+			PackageIdentifier: d.PackageIdentifier,
+			MinimumVersion:    d.MinimumVersion,
+		})
+	}
+	return out
+}
@@ -0,0 +1,1401 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: wingetpkg/v1/package_service.proto
+
+package wingetpkgv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SearchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Query string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Maximum number of packages to return, 50 when unset, at most 200.
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of a previous response.
+	PageToken string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Preferred locales, best first. Falls back to the "accept-language" metadata.
+	Locales       []string `protobuf:"bytes,4,rep,name=locales,proto3" json:"locales,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetLocales() []string {
+	if x != nil {
+		return x.Locales
+	}
+	return nil
+}
+
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Packages      []*Package             `protobuf:"bytes,1,rep,name=packages,proto3" json:"packages,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	TotalSize     int32                  `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SearchResponse) GetPackages() []*Package {
+	if x != nil {
+		return x.Packages
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *SearchResponse) GetTotalSize() int32 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+type GetPackageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Identifier    string                 `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	Locales       []string               `protobuf:"bytes,2,rep,name=locales,proto3" json:"locales,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPackageRequest) Reset() {
+	*x = GetPackageRequest{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPackageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPackageRequest) ProtoMessage() {}
+
+func (x *GetPackageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPackageRequest.ProtoReflect.Descriptor instead.
+func (*GetPackageRequest) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetPackageRequest) GetIdentifier() string {
+	if x != nil {
+		return x.Identifier
+	}
+	return ""
+}
+
+func (x *GetPackageRequest) GetLocales() []string {
+	if x != nil {
+		return x.Locales
+	}
+	return nil
+}
+
+type GetPackageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Package       *Package               `protobuf:"bytes,1,opt,name=package,proto3" json:"package,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPackageResponse) Reset() {
+	*x = GetPackageResponse{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPackageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPackageResponse) ProtoMessage() {}
+
+func (x *GetPackageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPackageResponse.ProtoReflect.Descriptor instead.
+func (*GetPackageResponse) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetPackageResponse) GetPackage() *Package {
+	if x != nil {
+		return x.Package
+	}
+	return nil
+}
+
+type ListVersionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Identifier    string                 `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVersionsRequest) Reset() {
+	*x = ListVersionsRequest{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVersionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVersionsRequest) ProtoMessage() {}
+
+func (x *ListVersionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVersionsRequest.ProtoReflect.Descriptor instead.
+func (*ListVersionsRequest) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListVersionsRequest) GetIdentifier() string {
+	if x != nil {
+		return x.Identifier
+	}
+	return ""
+}
+
+type ListVersionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Versions      []*Version             `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVersionsResponse) Reset() {
+	*x = ListVersionsResponse{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVersionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVersionsResponse) ProtoMessage() {}
+
+func (x *ListVersionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVersionsResponse.ProtoReflect.Descriptor instead.
+func (*ListVersionsResponse) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListVersionsResponse) GetVersions() []*Version {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type BatchGetRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// At most 100 identifiers.
+	Identifiers   []string `protobuf:"bytes,1,rep,name=identifiers,proto3" json:"identifiers,omitempty"`
+	Locales       []string `protobuf:"bytes,2,rep,name=locales,proto3" json:"locales,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetRequest) Reset() {
+	*x = BatchGetRequest{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetRequest) ProtoMessage() {}
+
+func (x *BatchGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetRequest) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchGetRequest) GetIdentifiers() []string {
+	if x != nil {
+		return x.Identifiers
+	}
+	return nil
+}
+
+func (x *BatchGetRequest) GetLocales() []string {
+	if x != nil {
+		return x.Locales
+	}
+	return nil
+}
+
+type BatchGetResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Packages []*Package             `protobuf:"bytes,1,rep,name=packages,proto3" json:"packages,omitempty"`
+	// Identifiers that matched no package.
+	NotFound      []string `protobuf:"bytes,2,rep,name=not_found,json=notFound,proto3" json:"not_found,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetResponse) Reset() {
+	*x = BatchGetResponse{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetResponse) ProtoMessage() {}
+
+func (x *BatchGetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetResponse) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BatchGetResponse) GetPackages() []*Package {
+	if x != nil {
+		return x.Packages
+	}
+	return nil
+}
+
+func (x *BatchGetResponse) GetNotFound() []string {
+	if x != nil {
+		return x.NotFound
+	}
+	return nil
+}
+
+type Package struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Identifier       string                 `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Publisher        string                 `protobuf:"bytes,3,opt,name=publisher,proto3" json:"publisher,omitempty"`
+	Author           string                 `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`
+	ShortDescription string                 `protobuf:"bytes,5,opt,name=short_description,json=shortDescription,proto3" json:"short_description,omitempty"`
+	Description      string                 `protobuf:"bytes,6,opt,name=description,proto3" json:"description,omitempty"`
+	Moniker          string                 `protobuf:"bytes,7,opt,name=moniker,proto3" json:"moniker,omitempty"`
+	Tags             []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	License          string                 `protobuf:"bytes,9,opt,name=license,proto3" json:"license,omitempty"`
+	Homepage         string                 `protobuf:"bytes,10,opt,name=homepage,proto3" json:"homepage,omitempty"`
+	Locale           string                 `protobuf:"bytes,11,opt,name=locale,proto3" json:"locale,omitempty"`
+	LatestVersion    string                 `protobuf:"bytes,12,opt,name=latest_version,json=latestVersion,proto3" json:"latest_version,omitempty"`
+	Versions         []*Version             `protobuf:"bytes,13,rep,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Package) Reset() {
+	*x = Package{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Package) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Package) ProtoMessage() {}
+
+func (x *Package) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Package.ProtoReflect.Descriptor instead.
+func (*Package) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Package) GetIdentifier() string {
+	if x != nil {
+		return x.Identifier
+	}
+	return ""
+}
+
+func (x *Package) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Package) GetPublisher() string {
+	if x != nil {
+		return x.Publisher
+	}
+	return ""
+}
+
+func (x *Package) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Package) GetShortDescription() string {
+	if x != nil {
+		return x.ShortDescription
+	}
+	return ""
+}
+
+func (x *Package) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Package) GetMoniker() string {
+	if x != nil {
+		return x.Moniker
+	}
+	return ""
+}
+
+func (x *Package) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Package) GetLicense() string {
+	if x != nil {
+		return x.License
+	}
+	return ""
+}
+
+func (x *Package) GetHomepage() string {
+	if x != nil {
+		return x.Homepage
+	}
+	return ""
+}
+
+func (x *Package) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *Package) GetLatestVersion() string {
+	if x != nil {
+		return x.LatestVersion
+	}
+	return ""
+}
+
+func (x *Package) GetVersions() []*Version {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type Version struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Channel       string                 `protobuf:"bytes,2,opt,name=channel,proto3" json:"channel,omitempty"`
+	ReleaseDate   string                 `protobuf:"bytes,3,opt,name=release_date,json=releaseDate,proto3" json:"release_date,omitempty"`
+	DefaultLocale string                 `protobuf:"bytes,4,opt,name=default_locale,json=defaultLocale,proto3" json:"default_locale,omitempty"`
+	Locales       []*Locale              `protobuf:"bytes,5,rep,name=locales,proto3" json:"locales,omitempty"`
+	Installers    []*Installer           `protobuf:"bytes,6,rep,name=installers,proto3" json:"installers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Version) Reset() {
+	*x = Version{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Version) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Version) ProtoMessage() {}
+
+func (x *Version) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Version.ProtoReflect.Descriptor instead.
+func (*Version) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Version) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Version) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *Version) GetReleaseDate() string {
+	if x != nil {
+		return x.ReleaseDate
+	}
+	return ""
+}
+
+func (x *Version) GetDefaultLocale() string {
+	if x != nil {
+		return x.DefaultLocale
+	}
+	return ""
+}
+
+func (x *Version) GetLocales() []*Locale {
+	if x != nil {
+		return x.Locales
+	}
+	return nil
+}
+
+func (x *Version) GetInstallers() []*Installer {
+	if x != nil {
+		return x.Installers
+	}
+	return nil
+}
+
+type Locale struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Locale              string                 `protobuf:"bytes,1,opt,name=locale,proto3" json:"locale,omitempty"`
+	Publisher           string                 `protobuf:"bytes,2,opt,name=publisher,proto3" json:"publisher,omitempty"`
+	PublisherUrl        string                 `protobuf:"bytes,3,opt,name=publisher_url,json=publisherUrl,proto3" json:"publisher_url,omitempty"`
+	PublisherSupportUrl string                 `protobuf:"bytes,4,opt,name=publisher_support_url,json=publisherSupportUrl,proto3" json:"publisher_support_url,omitempty"`
+	PrivacyUrl          string                 `protobuf:"bytes,5,opt,name=privacy_url,json=privacyUrl,proto3" json:"privacy_url,omitempty"`
+	Author              string                 `protobuf:"bytes,6,opt,name=author,proto3" json:"author,omitempty"`
+	PackageName         string                 `protobuf:"bytes,7,opt,name=package_name,json=packageName,proto3" json:"package_name,omitempty"`
+	PackageUrl          string                 `protobuf:"bytes,8,opt,name=package_url,json=packageUrl,proto3" json:"package_url,omitempty"`
+	License             string                 `protobuf:"bytes,9,opt,name=license,proto3" json:"license,omitempty"`
+	LicenseUrl          string                 `protobuf:"bytes,10,opt,name=license_url,json=licenseUrl,proto3" json:"license_url,omitempty"`
+	Copyright           string                 `protobuf:"bytes,11,opt,name=copyright,proto3" json:"copyright,omitempty"`
+	CopyrightUrl        string                 `protobuf:"bytes,12,opt,name=copyright_url,json=copyrightUrl,proto3" json:"copyright_url,omitempty"`
+	ShortDescription    string                 `protobuf:"bytes,13,opt,name=short_description,json=shortDescription,proto3" json:"short_description,omitempty"`
+	Description         string                 `protobuf:"bytes,14,opt,name=description,proto3" json:"description,omitempty"`
+	Moniker             string                 `protobuf:"bytes,15,opt,name=moniker,proto3" json:"moniker,omitempty"`
+	Tags                []string               `protobuf:"bytes,16,rep,name=tags,proto3" json:"tags,omitempty"`
+	ReleaseNotes        string                 `protobuf:"bytes,17,opt,name=release_notes,json=releaseNotes,proto3" json:"release_notes,omitempty"`
+	ReleaseNotesUrl     string                 `protobuf:"bytes,18,opt,name=release_notes_url,json=releaseNotesUrl,proto3" json:"release_notes_url,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Locale) Reset() {
+	*x = Locale{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Locale) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Locale) ProtoMessage() {}
+
+func (x *Locale) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Locale.ProtoReflect.Descriptor instead.
+func (*Locale) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Locale) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *Locale) GetPublisher() string {
+	if x != nil {
+		return x.Publisher
+	}
+	return ""
+}
+
+func (x *Locale) GetPublisherUrl() string {
+	if x != nil {
+		return x.PublisherUrl
+	}
+	return ""
+}
+
+func (x *Locale) GetPublisherSupportUrl() string {
+	if x != nil {
+		return x.PublisherSupportUrl
+	}
+	return ""
+}
+
+func (x *Locale) GetPrivacyUrl() string {
+	if x != nil {
+		return x.PrivacyUrl
+	}
+	return ""
+}
+
+func (x *Locale) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Locale) GetPackageName() string {
+	if x != nil {
+		return x.PackageName
+	}
+	return ""
+}
+
+func (x *Locale) GetPackageUrl() string {
+	if x != nil {
+		return x.PackageUrl
+	}
+	return ""
+}
+
+func (x *Locale) GetLicense() string {
+	if x != nil {
+		return x.License
+	}
+	return ""
+}
+
+func (x *Locale) GetLicenseUrl() string {
+	if x != nil {
+		return x.LicenseUrl
+	}
+	return ""
+}
+
+func (x *Locale) GetCopyright() string {
+	if x != nil {
+		return x.Copyright
+	}
+	return ""
+}
+
+func (x *Locale) GetCopyrightUrl() string {
+	if x != nil {
+		return x.CopyrightUrl
+	}
+	return ""
+}
+
+func (x *Locale) GetShortDescription() string {
+	if x != nil {
+		return x.ShortDescription
+	}
+	return ""
+}
+
+func (x *Locale) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Locale) GetMoniker() string {
+	if x != nil {
+		return x.Moniker
+	}
+	return ""
+}
+
+func (x *Locale) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Locale) GetReleaseNotes() string {
+	if x != nil {
+		return x.ReleaseNotes
+	}
+	return ""
+}
+
+func (x *Locale) GetReleaseNotesUrl() string {
+	if x != nil {
+		return x.ReleaseNotesUrl
+	}
+	return ""
+}
+
+type Installer struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Architecture      string                 `protobuf:"bytes,1,opt,name=architecture,proto3" json:"architecture,omitempty"`
+	Type              string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Url               string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	Sha256            string                 `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Scope             string                 `protobuf:"bytes,5,opt,name=scope,proto3" json:"scope,omitempty"`
+	Locale            string                 `protobuf:"bytes,6,opt,name=locale,proto3" json:"locale,omitempty"`
+	ProductCode       string                 `protobuf:"bytes,7,opt,name=product_code,json=productCode,proto3" json:"product_code,omitempty"`
+	MinimumOsVersion  string                 `protobuf:"bytes,8,opt,name=minimum_os_version,json=minimumOsVersion,proto3" json:"minimum_os_version,omitempty"`
+	UpgradeBehavior   string                 `protobuf:"bytes,9,opt,name=upgrade_behavior,json=upgradeBehavior,proto3" json:"upgrade_behavior,omitempty"`
+	PackageFamilyName string                 `protobuf:"bytes,10,opt,name=package_family_name,json=packageFamilyName,proto3" json:"package_family_name,omitempty"`
+	Commands          []string               `protobuf:"bytes,11,rep,name=commands,proto3" json:"commands,omitempty"`
+	Switches          *InstallerSwitches     `protobuf:"bytes,12,opt,name=switches,proto3" json:"switches,omitempty"`
+	Dependencies      *InstallerDependencies `protobuf:"bytes,13,opt,name=dependencies,proto3" json:"dependencies,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Installer) Reset() {
+	*x = Installer{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Installer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Installer) ProtoMessage() {}
+
+func (x *Installer) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Installer.ProtoReflect.Descriptor instead.
+func (*Installer) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Installer) GetArchitecture() string {
+	if x != nil {
+		return x.Architecture
+	}
+	return ""
+}
+
+func (x *Installer) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Installer) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Installer) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *Installer) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *Installer) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *Installer) GetProductCode() string {
+	if x != nil {
+		return x.ProductCode
+	}
+	return ""
+}
+
+func (x *Installer) GetMinimumOsVersion() string {
+	if x != nil {
+		return x.MinimumOsVersion
+	}
+	return ""
+}
+
+func (x *Installer) GetUpgradeBehavior() string {
+	if x != nil {
+		return x.UpgradeBehavior
+	}
+	return ""
+}
+
+func (x *Installer) GetPackageFamilyName() string {
+	if x != nil {
+		return x.PackageFamilyName
+	}
+	return ""
+}
+
+func (x *Installer) GetCommands() []string {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+func (x *Installer) GetSwitches() *InstallerSwitches {
+	if x != nil {
+		return x.Switches
+	}
+	return nil
+}
+
+func (x *Installer) GetDependencies() *InstallerDependencies {
+	if x != nil {
+		return x.Dependencies
+	}
+	return nil
+}
+
+type InstallerSwitches struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Silent             string                 `protobuf:"bytes,1,opt,name=silent,proto3" json:"silent,omitempty"`
+	SilentWithProgress string                 `protobuf:"bytes,2,opt,name=silent_with_progress,json=silentWithProgress,proto3" json:"silent_with_progress,omitempty"`
+	Interactive        string                 `protobuf:"bytes,3,opt,name=interactive,proto3" json:"interactive,omitempty"`
+	InstallLocation    string                 `protobuf:"bytes,4,opt,name=install_location,json=installLocation,proto3" json:"install_location,omitempty"`
+	Log                string                 `protobuf:"bytes,5,opt,name=log,proto3" json:"log,omitempty"`
+	Upgrade            string                 `protobuf:"bytes,6,opt,name=upgrade,proto3" json:"upgrade,omitempty"`
+	Custom             string                 `protobuf:"bytes,7,opt,name=custom,proto3" json:"custom,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *InstallerSwitches) Reset() {
+	*x = InstallerSwitches{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstallerSwitches) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstallerSwitches) ProtoMessage() {}
+
+func (x *InstallerSwitches) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstallerSwitches.ProtoReflect.Descriptor instead.
+func (*InstallerSwitches) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *InstallerSwitches) GetSilent() string {
+	if x != nil {
+		return x.Silent
+	}
+	return ""
+}
+
+func (x *InstallerSwitches) GetSilentWithProgress() string {
+	if x != nil {
+		return x.SilentWithProgress
+	}
+	return ""
+}
+
+func (x *InstallerSwitches) GetInteractive() string {
+	if x != nil {
+		return x.Interactive
+	}
+	return ""
+}
+
+func (x *InstallerSwitches) GetInstallLocation() string {
+	if x != nil {
+		return x.InstallLocation
+	}
+	return ""
+}
+
+func (x *InstallerSwitches) GetLog() string {
+	if x != nil {
+		return x.Log
+	}
+	return ""
+}
+
+func (x *InstallerSwitches) GetUpgrade() string {
+	if x != nil {
+		return x.Upgrade
+	}
+	return ""
+}
+
+func (x *InstallerSwitches) GetCustom() string {
+	if x != nil {
+		return x.Custom
+	}
+	return ""
+}
+
+type InstallerDependencies struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	WindowsFeatures      []string               `protobuf:"bytes,1,rep,name=windows_features,json=windowsFeatures,proto3" json:"windows_features,omitempty"`
+	WindowsLibraries     []string               `protobuf:"bytes,2,rep,name=windows_libraries,json=windowsLibraries,proto3" json:"windows_libraries,omitempty"`
+	PackageDependencies  []*PackageDependency   `protobuf:"bytes,3,rep,name=package_dependencies,json=packageDependencies,proto3" json:"package_dependencies,omitempty"`
+	ExternalDependencies []string               `protobuf:"bytes,4,rep,name=external_dependencies,json=externalDependencies,proto3" json:"external_dependencies,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *InstallerDependencies) Reset() {
+	*x = InstallerDependencies{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstallerDependencies) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstallerDependencies) ProtoMessage() {}
+
+func (x *InstallerDependencies) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstallerDependencies.ProtoReflect.Descriptor instead.
+func (*InstallerDependencies) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *InstallerDependencies) GetWindowsFeatures() []string {
+	if x != nil {
+		return x.WindowsFeatures
+	}
+	return nil
+}
+
+func (x *InstallerDependencies) GetWindowsLibraries() []string {
+	if x != nil {
+		return x.WindowsLibraries
+	}
+	return nil
+}
+
+func (x *InstallerDependencies) GetPackageDependencies() []*PackageDependency {
+	if x != nil {
+		return x.PackageDependencies
+	}
+	return nil
+}
+
+func (x *InstallerDependencies) GetExternalDependencies() []string {
+	if x != nil {
+		return x.ExternalDependencies
+	}
+	return nil
+}
+
+type PackageDependency struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	PackageIdentifier string                 `protobuf:"bytes,1,opt,name=package_identifier,json=packageIdentifier,proto3" json:"package_identifier,omitempty"`
+	MinimumVersion    string                 `protobuf:"bytes,2,opt,name=minimum_version,json=minimumVersion,proto3" json:"minimum_version,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *PackageDependency) Reset() {
+	*x = PackageDependency{}
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PackageDependency) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PackageDependency) ProtoMessage() {}
+
+func (x *PackageDependency) ProtoReflect() protoreflect.Message {
+	mi := &file_wingetpkg_v1_package_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PackageDependency.ProtoReflect.Descriptor instead.
+func (*PackageDependency) Descriptor() ([]byte, []int) {
+	return file_wingetpkg_v1_package_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *PackageDependency) GetPackageIdentifier() string {
+	if x != nil {
+		return x.PackageIdentifier
+	}
+	return ""
+}
+
+func (x *PackageDependency) GetMinimumVersion() string {
+	if x != nil {
+		return x.MinimumVersion
+	}
+	return ""
+}
+
+var File_wingetpkg_v1_package_service_proto protoreflect.FileDescriptor
+
+const file_wingetpkg_v1_package_service_proto_rawDesc = "" +
+	"\n" +
+	"\"wingetpkg/v1/package_service.proto\x12\fwingetpkg.v1\"{\n" +
+	"\rSearchRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\x12\x18\n" +
+	"\alocales\x18\x04 \x03(\tR\alocales\"\x8a\x01\n" +
+	"\x0eSearchResponse\x121\n" +
+	"\bpackages\x18\x01 \x03(\v2\x15.wingetpkg.v1.PackageR\bpackages\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x03 \x01(\x05R\ttotalSize\"M\n" +
+	"\x11GetPackageRequest\x12\x1e\n" +
+	"\n" +
+	"identifier\x18\x01 \x01(\tR\n" +
+	"identifier\x12\x18\n" +
+	"\alocales\x18\x02 \x03(\tR\alocales\"E\n" +
+	"\x12GetPackageResponse\x12/\n" +
+	"\apackage\x18\x01 \x01(\v2\x15.wingetpkg.v1.PackageR\apackage\"5\n" +
+	"\x13ListVersionsRequest\x12\x1e\n" +
+	"\n" +
+	"identifier\x18\x01 \x01(\tR\n" +
+	"identifier\"I\n" +
+	"\x14ListVersionsResponse\x121\n" +
+	"\bversions\x18\x01 \x03(\v2\x15.wingetpkg.v1.VersionR\bversions\"M\n" +
+	"\x0fBatchGetRequest\x12 \n" +
+	"\videntifiers\x18\x01 \x03(\tR\videntifiers\x12\x18\n" +
+	"\alocales\x18\x02 \x03(\tR\alocales\"b\n" +
+	"\x10BatchGetResponse\x121\n" +
+	"\bpackages\x18\x01 \x03(\v2\x15.wingetpkg.v1.PackageR\bpackages\x12\x1b\n" +
+	"\tnot_found\x18\x02 \x03(\tR\bnotFound\"\x98\x03\n" +
+	"\aPackage\x12\x1e\n" +
+	"\n" +
+	"identifier\x18\x01 \x01(\tR\n" +
+	"identifier\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1c\n" +
+	"\tpublisher\x18\x03 \x01(\tR\tpublisher\x12\x16\n" +
+	"\x06author\x18\x04 \x01(\tR\x06author\x12+\n" +
+	"\x11short_description\x18\x05 \x01(\tR\x10shortDescription\x12 \n" +
+	"\vdescription\x18\x06 \x01(\tR\vdescription\x12\x18\n" +
+	"\amoniker\x18\a \x01(\tR\amoniker\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\x12\x18\n" +
+	"\alicense\x18\t \x01(\tR\alicense\x12\x1a\n" +
+	"\bhomepage\x18\n" +
+	" \x01(\tR\bhomepage\x12\x16\n" +
+	"\x06locale\x18\v \x01(\tR\x06locale\x12%\n" +
+	"\x0elatest_version\x18\f \x01(\tR\rlatestVersion\x121\n" +
+	"\bversions\x18\r \x03(\v2\x15.wingetpkg.v1.VersionR\bversions\"\xf0\x01\n" +
+	"\aVersion\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x18\n" +
+	"\achannel\x18\x02 \x01(\tR\achannel\x12!\n" +
+	"\frelease_date\x18\x03 \x01(\tR\vreleaseDate\x12%\n" +
+	"\x0edefault_locale\x18\x04 \x01(\tR\rdefaultLocale\x12.\n" +
+	"\alocales\x18\x05 \x03(\v2\x14.wingetpkg.v1.LocaleR\alocales\x127\n" +
+	"\n" +
+	"installers\x18\x06 \x03(\v2\x17.wingetpkg.v1.InstallerR\n" +
+	"installers\"\xe0\x04\n" +
+	"\x06Locale\x12\x16\n" +
+	"\x06locale\x18\x01 \x01(\tR\x06locale\x12\x1c\n" +
+	"\tpublisher\x18\x02 \x01(\tR\tpublisher\x12#\n" +
+	"\rpublisher_url\x18\x03 \x01(\tR\fpublisherUrl\x122\n" +
+	"\x15publisher_support_url\x18\x04 \x01(\tR\x13publisherSupportUrl\x12\x1f\n" +
+	"\vprivacy_url\x18\x05 \x01(\tR\n" +
+	"privacyUrl\x12\x16\n" +
+	"\x06author\x18\x06 \x01(\tR\x06author\x12!\n" +
+	"\fpackage_name\x18\a \x01(\tR\vpackageName\x12\x1f\n" +
+	"\vpackage_url\x18\b \x01(\tR\n" +
+	"packageUrl\x12\x18\n" +
+	"\alicense\x18\t \x01(\tR\alicense\x12\x1f\n" +
+	"\vlicense_url\x18\n" +
+	" \x01(\tR\n" +
+	"licenseUrl\x12\x1c\n" +
+	"\tcopyright\x18\v \x01(\tR\tcopyright\x12#\n" +
+	"\rcopyright_url\x18\f \x01(\tR\fcopyrightUrl\x12+\n" +
+	"\x11short_description\x18\r \x01(\tR\x10shortDescription\x12 \n" +
+	"\vdescription\x18\x0e \x01(\tR\vdescription\x12\x18\n" +
+	"\amoniker\x18\x0f \x01(\tR\amoniker\x12\x12\n" +
+	"\x04tags\x18\x10 \x03(\tR\x04tags\x12#\n" +
+	"\rrelease_notes\x18\x11 \x01(\tR\freleaseNotes\x12*\n" +
+	"\x11release_notes_url\x18\x12 \x01(\tR\x0freleaseNotesUrl\"\xe9\x03\n" +
+	"\tInstaller\x12\"\n" +
+	"\farchitecture\x18\x01 \x01(\tR\farchitecture\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12\x16\n" +
+	"\x06sha256\x18\x04 \x01(\tR\x06sha256\x12\x14\n" +
+	"\x05scope\x18\x05 \x01(\tR\x05scope\x12\x16\n" +
+	"\x06locale\x18\x06 \x01(\tR\x06locale\x12!\n" +
+	"\fproduct_code\x18\a \x01(\tR\vproductCode\x12,\n" +
+	"\x12minimum_os_version\x18\b \x01(\tR\x10minimumOsVersion\x12)\n" +
+	"\x10upgrade_behavior\x18\t \x01(\tR\x0fupgradeBehavior\x12.\n" +
+	"\x13package_family_name\x18\n" +
+	" \x01(\tR\x11packageFamilyName\x12\x1a\n" +
+	"\bcommands\x18\v \x03(\tR\bcommands\x12;\n" +
+	"\bswitches\x18\f \x01(\v2\x1f.wingetpkg.v1.InstallerSwitchesR\bswitches\x12G\n" +
+	"\fdependencies\x18\r \x01(\v2#.wingetpkg.v1.InstallerDependenciesR\fdependencies\"\xee\x01\n" +
+	"\x11InstallerSwitches\x12\x16\n" +
+	"\x06silent\x18\x01 \x01(\tR\x06silent\x120\n" +
+	"\x14silent_with_progress\x18\x02 \x01(\tR\x12silentWithProgress\x12 \n" +
+	"\vinteractive\x18\x03 \x01(\tR\vinteractive\x12)\n" +
+	"\x10install_location\x18\x04 \x01(\tR\x0finstallLocation\x12\x10\n" +
+	"\x03log\x18\x05 \x01(\tR\x03log\x12\x18\n" +
+	"\aupgrade\x18\x06 \x01(\tR\aupgrade\x12\x16\n" +
+	"\x06custom\x18\a \x01(\tR\x06custom\"\xf8\x01\n" +
+	"\x15InstallerDependencies\x12)\n" +
+	"\x10windows_features\x18\x01 \x03(\tR\x0fwindowsFeatures\x12+\n" +
+	"\x11windows_libraries\x18\x02 \x03(\tR\x10windowsLibraries\x12R\n" +
+	"\x14package_dependencies\x18\x03 \x03(\v2\x1f.wingetpkg.v1.PackageDependencyR\x13packageDependencies\x123\n" +
+	"\x15external_dependencies\x18\x04 \x03(\tR\x14externalDependencies\"k\n" +
+	"\x11PackageDependency\x12-\n" +
+	"\x12package_identifier\x18\x01 \x01(\tR\x11packageIdentifier\x12'\n" +
+	"\x0fminimum_version\x18\x02 \x01(\tR\x0eminimumVersion2\xc8\x02\n" +
+	"\x0ePackageService\x12C\n" +
+	"\x06Search\x12\x1b.wingetpkg.v1.SearchRequest\x1a\x1c.wingetpkg.v1.SearchResponse\x12O\n" +
+	"\n" +
+	"GetPackage\x12\x1f.wingetpkg.v1.GetPackageRequest\x1a .wingetpkg.v1.GetPackageResponse\x12U\n" +
+	"\fListVersions\x12!.wingetpkg.v1.ListVersionsRequest\x1a\".wingetpkg.v1.ListVersionsResponse\x12I\n" +
+	"\bBatchGet\x12\x1d.wingetpkg.v1.BatchGetRequest\x1a\x1e.wingetpkg.v1.BatchGetResponseBLZJgithub.com/iamBijoyKar/winget-pkg/api/internal/rpc/wingetpkgv1;wingetpkgv1b\x06proto3"
+
+var (
+	file_wingetpkg_v1_package_service_proto_rawDescOnce sync.Once
+	file_wingetpkg_v1_package_service_proto_rawDescData []byte
+)
+
+func file_wingetpkg_v1_package_service_proto_rawDescGZIP() []byte {
+	file_wingetpkg_v1_package_service_proto_rawDescOnce.Do(func() {
+		file_wingetpkg_v1_package_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_wingetpkg_v1_package_service_proto_rawDesc), len(file_wingetpkg_v1_package_service_proto_rawDesc)))
+	})
+	return file_wingetpkg_v1_package_service_proto_rawDescData
+}
+
+var file_wingetpkg_v1_package_service_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_wingetpkg_v1_package_service_proto_goTypes = []any{
+	(*SearchRequest)(nil),         // 0: wingetpkg.v1.SearchRequest
+	(*SearchResponse)(nil),        // 1: wingetpkg.v1.SearchResponse
+	(*GetPackageRequest)(nil),     // 2: wingetpkg.v1.GetPackageRequest
+	(*GetPackageResponse)(nil),    // 3: wingetpkg.v1.GetPackageResponse
+	(*ListVersionsRequest)(nil),   // 4: wingetpkg.v1.ListVersionsRequest
+	(*ListVersionsResponse)(nil),  // 5: wingetpkg.v1.ListVersionsResponse
+	(*BatchGetRequest)(nil),       // 6: wingetpkg.v1.BatchGetRequest
+	(*BatchGetResponse)(nil),      // 7: wingetpkg.v1.BatchGetResponse
+	(*Package)(nil),               // 8: wingetpkg.v1.Package
+	(*Version)(nil),               // 9: wingetpkg.v1.Version
+	(*Locale)(nil),                // 10: wingetpkg.v1.Locale
+	(*Installer)(nil),             // 11: wingetpkg.v1.Installer
+	(*InstallerSwitches)(nil),     // 12: wingetpkg.v1.InstallerSwitches
+	(*InstallerDependencies)(nil), // 13: wingetpkg.v1.InstallerDependencies
+	(*PackageDependency)(nil),     // 14: wingetpkg.v1.PackageDependency
+}
+var file_wingetpkg_v1_package_service_proto_depIdxs = []int32{
+	8,  // 0: wingetpkg.v1.SearchResponse.packages:type_name -> wingetpkg.v1.Package
+	8,  // 1: wingetpkg.v1.GetPackageResponse.package:type_name -> wingetpkg.v1.Package
+	9,  // 2: wingetpkg.v1.ListVersionsResponse.versions:type_name -> wingetpkg.v1.Version
+	8,  // 3: wingetpkg.v1.BatchGetResponse.packages:type_name -> wingetpkg.v1.Package
+	9,  // 4: wingetpkg.v1.Package.versions:type_name -> wingetpkg.v1.Version
+	10, // 5: wingetpkg.v1.Version.locales:type_name -> wingetpkg.v1.Locale
+	11, // 6: wingetpkg.v1.Version.installers:type_name -> wingetpkg.v1.Installer
+	12, // 7: wingetpkg.v1.Installer.switches:type_name -> wingetpkg.v1.InstallerSwitches
+	13, // 8: wingetpkg.v1.Installer.dependencies:type_name -> wingetpkg.v1.InstallerDependencies
+	14, // 9: wingetpkg.v1.InstallerDependencies.package_dependencies:type_name -> wingetpkg.v1.PackageDependency
+	0,  // 10: wingetpkg.v1.PackageService.Search:input_type -> wingetpkg.v1.SearchRequest
+	2,  // 11: wingetpkg.v1.PackageService.GetPackage:input_type -> wingetpkg.v1.GetPackageRequest
+	4,  // 12: wingetpkg.v1.PackageService.ListVersions:input_type -> wingetpkg.v1.ListVersionsRequest
+	6,  // 13: wingetpkg.v1.PackageService.BatchGet:input_type -> wingetpkg.v1.BatchGetRequest
+	1,  // 14: wingetpkg.v1.PackageService.Search:output_type -> wingetpkg.v1.SearchResponse
+	3,  // 15: wingetpkg.v1.PackageService.GetPackage:output_type -> wingetpkg.v1.GetPackageResponse
+	5,  // 16: wingetpkg.v1.PackageService.ListVersions:output_type -> wingetpkg.v1.ListVersionsResponse
+	7,  // 17: wingetpkg.v1.PackageService.BatchGet:output_type -> wingetpkg.v1.BatchGetResponse
+	14, // [14:18] is the sub-list for method output_type
+	10, // [10:14] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_wingetpkg_v1_package_service_proto_init() }
+func file_wingetpkg_v1_package_service_proto_init() {
+	if File_wingetpkg_v1_package_service_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_wingetpkg_v1_package_service_proto_rawDesc), len(file_wingetpkg_v1_package_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_wingetpkg_v1_package_service_proto_goTypes,
+		DependencyIndexes: file_wingetpkg_v1_package_service_proto_depIdxs,
+		MessageInfos:      file_wingetpkg_v1_package_service_proto_msgTypes,
+	}.Build()
+	File_wingetpkg_v1_package_service_proto = out.File
+	file_wingetpkg_v1_package_service_proto_goTypes = nil
+	file_wingetpkg_v1_package_service_proto_depIdxs = nil
+}
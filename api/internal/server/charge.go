@@ -0,0 +1,85 @@
+package server
+
+import "strconv"
+
+// Charge is the outcome of counting one call against the rate limit and
+// quotas of the key that made it. HTTP and gRPC report it in their own way.
+type Charge struct {
+	Tier Tier
+	// Cost is what the call took, capped at Limit
+	Cost  int
+	Limit int
+	// Headers are the X-RateLimit-* and X-Quota-* headers describing the
+	// limit and quotas after the call
+	Headers map[string]string
+	// Code is CodeRateLimited or CodeQuotaExceeded when the call was
+	// refused, and "" when it may go ahead
+	Code string
+	// Quota is the quota that refused the call, "daily" or "monthly"
+	Quota string
+	// RetryAfter is how many seconds a refused call should wait
+	RetryAfter int
+}
+
+// Allowed reports whether the call may go ahead
+func (c Charge) Allowed() bool {
+	return c.Code == ""
+}
+
+// Charge takes cost from the rate limit of the key with keyID and, unless the
+// call is free, counts it against the key's quotas. tier is the name of the
+// key's tier. Calls are counted per key whichever transport they came in on,
+// so HTTP requests and gRPC calls share a window.
+func (tl *TierLimiter) Charge(keyID, tier string, cost int) Charge {
+	key := CallerKey(keyID, "")
+	t := tl.Tier(tier)
+	limiter := tl.Limiter(t)
+	now := tl.clock()
+
+	remaining, reset, limit := GetRateLimitInfo(key, limiter)
+	// Routes costing more than the tier allows at once take only the limit
+	cost = Charged(cost, limit)
+	charge := Charge{
+		Tier:  t,
+		Cost:  cost,
+		Limit: limit,
+		Headers: map[string]string{
+			"X-RateLimit-Limit":     strconv.Itoa(limit),
+			"X-RateLimit-Remaining": strconv.Itoa(remaining),
+			"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+			"X-RateLimit-Tier":      t.Name,
+			"X-RateLimit-Cost":      strconv.Itoa(cost),
+		},
+	}
+
+	if !CheckRateLimit(key, limiter, cost) {
+		if upgrade := t.SuggestUpgrade(tl.QuotaUsage(keyID), true); upgrade != "" {
+			charge.Headers["X-RateLimit-Upgrade-Suggested"] = upgrade
+		}
+		charge.Code = CodeRateLimited
+		charge.RetryAfter = int(reset.Sub(now).Seconds())
+		return charge
+	}
+
+	// Free calls do not count against the quotas
+	if cost == 0 {
+		return charge
+	}
+	usage, ok := tl.UseQuota(keyID, t)
+	for name, value := range t.QuotaHeaders(usage) {
+		charge.Headers[name] = value
+	}
+	if upgrade := t.SuggestUpgrade(usage, !ok); upgrade != "" {
+		charge.Headers["X-RateLimit-Upgrade-Suggested"] = upgrade
+	}
+	if !ok {
+		charge.Code = CodeQuotaExceeded
+		charge.Quota = "daily"
+		resetAt := usage.DailyReset
+		if t.Monthly > 0 && usage.Monthly >= t.Monthly {
+			charge.Quota, resetAt = "monthly", usage.MonthlyReset
+		}
+		charge.RetryAfter = int(resetAt.Sub(now).Seconds())
+	}
+	return charge
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCharge(t *testing.T) {
+	clock := newFakeClock()
+	tiers := NewTierLimiter([]Tier{{Name: "free", Limit: 10, Window: time.Minute, Daily: 3}}, nil, FixedWindow, clock.Now)
+	t.Cleanup(tiers.Stop)
+
+	// Free calls take nothing from the limit or the quota
+	if c := tiers.Charge("k1", "free", FreeCost); !c.Allowed() || c.Headers["X-Quota-Daily-Limit"] != "" {
+		t.Errorf("free call = %+v", c)
+	}
+	// Costs above the limit take the whole limit, and unknown tiers are the first
+	c := tiers.Charge("k1", "unknown", ExportCost)
+	if !c.Allowed() || c.Cost != 10 || c.Tier.Name != "free" || c.Headers["X-Quota-Daily-Remaining"] != "2" {
+		t.Errorf("export call = %+v", c)
+	}
+	c = tiers.Charge("k1", "free", DefaultCost)
+	if c.Code != CodeRateLimited || c.RetryAfter != 60 {
+		t.Errorf("call over the limit = %q retry after %d, want %q after 60", c.Code, c.RetryAfter, CodeRateLimited)
+	}
+
+	clock.Advance(time.Minute + time.Second)
+	tiers.Charge("k1", "free", DefaultCost)
+	tiers.Charge("k1", "free", DefaultCost)
+	c = tiers.Charge("k1", "free", DefaultCost)
+	if c.Code != CodeQuotaExceeded || c.Quota != "daily" || c.RetryAfter != int(24*time.Hour/time.Second)-61 {
+		t.Errorf("call over the quota = %q %q retry after %d", c.Code, c.Quota, c.RetryAfter)
+	}
+}
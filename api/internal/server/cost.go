@@ -0,0 +1,43 @@
+package server
+
+// Costs of the kinds of routes, in requests of the rate limit
+const (
+	// FreeCost is for health and status checks, which never use up the limit
+	FreeCost = 0
+	// DefaultCost is for exact lookups and every route not given a cost
+	DefaultCost = 1
+	// SearchCost is for routes scanning the catalog for partial matches
+	SearchCost = 3
+	// ExportCost is for full catalog downloads
+	ExportCost = 50
+	// QueryCost marks routes that charge the rate limit themselves once
+	// they know what a call costs, such as GraphQL queries
+	QueryCost = -1
+	// ItemCost marks batch calls, which cost DefaultCost per item they look up
+	ItemCost = -2
+)
+
+// Costs maps routes to how many requests of the rate limit one call uses.
+// HTTP routes are keyed by gin's FullPath and gRPC methods by their full
+// method name.
+type Costs map[string]int
+
+// Cost returns the cost of route, DefaultCost for routes not in c
+func (c Costs) Cost(route string) int {
+	if cost, ok := c[route]; ok {
+		return cost
+	}
+	return DefaultCost
+}
+
+// chargeable caps cost at capacity, so a route costing more than a tier
+// allows at once still goes through on an unused allowance
+func chargeable(cost, capacity int) int {
+	return min(cost, capacity)
+}
+
+// Charged returns how much of a limit of limit requests at once a call
+// costing cost takes, as reported in the X-RateLimit-Cost header
+func Charged(cost, limit int) int {
+	return chargeable(cost, limit)
+}
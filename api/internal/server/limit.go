@@ -1,23 +1,172 @@
 package server
 
 import (
+	"fmt"
 	"sync"
 	"time"
 )
 
-type RateLimiterData struct {
-	IP        string
-	Remaining int       // Remaining requests in the current window
-	Reset     time.Time // Time when the rate limit will reset
-	LastSeen  time.Time // Last time this IP made a request
+// Limiter decides whether a caller, identified by a key such as the one from
+// CallerKey, may make another request. Every strategy implements it.
+type Limiter interface {
+	// Allow takes cost requests from the allowance of key and reports whether
+	// they were within the limit. A cost of 0 is always allowed; costs above
+	// the limit take the whole allowance.
+	Allow(key string, cost int) bool
+	// Info reports the requests key has left, when its full allowance is
+	// restored and the most requests it can make at once, without counting
+	// a request
+	Info(key string) (remaining int, reset time.Time, limit int)
+	// GetStats returns statistics about the limiter
+	GetStats() map[string]interface{}
+	// Stop stops the cleanup of idle callers
+	Stop()
+}
+
+// Strategy selects the algorithm a Limiter uses
+type Strategy string
+
+const (
+	// FixedWindow counts requests in consecutive windows. It is the cheapest,
+	// but lets a caller make twice the limit around a window boundary.
+	FixedWindow Strategy = "fixed-window"
+	// TokenBucket refills Limit tokens per Window up to Burst, smoothing
+	// traffic while allowing short bursts
+	TokenBucket Strategy = "token-bucket"
+	// SlidingLog remembers the time of each request in the last Window. It is
+	// exact, but keeps up to Limit timestamps per caller.
+	SlidingLog Strategy = "sliding-log"
+	// SlidingWindow weighs the count of the previous window by how much of it
+	// still overlaps the last Window, approximating SlidingLog in constant
+	// memory
+	SlidingWindow Strategy = "sliding-window"
+)
+
+// AllStrategies lists every strategy NewLimiter knows
+var AllStrategies = []Strategy{FixedWindow, TokenBucket, SlidingLog, SlidingWindow}
+
+// ParseStrategy validates a strategy name. An empty name is FixedWindow.
+func ParseStrategy(name string) (Strategy, error) {
+	if name == "" {
+		return FixedWindow, nil
+	}
+	for _, s := range AllStrategies {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown rate limit strategy %q", name)
 }
 
+// Clock returns the current time. Limiters read time only through their
+// clock, so a fake one makes them deterministic.
+type Clock func() time.Time
+
 type RateLimiterConfig struct {
+	Strategy        Strategy
 	Limit           int           // Maximum requests allowed in the window
 	Window          time.Duration // Duration of the rate limit window
+	Burst           int           // Token bucket capacity, Limit when 0
 	CleanupInterval time.Duration // How often to clean up old entries
+	Clock           Clock         // time.Now when nil
 }
 
+// withDefaults fills in the fields of config left at their zero value
+func (config RateLimiterConfig) withDefaults() RateLimiterConfig {
+	if config.Strategy == "" {
+		config.Strategy = FixedWindow
+	}
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = config.Window * 2 // Clean up entries older than 2 windows
+	}
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+	if config.Burst <= 0 {
+		config.Burst = config.Limit
+	}
+	return config
+}
+
+// Backend stores the state of rate limited callers and their quotas.
+// MemoryBackend, the default, keeps it in the process; RedisBackend shares it
+// between instances.
+type Backend interface {
+	// NewLimiter creates a limiter keeping its callers in the backend. name
+	// tells apart the limiters of one backend, such as those of each tier.
+	NewLimiter(name string, config RateLimiterConfig) Limiter
+	// NewQuotas creates the daily and monthly quota counters
+	NewQuotas() Quotas
+}
+
+// MemoryBackend keeps every limiter's callers in a map of its own
+type MemoryBackend struct{}
+
+// NewLimiter implements Backend
+func (MemoryBackend) NewLimiter(name string, config RateLimiterConfig) Limiter {
+	return NewLimiter(config)
+}
+
+// NewQuotas implements Backend
+func (MemoryBackend) NewQuotas() Quotas {
+	return NewQuotaTracker()
+}
+
+// NewLimiter creates an in-memory limiter using the strategy of config,
+// FixedWindow for strategies it does not know. A CleanupInterval of 0 is two
+// windows.
+func NewLimiter(config RateLimiterConfig) Limiter {
+	config = config.withDefaults()
+
+	switch config.Strategy {
+	case TokenBucket:
+		return newTokenBucketLimiter(config)
+	case SlidingLog:
+		return newSlidingLogLimiter(config)
+	case SlidingWindow:
+		return newSlidingWindowLimiter(config)
+	default:
+		config.Strategy = FixedWindow
+		return newFixedWindowLimiter(config)
+	}
+}
+
+// cleanupRoutine calls cleanup every interval until stop is closed
+func cleanupRoutine(interval time.Duration, stop <-chan struct{}, cleanup func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cleanup()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Function to check if a request of the caller identified by key, costing
+// cost requests, exceeds the rate limit. Allowed requests are deducted.
+func CheckRateLimit(key string, limiter Limiter, cost int) bool {
+	return limiter.Allow(key, cost)
+}
+
+// Get rate limit information for a caller (useful for headers)
+func GetRateLimitInfo(key string, limiter Limiter) (remaining int, reset time.Time, limit int) {
+	return limiter.Info(key)
+}
+
+// RateLimiterData is the window of one caller: an API key, or the client IP of
+// unauthenticated requests
+type RateLimiterData struct {
+	Key       string
+	Remaining int       // Remaining requests in the current window
+	Reset     time.Time // Time when the rate limit will reset
+	LastSeen  time.Time // Last time this caller made a request
+}
+
+// RateLimiter is the FixedWindow limiter
 type RateLimiter struct {
 	data   map[string]RateLimiterData
 	config RateLimiterConfig
@@ -25,19 +174,20 @@ type RateLimiter struct {
 	stop   chan struct{}
 }
 
+// CreateRateLimiter creates a FixedWindow limiter on the system clock
 func CreateRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return NewLimiter(RateLimiterConfig{Limit: limit, Window: window}).(*RateLimiter)
+}
+
+func newFixedWindowLimiter(config RateLimiterConfig) *RateLimiter {
 	rl := &RateLimiter{
-		data: make(map[string]RateLimiterData),
-		config: RateLimiterConfig{
-			Limit:           limit,
-			Window:          window,
-			CleanupInterval: window * 2, // Clean up entries older than 2 windows
-		},
-		stop: make(chan struct{}),
+		data:   make(map[string]RateLimiterData),
+		config: config,
+		stop:   make(chan struct{}),
 	}
 
 	// Start cleanup goroutine
-	go rl.cleanupRoutine()
+	go cleanupRoutine(config.CleanupInterval, rl.stop, rl.cleanup)
 
 	return rl
 }
@@ -47,51 +197,41 @@ func (rl *RateLimiter) Stop() {
 	close(rl.stop)
 }
 
-// Cleanup routine to remove old entries
-func (rl *RateLimiter) cleanupRoutine() {
-	ticker := time.NewTicker(rl.config.CleanupInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			rl.cleanup()
-		case <-rl.stop:
-			return
-		}
-	}
-}
-
 // Remove old entries to prevent memory leaks
 func (rl *RateLimiter) cleanup() {
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
 
-	cutoff := time.Now().Add(-rl.config.CleanupInterval)
-	for ip, data := range rl.data {
+	cutoff := rl.config.Clock().Add(-rl.config.CleanupInterval)
+	for key, data := range rl.data {
 		if data.LastSeen.Before(cutoff) {
-			delete(rl.data, ip)
+			delete(rl.data, key)
 		}
 	}
 }
 
-// Function to check if the request exceeds the rate limit
-func CheckRateLimit(ip string, rateLimiter *RateLimiter) bool {
-	rateLimiter.mutex.Lock()
-	defer rateLimiter.mutex.Unlock()
+// Allow implements Limiter
+func (rl *RateLimiter) Allow(key string, cost int) bool {
+	if cost <= 0 {
+		return true
+	}
+	cost = chargeable(cost, rl.config.Limit)
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
 
-	now := time.Now()
-	data, exists := rateLimiter.data[ip]
+	now := rl.config.Clock()
+	data, exists := rl.data[key]
 
 	if !exists {
-		// If no data exists for the IP, create a new entry
+		// If no data exists for the caller, create a new entry
 		data = RateLimiterData{
-			IP:        ip,
-			Remaining: rateLimiter.config.Limit - 1, // Subtract 1 for current request
-			Reset:     now.Add(rateLimiter.config.Window),
+			Key:       key,
+			Remaining: rl.config.Limit - cost, // Subtract the current request
+			Reset:     now.Add(rl.config.Window),
 			LastSeen:  now,
 		}
-		rateLimiter.data[ip] = data
+		rl.data[key] = data
 		return true
 	}
 
@@ -101,40 +241,41 @@ func CheckRateLimit(ip string, rateLimiter *RateLimiter) bool {
 	// Check if the window has expired
 	if now.After(data.Reset) {
 		// Reset the rate limit if the window has expired
-		data.Remaining = rateLimiter.config.Limit - 1 // Subtract 1 for current request
-		data.Reset = now.Add(rateLimiter.config.Window)
-		rateLimiter.data[ip] = data
+		data.Remaining = rl.config.Limit - cost // Subtract the current request
+		data.Reset = now.Add(rl.config.Window)
+		rl.data[key] = data
 		return true
 	}
 
-	// Check if there are remaining requests
-	if data.Remaining > 0 {
-		data.Remaining--
-		rateLimiter.data[ip] = data
+	// Check if there are enough remaining requests
+	if data.Remaining >= cost {
+		data.Remaining -= cost
+		rl.data[key] = data
 		return true
 	}
 
 	// Rate limit exceeded, update the data but don't allow the request
-	rateLimiter.data[ip] = data
+	rl.data[key] = data
 	return false
 }
 
-// Get rate limit information for an IP (useful for headers)
-func GetRateLimitInfo(ip string, rateLimiter *RateLimiter) (remaining int, reset time.Time, limit int) {
-	rateLimiter.mutex.RLock()
-	defer rateLimiter.mutex.RUnlock()
+// Info implements Limiter
+func (rl *RateLimiter) Info(key string) (remaining int, reset time.Time, limit int) {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
 
-	data, exists := rateLimiter.data[ip]
+	now := rl.config.Clock()
+	data, exists := rl.data[key]
 	if !exists {
-		return rateLimiter.config.Limit, time.Now().Add(rateLimiter.config.Window), rateLimiter.config.Limit
+		return rl.config.Limit, now.Add(rl.config.Window), rl.config.Limit
 	}
 
 	// Check if window has expired
-	if time.Now().After(data.Reset) {
-		return rateLimiter.config.Limit, time.Now().Add(rateLimiter.config.Window), rateLimiter.config.Limit
+	if now.After(data.Reset) {
+		return rl.config.Limit, now.Add(rl.config.Window), rl.config.Limit
 	}
 
-	return data.Remaining, data.Reset, rateLimiter.config.Limit
+	return data.Remaining, data.Reset, rl.config.Limit
 }
 
 // Get current statistics about the rate limiter
@@ -143,7 +284,8 @@ func (rl *RateLimiter) GetStats() map[string]interface{} {
 	defer rl.mutex.RUnlock()
 
 	return map[string]interface{}{
-		"active_ips":       len(rl.data),
+		"strategy":         string(rl.config.Strategy),
+		"active_keys":      len(rl.data),
 		"limit":            rl.config.Limit,
 		"window":           rl.config.Window.String(),
 		"cleanup_interval": rl.config.CleanupInterval.String(),
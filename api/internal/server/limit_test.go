@@ -0,0 +1,249 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock the tests move by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter(t *testing.T, clock *fakeClock, config RateLimiterConfig) Limiter {
+	t.Helper()
+	config.Clock = clock.Now
+	l := NewLimiter(config)
+	t.Cleanup(l.Stop)
+	return l
+}
+
+// allowN makes n requests of cost 1 and returns how many were allowed
+func allowN(l Limiter, key string, n int) int {
+	allowed := 0
+	for range n {
+		if l.Allow(key, 1) {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestParseStrategy(t *testing.T) {
+	for _, s := range AllStrategies {
+		if got, err := ParseStrategy(string(s)); err != nil || got != s {
+			t.Errorf("ParseStrategy(%q) = %q, %v", s, got, err)
+		}
+	}
+	if got, err := ParseStrategy(""); err != nil || got != FixedWindow {
+		t.Errorf(`ParseStrategy("") = %q, %v, want fixed-window`, got, err)
+	}
+	if _, err := ParseStrategy("leaky-bucket"); err == nil {
+		t.Error("ParseStrategy accepted an unknown strategy")
+	}
+}
+
+func TestFixedWindowBoundary(t *testing.T) {
+	clock := newFakeClock()
+	l := newTestLimiter(t, clock, RateLimiterConfig{Strategy: FixedWindow, Limit: 10, Window: time.Second})
+
+	// The window starts with the caller's first request, so a burst just
+	// before a wall clock second and one just after share it
+	clock.Advance(900 * time.Millisecond)
+	if got := allowN(l, "k", 10); got != 10 {
+		t.Fatalf("allowed %d of the first 10 requests", got)
+	}
+	clock.Advance(200 * time.Millisecond)
+	if got := allowN(l, "k", 10); got != 0 {
+		t.Errorf("allowed %d requests across the second boundary, want 0", got)
+	}
+
+	// The window ends one Window after it started, not before
+	clock.Advance(800 * time.Millisecond)
+	if l.Allow("k", 1) {
+		t.Error("allowed a request at the exact end of the window")
+	}
+	remaining, reset, limit := l.Info("k")
+	if remaining != 0 || limit != 10 || !reset.Equal(clock.now) {
+		t.Errorf("Info = %d, %v, %d, want 0, %v, 10", remaining, reset, limit, clock.now)
+	}
+	clock.Advance(time.Millisecond)
+	if got := allowN(l, "k", 11); got != 10 {
+		t.Errorf("allowed %d requests in the next window, want 10", got)
+	}
+}
+
+func TestFixedWindowCallersAreIndependent(t *testing.T) {
+	clock := newFakeClock()
+	l := newTestLimiter(t, clock, RateLimiterConfig{Strategy: FixedWindow, Limit: 3, Window: time.Second})
+
+	if got := allowN(l, "a", 5); got != 3 {
+		t.Errorf("allowed %d requests of a, want 3", got)
+	}
+	if got := allowN(l, "b", 5); got != 3 {
+		t.Errorf("allowed %d requests of b, want 3", got)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := newFakeClock()
+	l := newTestLimiter(t, clock, RateLimiterConfig{Strategy: TokenBucket, Limit: 10, Window: time.Second, Burst: 20})
+
+	// A new bucket is full, so the whole burst passes at once
+	if got := allowN(l, "k", 25); got != 20 {
+		t.Fatalf("allowed %d of a burst of 25, want the burst capacity of 20", got)
+	}
+
+	// Tokens come back at Limit per Window
+	clock.Advance(100 * time.Millisecond)
+	if got := allowN(l, "k", 5); got != 1 {
+		t.Errorf("allowed %d requests after 100ms, want 1", got)
+	}
+	clock.Advance(500 * time.Millisecond)
+	if got := allowN(l, "k", 10); got != 5 {
+		t.Errorf("allowed %d requests after 500ms, want 5", got)
+	}
+
+	// The bucket never holds more than the burst
+	clock.Advance(time.Minute)
+	remaining, reset, limit := l.Info("k")
+	if remaining != 20 || limit != 20 || !reset.Equal(clock.now) {
+		t.Errorf("Info of a full bucket = %d, %v, %d, want 20, %v, 20", remaining, reset, limit, clock.now)
+	}
+	if got := allowN(l, "k", 25); got != 20 {
+		t.Errorf("allowed %d requests from a refilled bucket, want 20", got)
+	}
+
+	// An empty bucket is full again Burst/Limit windows later
+	_, reset, _ = l.Info("k")
+	if want := clock.now.Add(2 * time.Second); !reset.Equal(want) {
+		t.Errorf("empty bucket resets at %v, want %v", reset, want)
+	}
+}
+
+func TestSlidingLogEviction(t *testing.T) {
+	clock := newFakeClock()
+	l := newTestLimiter(t, clock, RateLimiterConfig{Strategy: SlidingLog, Limit: 10, Window: time.Second})
+
+	if got := allowN(l, "k", 5); got != 5 {
+		t.Fatalf("allowed %d of the first 5 requests", got)
+	}
+	clock.Advance(500 * time.Millisecond)
+	if got := allowN(l, "k", 10); got != 5 {
+		t.Fatalf("allowed %d requests half a window later, want 5", got)
+	}
+
+	// The first five leave the window exactly one Window after they were made
+	clock.Advance(499 * time.Millisecond)
+	if l.Allow("k", 1) {
+		t.Error("allowed a request before any left the window")
+	}
+	clock.Advance(time.Millisecond)
+	if got := allowN(l, "k", 10); got != 5 {
+		t.Errorf("allowed %d requests once the first five left the window, want 5", got)
+	}
+	remaining, reset, _ := l.Info("k")
+	if remaining != 0 || !reset.Equal(clock.now.Add(time.Second)) {
+		t.Errorf("Info = %d, %v, want 0, %v", remaining, reset, clock.now.Add(time.Second))
+	}
+
+	// Callers whose requests all left the window are cleaned up
+	clock.Advance(time.Second)
+	sl := l.(*slidingLogLimiter)
+	sl.cleanup()
+	if n := sl.GetStats()["active_keys"]; n != 0 {
+		t.Errorf("%v callers left after cleanup, want 0", n)
+	}
+}
+
+func TestSlidingWindowWeighting(t *testing.T) {
+	clock := newFakeClock()
+	l := newTestLimiter(t, clock, RateLimiterConfig{Strategy: SlidingWindow, Limit: 10, Window: time.Second})
+
+	if got := allowN(l, "k", 10); got != 10 {
+		t.Fatalf("allowed %d of the first 10 requests", got)
+	}
+
+	tests := []struct {
+		at      time.Duration // Time since the first window started
+		allowed int
+	}{
+		// 10 previous requests weigh 7.5 with a quarter of the window gone
+		{at: 1250 * time.Millisecond, allowed: 2},
+		// they weigh 5 at half way, next to the 2 already made
+		{at: 1500 * time.Millisecond, allowed: 3},
+		// the 5 made in the second window weigh 3.75 a quarter into the third
+		{at: 2250 * time.Millisecond, allowed: 6},
+		// two windows without requests forget everything
+		{at: 5 * time.Second, allowed: 10},
+	}
+	start := clock.now
+	for _, tt := range tests {
+		clock.now = start.Add(tt.at)
+		if got := allowN(l, "k", 20); got != tt.allowed {
+			t.Errorf("at %v allowed %d requests, want %d", tt.at, got, tt.allowed)
+		}
+	}
+}
+
+func TestSlidingStrategiesCapAcrossBoundary(t *testing.T) {
+	for _, strategy := range []Strategy{SlidingLog, SlidingWindow} {
+		t.Run(string(strategy), func(t *testing.T) {
+			clock := newFakeClock()
+			l := newTestLimiter(t, clock, RateLimiterConfig{Strategy: strategy, Limit: 10, Window: time.Second})
+
+			// Unlike a fixed window, a burst at the end of a window and one
+			// right after it stay within one Limit together
+			allowN(l, "k", 1)
+			clock.Advance(950 * time.Millisecond)
+			before := allowN(l, "k", 10)
+			clock.Advance(100 * time.Millisecond)
+			after := allowN(l, "k", 10)
+			if before+after > 10 {
+				t.Errorf("allowed %d+%d requests within 100ms, want at most 10", before, after)
+			}
+		})
+	}
+}
+
+func TestCost(t *testing.T) {
+	for _, strategy := range AllStrategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			clock := newFakeClock()
+			l := newTestLimiter(t, clock, RateLimiterConfig{Strategy: strategy, Limit: 20, Window: time.Second})
+
+			if !l.Allow("k", 0) {
+				t.Error("refused a free request")
+			}
+			if remaining, _, _ := l.Info("k"); remaining != 20 {
+				t.Errorf("a free request used %d of the limit", 20-remaining)
+			}
+			if !l.Allow("k", 3) {
+				t.Fatal("refused a request costing 3")
+			}
+			if remaining, _, _ := l.Info("k"); remaining != 17 {
+				t.Errorf("%d remaining after a request costing 3, want 17", remaining)
+			}
+
+			// A cost above the limit takes the whole allowance of a fresh
+			// caller rather than never passing
+			if !l.Allow("other", 50) {
+				t.Error("refused a request costing more than the limit on an unused allowance")
+			}
+			if remaining, _, _ := l.Info("other"); remaining != 0 {
+				t.Errorf("%d remaining after a request costing more than the limit, want 0", remaining)
+			}
+			if l.Allow("other", 1) {
+				t.Error("allowed a request after the allowance was used up")
+			}
+		})
+	}
+}
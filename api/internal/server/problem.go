@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stable error codes clients can branch on
+const (
+	CodeMissingKey       = "auth.missing_key"
+	CodeInvalidKey       = "auth.invalid_key"
+	CodeExpiredKey       = "auth.expired_key"
+	CodeRevokedKey       = "auth.revoked_key"
+	CodeForbidden        = "auth.forbidden"
+	CodeRateLimited      = "rate_limited"
+	CodeQuotaExceeded    = "quota_exceeded"
+	CodeMissingParam     = "query.missing_param"
+	CodeInvalidParam     = "query.invalid_param"
+	CodeInvalidBody      = "request.invalid_body"
+	CodeInvalidHeader    = "request.invalid_header"
+	CodeLimitReached     = "resource.limit_reached"
+	CodeStoreUnavailable = "store.unavailable"
+	CodeNotFound         = "resource.not_found"
+	CodeConflict         = "resource.conflict"
+	CodeInternal         = "internal"
+)
+
+// problemTypeBase prefixes the error code to form the problem type URI
+const problemTypeBase = "https://winget-pkg-api.onrender.com/problems/"
+
+// problemTitles holds the short, code specific summary of each problem type
+var problemTitles = map[string]string{
+	CodeMissingKey:       "API key is required",
+	CodeInvalidKey:       "Invalid API key",
+	CodeExpiredKey:       "API key expired",
+	CodeRevokedKey:       "API key revoked",
+	CodeForbidden:        "Not allowed for this API key",
+	CodeRateLimited:      "Rate limit exceeded",
+	CodeQuotaExceeded:    "Quota exceeded",
+	CodeMissingParam:     "Missing required parameter",
+	CodeInvalidParam:     "Invalid parameter",
+	CodeInvalidBody:      "Invalid request body",
+	CodeInvalidHeader:    "Invalid request header",
+	CodeLimitReached:     "Resource limit reached",
+	CodeStoreUnavailable: "Package store unavailable",
+	CodeNotFound:         "Resource not found",
+	CodeConflict:         "Resource conflict",
+	CodeInternal:         "Internal server error",
+}
+
+// Problem is an RFC 9457 problem details document
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Code       string
+	Extensions map[string]interface{}
+}
+
+// NewProblem builds a problem for one of the error codes
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypeBase + code,
+		Title:  problemTitles[code],
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// With adds an extension member to the problem
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// MarshalJSON flattens the extension members next to the standard ones
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	doc := make(map[string]interface{}, len(p.Extensions)+6)
+	for k, v := range p.Extensions {
+		doc[k] = v
+	}
+	doc["type"] = p.Type
+	doc["title"] = p.Title
+	doc["status"] = p.Status
+	doc["code"] = p.Code
+	if p.Detail != "" {
+		doc["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		doc["instance"] = p.Instance
+	}
+	return json.Marshal(doc)
+}
+
+// AbortWithProblem writes p as application/problem+json and stops the handler chain
+func AbortWithProblem(c *gin.Context, p *Problem) {
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	c.Render(p.Status, problemRender{p})
+	c.Abort()
+}
+
+// problemRender renders a problem with the problem+json content type
+type problemRender struct {
+	problem *Problem
+}
+
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	body, err := json.Marshal(r.problem)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header()["Content-Type"] = []string{"application/problem+json"}
+}
+
+// MissingParam reports a required query or path parameter that was not given
+func MissingParam(c *gin.Context, param string) {
+	AbortWithProblem(c, NewProblem(400, CodeMissingParam, "Parameter '"+param+"' is required").With("param", param))
+}
+
+// InvalidParam reports a parameter whose value breaks its documented constraints
+func InvalidParam(c *gin.Context, param, reason string) {
+	AbortWithProblem(c, NewProblem(400, CodeInvalidParam, "Parameter '"+param+"' "+reason).With("param", param))
+}
+
+// InvalidBody reports a request body field that is missing or malformed
+func InvalidBody(c *gin.Context, field, reason string) {
+	AbortWithProblem(c, NewProblem(400, CodeInvalidBody, "Field '"+field+"' "+reason).With("field", field))
+}
+
+// InvalidHeader reports a request header whose value cannot be used
+func InvalidHeader(c *gin.Context, header, reason string) {
+	AbortWithProblem(c, NewProblem(400, CodeInvalidHeader, "Header '"+header+"' "+reason).With("header", header))
+}
+
+// StoreUnavailable reports a failed database query
+func StoreUnavailable(c *gin.Context, detail string) {
+	AbortWithProblem(c, NewProblem(503, CodeStoreUnavailable, detail))
+}
+
+// NotFound reports a missing resource
+func NotFound(c *gin.Context, detail string) {
+	AbortWithProblem(c, NewProblem(404, CodeNotFound, detail))
+}
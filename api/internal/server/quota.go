@@ -0,0 +1,132 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// QuotaUsage is how many requests a key made in the current UTC day and month
+type QuotaUsage struct {
+	Daily        int
+	Monthly      int
+	DailyReset   time.Time // Start of the next UTC day
+	MonthlyReset time.Time // Start of the next UTC month
+}
+
+// Quotas counts the requests of each key against the daily and monthly
+// quotas of its tier
+type Quotas interface {
+	// Use counts a request of key if the quotas of tier allow it. It returns
+	// the usage including the request, and false when a quota was already
+	// used up.
+	Use(key string, tier Tier, now time.Time) (QuotaUsage, bool)
+	// Usage returns the usage of key at now without counting a request
+	Usage(key string, now time.Time) QuotaUsage
+	// GetStats returns statistics about the counters
+	GetStats() map[string]interface{}
+}
+
+// quotaCounter counts the requests of one key
+type quotaCounter struct {
+	day     time.Time // Start of the day being counted
+	month   time.Time // Start of the month being counted
+	daily   int
+	monthly int
+}
+
+// QuotaTracker counts requests per API key for daily and monthly quotas in
+// process memory
+type QuotaTracker struct {
+	counters map[string]*quotaCounter
+	mutex    sync.Mutex
+}
+
+func NewQuotaTracker() *QuotaTracker {
+	return &QuotaTracker{counters: make(map[string]*quotaCounter)}
+}
+
+// periods returns the start of the UTC day and month containing now
+func periods(now time.Time) (day, month time.Time) {
+	now = now.UTC()
+	day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, month
+}
+
+// counter returns the counter of key, reset for the periods containing now
+func (q *QuotaTracker) counter(key string, now time.Time) *quotaCounter {
+	day, month := periods(now)
+	c, ok := q.counters[key]
+	if !ok {
+		c = &quotaCounter{day: day, month: month}
+		q.counters[key] = c
+	}
+	if c.month.Before(month) {
+		// Counters of keys unused since last month are dropped at the same
+		// time, so the map does not grow with every key ever seen
+		for k, other := range q.counters {
+			if other.month.Before(month) && k != key {
+				delete(q.counters, k)
+			}
+		}
+		c.month, c.monthly = month, 0
+	}
+	if c.day.Before(day) {
+		c.day, c.daily = day, 0
+	}
+	return c
+}
+
+// emptyUsage is the usage of a key without requests at now
+func emptyUsage(now time.Time) QuotaUsage {
+	day, month := periods(now)
+	return (&quotaCounter{day: day, month: month}).usage()
+}
+
+func (c *quotaCounter) usage() QuotaUsage {
+	return QuotaUsage{
+		Daily:        c.daily,
+		Monthly:      c.monthly,
+		DailyReset:   c.day.AddDate(0, 0, 1),
+		MonthlyReset: c.month.AddDate(0, 1, 0),
+	}
+}
+
+// Use implements Quotas
+func (q *QuotaTracker) Use(key string, tier Tier, now time.Time) (QuotaUsage, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	c := q.counter(key, now)
+	if tier.Daily > 0 && c.daily >= tier.Daily || tier.Monthly > 0 && c.monthly >= tier.Monthly {
+		return c.usage(), false
+	}
+	c.daily++
+	c.monthly++
+	return c.usage(), true
+}
+
+// Usage implements Quotas
+func (q *QuotaTracker) Usage(key string, now time.Time) QuotaUsage {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if _, ok := q.counters[key]; !ok {
+		return emptyUsage(now)
+	}
+	return q.counter(key, now).usage()
+}
+
+// Len returns how many keys are being counted
+func (q *QuotaTracker) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.counters)
+}
+
+// GetStats implements Quotas
+func (q *QuotaTracker) GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"backend": "memory",
+		"keys":    q.Len(),
+	}
+}
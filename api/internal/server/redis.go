@@ -0,0 +1,330 @@
+package server
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	logs "github.com/iamBijoyKar/winget-pkg/api/internal/utils"
+)
+
+// The scripts below check and count a request in one step, which Redis runs
+// atomically. They take the same arguments: the time in milliseconds from the
+// limiter's clock, the limit, the window in milliseconds, the burst and the
+// cost, where a cost of 0 only reads. They return whether the request was
+// allowed, the requests left and when the full allowance is restored.
+
+// fixedWindowScript keeps the remaining requests and the end of the window
+// in a hash
+var fixedWindowScript = redis.NewScript(1, `
+local now, limit, window, cost = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[5])
+local state = redis.call('HMGET', KEYS[1], 'remaining', 'reset')
+local remaining, reset = tonumber(state[1]), tonumber(state[2])
+if reset == nil or now > reset then
+	remaining, reset = limit, now + window
+end
+if cost == 0 then
+	return {1, remaining, reset}
+end
+local allowed = 0
+if remaining >= cost then
+	remaining = remaining - cost
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'remaining', remaining, 'reset', reset)
+redis.call('PEXPIRE', KEYS[1], reset - now + window)
+return {allowed, remaining, reset}
+`)
+
+// tokenBucketScript keeps the tokens and when they were counted in a hash
+var tokenBucketScript = redis.NewScript(1, `
+local now, limit, window, burst, cost = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[4]), tonumber(ARGV[5])
+local rate = limit / window
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens, updated = tonumber(state[1]), tonumber(state[2])
+if tokens == nil then
+	tokens, updated = burst, now
+end
+if now > updated then
+	tokens = math.min(burst, tokens + (now - updated) * rate)
+	updated = now
+end
+local allowed = 0
+if cost > 0 then
+	if tokens >= cost then
+		tokens = tokens - cost
+		allowed = 1
+	end
+	redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', updated)
+	redis.call('PEXPIRE', KEYS[1], math.max(math.ceil((burst - tokens) / rate), 1))
+end
+return {allowed, math.floor(tokens), now + math.ceil((burst - tokens) / rate)}
+`)
+
+// slidingLogScript keeps a sorted set of request times, with a counter in
+// KEYS[2] to tell apart requests made in the same millisecond
+var slidingLogScript = redis.NewScript(2, `
+local now, limit, window, cost = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[5])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if cost > 0 and count + cost <= limit then
+	local seq = redis.call('INCRBY', KEYS[2], cost)
+	for i = seq - cost + 1, seq do
+		redis.call('ZADD', KEYS[1], now, i)
+	end
+	count = count + cost
+	allowed = 1
+	redis.call('PEXPIRE', KEYS[1], window)
+	redis.call('PEXPIRE', KEYS[2], window)
+end
+local reset = now
+local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+if #newest > 0 then
+	reset = tonumber(newest[2]) + window
+end
+return {allowed, math.max(limit - count, 0), reset}
+`)
+
+// slidingWindowScript keeps the start of the current window and the counts
+// of it and the previous one in a hash
+var slidingWindowScript = redis.NewScript(1, `
+local now, limit, window, cost = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[5])
+local start = now - now % window
+local state = redis.call('HMGET', KEYS[1], 'start', 'current', 'previous')
+local s, current, previous = tonumber(state[1]), tonumber(state[2]) or 0, tonumber(state[3]) or 0
+if s ~= start then
+	if s ~= nil and s + window == start then
+		previous, current = current, 0
+	else
+		previous, current = 0, 0
+	end
+end
+local estimate = previous * (1 - (now - start) / window) + current
+local allowed = 0
+if cost > 0 then
+	if estimate + cost <= limit then
+		current = current + cost
+		estimate = estimate + cost
+		allowed = 1
+	end
+	redis.call('HSET', KEYS[1], 'start', start, 'current', current, 'previous', previous)
+	redis.call('PEXPIRE', KEYS[1], start + 2 * window - now)
+end
+local reset = now
+if current > 0 then
+	reset = start + 2 * window
+elseif previous > 0 then
+	reset = start + window
+end
+return {allowed, math.max(limit - math.ceil(estimate), 0), reset}
+`)
+
+// quotaScript counts a request against the daily and monthly counters in
+// KEYS[1] and KEYS[2]. It takes the daily and monthly quotas, where 0 is no
+// quota, the lifetimes of the counters in milliseconds and whether to count;
+// it returns whether the request was counted and both counts.
+var quotaScript = redis.NewScript(2, `
+local daily = tonumber(redis.call('GET', KEYS[1])) or 0
+local monthly = tonumber(redis.call('GET', KEYS[2])) or 0
+local dailyQuota, monthlyQuota = tonumber(ARGV[1]), tonumber(ARGV[2])
+if ARGV[5] == '0' then
+	return {1, daily, monthly}
+end
+if (dailyQuota > 0 and daily >= dailyQuota) or (monthlyQuota > 0 and monthly >= monthlyQuota) then
+	return {0, daily, monthly}
+end
+daily = redis.call('INCR', KEYS[1])
+monthly = redis.call('INCR', KEYS[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+redis.call('PEXPIRE', KEYS[2], ARGV[4])
+return {1, daily, monthly}
+`)
+
+var redisScripts = map[Strategy]*redis.Script{
+	FixedWindow:   fixedWindowScript,
+	TokenBucket:   tokenBucketScript,
+	SlidingLog:    slidingLogScript,
+	SlidingWindow: slidingWindowScript,
+}
+
+// RedisBackend keeps the callers of every limiter in Redis, or a server
+// speaking its protocol, so API instances sharing it share limits. The time
+// comes from each limiter's clock, so instances should keep their clocks in
+// sync. When Redis cannot be reached requests are allowed rather than
+// refused.
+type RedisBackend struct {
+	pool   *redis.Pool
+	prefix string
+}
+
+// NewRedisBackend creates a backend using connections from pool. Its keys
+// start with prefix.
+func NewRedisBackend(pool *redis.Pool, prefix string) *RedisBackend {
+	return &RedisBackend{pool: pool, prefix: prefix}
+}
+
+// NewLimiter implements Backend
+func (b *RedisBackend) NewLimiter(name string, config RateLimiterConfig) Limiter {
+	config = config.withDefaults()
+	script, ok := redisScripts[config.Strategy]
+	if !ok {
+		config.Strategy, script = FixedWindow, fixedWindowScript
+	}
+	return &redisLimiter{backend: b, name: name, config: config, script: script}
+}
+
+// NewQuotas implements Backend. Counters are named after the day or month
+// they count, and expire an hour after it ends.
+func (b *RedisBackend) NewQuotas() Quotas {
+	return &redisQuotas{backend: b}
+}
+
+// redisQuotas are quota counters kept in Redis
+type redisQuotas struct {
+	backend *RedisBackend
+	errors  atomic.Int64
+	failing atomic.Bool
+}
+
+// run runs quotaScript for key, counting a request when use is set
+func (q *redisQuotas) run(key string, tier Tier, now time.Time, use bool) (QuotaUsage, bool, error) {
+	usage := emptyUsage(now)
+	dayStart, monthStart := periods(now)
+	day := q.backend.prefix + "quota:" + dayStart.Format(time.DateOnly) + ":" + key
+	month := q.backend.prefix + "quota:" + monthStart.Format("2006-01") + ":" + key
+	grace := time.Hour
+
+	conn := q.backend.pool.Get()
+	defer conn.Close()
+	reply, err := redis.Int64s(quotaScript.Do(conn, day, month, tier.Daily, tier.Monthly,
+		(usage.DailyReset.Sub(now) + grace).Milliseconds(), (usage.MonthlyReset.Sub(now) + grace).Milliseconds(), use))
+	if err == nil && len(reply) != 3 {
+		err = redis.Error("unexpected quota script reply")
+	}
+	if err != nil {
+		q.errors.Add(1)
+		if !q.failing.Swap(true) {
+			logs.PrintWarning("Quota backend unavailable, allowing requests: %v", err)
+		}
+		return usage, true, err
+	}
+	if q.failing.Swap(false) {
+		logs.PrintInfo("Quota backend available again")
+	}
+	usage.Daily, usage.Monthly = int(reply[1]), int(reply[2])
+	return usage, reply[0] == 1, nil
+}
+
+// Use implements Quotas. Requests are allowed while Redis cannot be reached.
+func (q *redisQuotas) Use(key string, tier Tier, now time.Time) (QuotaUsage, bool) {
+	usage, ok, _ := q.run(key, tier, now, true)
+	return usage, ok
+}
+
+// Usage implements Quotas
+func (q *redisQuotas) Usage(key string, now time.Time) QuotaUsage {
+	usage, _, _ := q.run(key, Tier{}, now, false)
+	return usage
+}
+
+// GetStats implements Quotas. Keys are not counted, as that would mean
+// scanning Redis.
+func (q *redisQuotas) GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"backend": "redis",
+		"errors":  q.errors.Load(),
+	}
+}
+
+// redisLimiter is a limiter of any strategy keeping its callers in Redis
+type redisLimiter struct {
+	backend *RedisBackend
+	name    string
+	config  RateLimiterConfig
+	script  *redis.Script
+	errors  atomic.Int64
+	failing atomic.Bool
+}
+
+// capacity is the most requests a caller can make at once
+func (rl *redisLimiter) capacity() int {
+	if rl.config.Strategy == TokenBucket {
+		return rl.config.Burst
+	}
+	return rl.config.Limit
+}
+
+// keys returns the Redis keys holding the state of the caller key. Strategies
+// get keys of their own, as they store different types.
+func (rl *redisLimiter) keys(key string) []interface{} {
+	base := rl.backend.prefix + string(rl.config.Strategy) + ":" + rl.name + ":" + key
+	if rl.config.Strategy == SlidingLog {
+		return []interface{}{base, base + ":seq"}
+	}
+	return []interface{}{base}
+}
+
+// run runs the strategy's script for a request of key costing cost
+func (rl *redisLimiter) run(key string, cost int) (allowed bool, remaining int, reset time.Time, err error) {
+	conn := rl.backend.pool.Get()
+	defer conn.Close()
+
+	args := append(rl.keys(key), rl.config.Clock().UnixMilli(), rl.config.Limit,
+		rl.config.Window.Milliseconds(), rl.config.Burst, cost)
+	reply, err := redis.Int64s(rl.script.Do(conn, args...))
+	if err == nil && len(reply) != 3 {
+		err = redis.Error("unexpected rate limit script reply")
+	}
+	if err != nil {
+		rl.errors.Add(1)
+		// Only the first failure in a row is logged, so an outage does not
+		// log every request
+		if !rl.failing.Swap(true) {
+			logs.PrintWarning("Rate limit backend unavailable, allowing requests: %v", err)
+		}
+		return false, 0, time.Time{}, err
+	}
+	if rl.failing.Swap(false) {
+		logs.PrintInfo("Rate limit backend available again")
+	}
+	return reply[0] == 1, int(reply[1]), time.UnixMilli(reply[2]), nil
+}
+
+// Allow implements Limiter
+func (rl *redisLimiter) Allow(key string, cost int) bool {
+	if cost <= 0 {
+		return true
+	}
+	allowed, _, _, err := rl.run(key, chargeable(cost, rl.capacity()))
+	return allowed || err != nil
+}
+
+// Info implements Limiter
+func (rl *redisLimiter) Info(key string) (remaining int, reset time.Time, limit int) {
+	_, remaining, reset, err := rl.run(key, 0)
+	if err != nil {
+		return rl.capacity(), rl.config.Clock().Add(rl.config.Window), rl.capacity()
+	}
+	return remaining, reset, rl.capacity()
+}
+
+// GetStats implements Limiter. Callers are not counted, as that would mean
+// scanning Redis.
+func (rl *redisLimiter) GetStats() map[string]interface{} {
+	stats := map[string]interface{}{
+		"strategy": string(rl.config.Strategy),
+		"backend":  "redis",
+		"limit":    rl.config.Limit,
+		"window":   rl.config.Window.String(),
+		"errors":   rl.errors.Load(),
+	}
+	if rl.config.Strategy == TokenBucket {
+		stats["burst"] = rl.config.Burst
+	}
+	return stats
+}
+
+// Stop implements Limiter. Redis expires idle callers itself, and the pool
+// belongs to whoever created the backend.
+func (rl *redisLimiter) Stop() {}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+// newTestRedisBackend returns a backend on a miniredis server that is closed
+// when the test ends
+func newTestRedisBackend(t *testing.T) (*RedisBackend, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	pool := &redis.Pool{
+		MaxIdle: 2,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr)
+		},
+	}
+	t.Cleanup(func() { pool.Close() })
+	return NewRedisBackend(pool, "test:"), mr
+}
+
+// step advances the clock by advance, then makes n requests of cost
+type step struct {
+	advance time.Duration
+	n       int
+	cost    int
+}
+
+func TestRedisScriptsMatchMemory(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RateLimiterConfig
+		steps  []step
+	}{
+		{
+			name:   "fixed window",
+			config: RateLimiterConfig{Strategy: FixedWindow, Limit: 10, Window: time.Second},
+			steps: []step{
+				{n: 12, cost: 1},
+				{advance: 500 * time.Millisecond, n: 3, cost: 1},
+				{advance: 500 * time.Millisecond, n: 1, cost: 1},
+				{advance: time.Millisecond, n: 4, cost: 3},
+				{advance: 3 * time.Second, n: 1, cost: 50},
+			},
+		},
+		{
+			name:   "token bucket",
+			config: RateLimiterConfig{Strategy: TokenBucket, Limit: 10, Window: time.Second, Burst: 20},
+			steps: []step{
+				{n: 25, cost: 1},
+				{advance: 100 * time.Millisecond, n: 5, cost: 1},
+				{advance: 550 * time.Millisecond, n: 4, cost: 2},
+				{advance: time.Minute, n: 2, cost: 50},
+				{advance: 250 * time.Millisecond, n: 5, cost: 1},
+			},
+		},
+		{
+			name:   "sliding log",
+			config: RateLimiterConfig{Strategy: SlidingLog, Limit: 10, Window: time.Second},
+			steps: []step{
+				{n: 5, cost: 1},
+				{advance: 500 * time.Millisecond, n: 10, cost: 1},
+				{advance: 499 * time.Millisecond, n: 1, cost: 1},
+				{advance: time.Millisecond, n: 3, cost: 2},
+				{advance: 2 * time.Second, n: 2, cost: 50},
+			},
+		},
+		{
+			name:   "sliding window",
+			config: RateLimiterConfig{Strategy: SlidingWindow, Limit: 10, Window: time.Second},
+			steps: []step{
+				{n: 10, cost: 1},
+				{advance: 1250 * time.Millisecond, n: 20, cost: 1},
+				{advance: 250 * time.Millisecond, n: 5, cost: 2},
+				{advance: 750 * time.Millisecond, n: 20, cost: 1},
+				{advance: 3 * time.Second, n: 2, cost: 50},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend, _ := newTestRedisBackend(t)
+			clock := newFakeClock()
+			config := tt.config
+			config.Clock = clock.Now
+			memory := NewLimiter(config)
+			t.Cleanup(memory.Stop)
+			shared := backend.NewLimiter("test", config)
+
+			for i, s := range tt.steps {
+				clock.Advance(s.advance)
+				for j := range s.n {
+					want := memory.Allow("k", s.cost)
+					if got := shared.Allow("k", s.cost); got != want {
+						t.Fatalf("step %d request %d: Redis allowed %v, memory %v", i, j, got, want)
+					}
+				}
+				wantRemaining, wantReset, wantLimit := memory.Info("k")
+				remaining, reset, limit := shared.Info("k")
+				if remaining != wantRemaining || !reset.Equal(wantReset) || limit != wantLimit {
+					t.Errorf("step %d: Redis Info = %d, %v, %d, memory %d, %v, %d",
+						i, remaining, reset, limit, wantRemaining, wantReset, wantLimit)
+				}
+			}
+			if n := shared.GetStats()["errors"]; n != int64(0) {
+				t.Errorf("%v script errors", n)
+			}
+		})
+	}
+}
+
+func TestRedisLimitersShareCallers(t *testing.T) {
+	backend, _ := newTestRedisBackend(t)
+	clock := newFakeClock()
+	config := RateLimiterConfig{Strategy: FixedWindow, Limit: 10, Window: time.Second, Clock: clock.Now}
+
+	// Two instances using the same backend split one allowance
+	a, b := backend.NewLimiter("free", config), backend.NewLimiter("free", config)
+	if got := allowN(a, "k", 6) + allowN(b, "k", 6); got != 10 {
+		t.Errorf("allowed %d requests across two instances, want 10", got)
+	}
+	// while limiters of other names keep their own
+	if got := allowN(backend.NewLimiter("team", config), "k", 6); got != 6 {
+		t.Errorf("allowed %d requests of another limiter, want 6", got)
+	}
+}
+
+func TestRedisLimiterFailsOpen(t *testing.T) {
+	backend, mr := newTestRedisBackend(t)
+	l := backend.NewLimiter("test", RateLimiterConfig{Limit: 1, Window: time.Second})
+	mr.Close()
+
+	if got := allowN(l, "k", 3); got != 3 {
+		t.Errorf("allowed %d of 3 requests while Redis was down, want 3", got)
+	}
+	if n := l.GetStats()["errors"]; n != int64(3) {
+		t.Errorf("%v errors counted, want 3", n)
+	}
+}
+
+func TestRedisQuotasMatchMemory(t *testing.T) {
+	backend, _ := newTestRedisBackend(t)
+	clock := newFakeClock()
+	tier := Tier{Name: "test", Daily: 3, Monthly: 5}
+	memory, shared := MemoryBackend{}.NewQuotas(), backend.NewQuotas()
+
+	// Each day allows 3 requests until the month's 5 are used up, and a new
+	// month starts over
+	days := []time.Duration{0, 24 * time.Hour, 24 * time.Hour, 29 * 24 * time.Hour}
+	for i, advance := range days {
+		clock.Advance(advance)
+		for j := range 4 {
+			wantUsage, want := memory.Use("k", tier, clock.now)
+			usage, got := shared.Use("k", tier, clock.now)
+			if got != want || usage != wantUsage {
+				t.Fatalf("day %d request %d: Redis Use = %+v, %v, memory %+v, %v", i, j, usage, got, wantUsage, want)
+			}
+		}
+		if usage, want := shared.Usage("k", clock.now), memory.Usage("k", clock.now); usage != want {
+			t.Errorf("day %d: Redis Usage = %+v, memory %+v", i, usage, want)
+		}
+	}
+
+	// Quotas are shared by every instance using the backend
+	other := backend.NewQuotas()
+	if usage := other.Usage("k", clock.now); usage.Monthly != 3 {
+		t.Errorf("another instance sees %d requests this month, want 3", usage.Monthly)
+	}
+	if usage := other.Usage("unused", clock.now); usage != emptyUsage(clock.now) {
+		t.Errorf("Usage of an unused key = %+v", usage)
+	}
+}
+
+func TestRedisQuotasFailOpen(t *testing.T) {
+	backend, mr := newTestRedisBackend(t)
+	quotas := backend.NewQuotas()
+	mr.Close()
+
+	now := newFakeClock().now
+	if _, ok := quotas.Use("k", Tier{Daily: 1}, now); !ok {
+		t.Error("refused a request while Redis was down")
+	}
+	if n := quotas.GetStats()["errors"]; n != int64(1) {
+		t.Errorf("%v errors counted, want 1", n)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Envelope is the body of every successful API response. Failures are
+// answered with an application/problem+json document instead, so Error is
+// always null here and only kept for a stable shape.
+type Envelope struct {
+	Data  interface{} `json:"data"`
+	Meta  gin.H       `json:"meta"`
+	Error *Problem    `json:"error"`
+}
+
+// JSON writes data wrapped in the response envelope, trimmed to the
+// fields requested with the fields query parameter
+func JSON(c *gin.Context, status int, data interface{}, meta gin.H) {
+	if fields := c.Query("fields"); fields != "" {
+		data = SelectFields(data, strings.Split(fields, ","))
+	}
+	if meta == nil {
+		meta = gin.H{}
+	}
+	c.JSON(status, Envelope{Data: data, Meta: meta})
+}
+
+// fieldTree is a parsed fields selection, "versions.installers" becomes
+// {"versions": {"installers": {}}}
+type fieldTree map[string]fieldTree
+
+func parseFields(fields []string) fieldTree {
+	tree := fieldTree{}
+	for _, field := range fields {
+		node := tree
+		for _, part := range strings.Split(strings.TrimSpace(field), ".") {
+			if part == "" {
+				break
+			}
+			if node[part] == nil {
+				node[part] = fieldTree{}
+			}
+			node = node[part]
+		}
+	}
+	return tree
+}
+
+// SelectFields keeps only the listed JSON fields of data (or of each element
+// when data is a list). Nested fields are addressed with dots.
+func SelectFields(data interface{}, fields []string) interface{} {
+	tree := parseFields(fields)
+	if len(tree) == 0 {
+		return data
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return data
+	}
+	return tree.apply(generic)
+}
+
+func (t fieldTree) apply(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = t.apply(v[i])
+		}
+		return v
+	case map[string]interface{}:
+		selected := make(map[string]interface{}, len(t))
+		for key, sub := range t {
+			field, ok := v[key]
+			if !ok {
+				continue
+			}
+			if len(sub) > 0 {
+				field = sub.apply(field)
+			}
+			selected[key] = field
+		}
+		return selected
+	}
+	return value
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// slidingLogLimiter is the SlidingLog limiter
+type slidingLogLimiter struct {
+	logs   map[string][]time.Time // Request times of each caller, oldest first
+	config RateLimiterConfig
+	mutex  sync.Mutex
+	stop   chan struct{}
+}
+
+func newSlidingLogLimiter(config RateLimiterConfig) *slidingLogLimiter {
+	sl := &slidingLogLimiter{
+		logs:   make(map[string][]time.Time),
+		config: config,
+		stop:   make(chan struct{}),
+	}
+	go cleanupRoutine(config.CleanupInterval, sl.stop, sl.cleanup)
+	return sl
+}
+
+// recent returns the requests of key made in the window ending at now
+func (sl *slidingLogLimiter) recent(key string, now time.Time) []time.Time {
+	log := sl.logs[key]
+	cutoff := now.Add(-sl.config.Window)
+	i := 0
+	for i < len(log) && !log[i].After(cutoff) {
+		i++
+	}
+	return log[i:]
+}
+
+// Allow implements Limiter
+func (sl *slidingLogLimiter) Allow(key string, cost int) bool {
+	if cost <= 0 {
+		return true
+	}
+	cost = chargeable(cost, sl.config.Limit)
+
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	now := sl.config.Clock()
+	log := sl.recent(key, now)
+	if len(log)+cost > sl.config.Limit {
+		sl.logs[key] = log
+		return false
+	}
+	// A request is logged once for each request it costs
+	for range cost {
+		log = append(log, now)
+	}
+	sl.logs[key] = log
+	return true
+}
+
+// Info implements Limiter. The allowance is restored once the newest request
+// leaves the window.
+func (sl *slidingLogLimiter) Info(key string) (remaining int, reset time.Time, limit int) {
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	now := sl.config.Clock()
+	log := sl.recent(key, now)
+	if len(log) == 0 {
+		return sl.config.Limit, now, sl.config.Limit
+	}
+	return max(sl.config.Limit-len(log), 0), log[len(log)-1].Add(sl.config.Window), sl.config.Limit
+}
+
+// cleanup drops callers without requests in the window
+func (sl *slidingLogLimiter) cleanup() {
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	now := sl.config.Clock()
+	for key := range sl.logs {
+		if len(sl.recent(key, now)) == 0 {
+			delete(sl.logs, key)
+		}
+	}
+}
+
+// Stop implements Limiter
+func (sl *slidingLogLimiter) Stop() {
+	close(sl.stop)
+}
+
+// GetStats implements Limiter
+func (sl *slidingLogLimiter) GetStats() map[string]interface{} {
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	return map[string]interface{}{
+		"strategy":         string(SlidingLog),
+		"active_keys":      len(sl.logs),
+		"limit":            sl.config.Limit,
+		"window":           sl.config.Window.String(),
+		"cleanup_interval": sl.config.CleanupInterval.String(),
+	}
+}
+
+// windowCount counts the requests of one caller in the window starting at
+// start and the one before it
+type windowCount struct {
+	start    time.Time
+	current  int
+	previous int
+}
+
+// slidingWindowLimiter is the SlidingWindow limiter
+type slidingWindowLimiter struct {
+	counts map[string]windowCount
+	config RateLimiterConfig
+	mutex  sync.Mutex
+	stop   chan struct{}
+}
+
+func newSlidingWindowLimiter(config RateLimiterConfig) *slidingWindowLimiter {
+	sw := &slidingWindowLimiter{
+		counts: make(map[string]windowCount),
+		config: config,
+		stop:   make(chan struct{}),
+	}
+	go cleanupRoutine(config.CleanupInterval, sw.stop, sw.cleanup)
+	return sw
+}
+
+// count returns the counts of key moved to the window containing now
+func (sw *slidingWindowLimiter) count(key string, now time.Time) windowCount {
+	start := now.Truncate(sw.config.Window)
+	c := sw.counts[key]
+	switch {
+	case c.start.Equal(start):
+	case c.start.Add(sw.config.Window).Equal(start):
+		c = windowCount{start: start, previous: c.current}
+	default:
+		c = windowCount{start: start}
+	}
+	return c
+}
+
+// estimate is the number of requests in the window ending at now, assuming
+// the requests of the previous window were spread evenly over it
+func (sw *slidingWindowLimiter) estimate(c windowCount, now time.Time) float64 {
+	overlap := 1 - float64(now.Sub(c.start))/float64(sw.config.Window)
+	return float64(c.previous)*overlap + float64(c.current)
+}
+
+// Allow implements Limiter
+func (sw *slidingWindowLimiter) Allow(key string, cost int) bool {
+	if cost <= 0 {
+		return true
+	}
+	cost = chargeable(cost, sw.config.Limit)
+
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	now := sw.config.Clock()
+	c := sw.count(key, now)
+	if sw.estimate(c, now)+float64(cost) > float64(sw.config.Limit) {
+		sw.counts[key] = c
+		return false
+	}
+	c.current += cost
+	sw.counts[key] = c
+	return true
+}
+
+// Info implements Limiter. The allowance is restored once the windows holding
+// requests have slid past.
+func (sw *slidingWindowLimiter) Info(key string) (remaining int, reset time.Time, limit int) {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	now := sw.config.Clock()
+	c := sw.count(key, now)
+	remaining = max(sw.config.Limit-int(math.Ceil(sw.estimate(c, now))), 0)
+	switch {
+	case c.current > 0:
+		reset = c.start.Add(2 * sw.config.Window)
+	case c.previous > 0:
+		reset = c.start.Add(sw.config.Window)
+	default:
+		reset = now
+	}
+	return remaining, reset, sw.config.Limit
+}
+
+// cleanup drops callers without requests in the current or previous window
+func (sw *slidingWindowLimiter) cleanup() {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	now := sw.config.Clock()
+	for key, c := range sw.counts {
+		if !c.start.Add(2 * sw.config.Window).After(now) {
+			delete(sw.counts, key)
+		}
+	}
+}
+
+// Stop implements Limiter
+func (sw *slidingWindowLimiter) Stop() {
+	close(sw.stop)
+}
+
+// GetStats implements Limiter
+func (sw *slidingWindowLimiter) GetStats() map[string]interface{} {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	return map[string]interface{}{
+		"strategy":         string(SlidingWindow),
+		"active_keys":      len(sw.counts),
+		"limit":            sw.config.Limit,
+		"window":           sw.config.Window.String(),
+		"cleanup_interval": sw.config.CleanupInterval.String(),
+	}
+}
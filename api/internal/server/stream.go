@@ -0,0 +1,149 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+)
+
+const (
+	// MIMENDJSON is newline delimited JSON, one package per line
+	MIMENDJSON = "application/x-ndjson"
+	// MIMECSV is one flattened package per row
+	MIMECSV = "text/csv"
+
+	// flushEvery is how many rows are written between flushes
+	flushEvery = 100
+)
+
+// csvColumns are the package fields written to CSV rows, in order
+var csvColumns = []string{
+	"identifier", "name", "publisher", "author", "shortDescription",
+	"license", "homepage", "moniker", "tags", "locale", "latestVersion", "versionCount",
+}
+
+func csvValue(p models.Package, column string) string {
+	switch column {
+	case "identifier":
+		return p.Identifier
+	case "name":
+		return p.Name
+	case "publisher":
+		return p.Publisher
+	case "author":
+		return p.Author
+	case "shortDescription":
+		return p.ShortDescription
+	case "license":
+		return p.License
+	case "homepage":
+		return p.Homepage
+	case "moniker":
+		return p.Moniker
+	case "tags":
+		return strings.Join(p.Tags, ";")
+	case "locale":
+		return p.Locale
+	case "latestVersion":
+		return p.LatestVersion
+	case "versionCount":
+		return strconv.Itoa(len(p.Versions))
+	}
+	return ""
+}
+
+// StreamFormat returns the streaming format negotiated from the Accept
+// header, or "" when the client wants the regular JSON envelope
+func StreamFormat(c *gin.Context) string {
+	switch c.NegotiateFormat(gin.MIMEJSON, MIMENDJSON, MIMECSV) {
+	case MIMENDJSON:
+		return MIMENDJSON
+	case MIMECSV:
+		return MIMECSV
+	}
+	return ""
+}
+
+// PackageWriter writes packages to the response as they are produced
+type PackageWriter struct {
+	c       *gin.Context
+	format  string
+	fields  []string
+	csv     *csv.Writer
+	columns []string
+	rows    int
+}
+
+// NewPackageWriter starts a streamed response in the given format. The fields
+// query parameter selects JSON fields for NDJSON and columns for CSV.
+func NewPackageWriter(c *gin.Context, format string) *PackageWriter {
+	w := &PackageWriter{c: c, format: format}
+	if fields := c.Query("fields"); fields != "" {
+		w.fields = strings.Split(fields, ",")
+	}
+
+	c.Header("Content-Type", format+"; charset=utf-8")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	if format == MIMECSV {
+		w.csv = csv.NewWriter(c.Writer)
+		w.columns = csvColumns
+		if len(w.fields) > 0 {
+			w.columns = nil
+			for _, f := range w.fields {
+				for _, col := range csvColumns {
+					if strings.TrimSpace(f) == col {
+						w.columns = append(w.columns, col)
+					}
+				}
+			}
+		}
+		_ = w.csv.Write(w.columns)
+	}
+	return w
+}
+
+// Write sends one package and flushes the response every few rows
+func (w *PackageWriter) Write(p models.Package) error {
+	if w.format == MIMECSV {
+		row := make([]string, len(w.columns))
+		for i, col := range w.columns {
+			row[i] = csvValue(p, col)
+		}
+		if err := w.csv.Write(row); err != nil {
+			return err
+		}
+	} else {
+		var item interface{} = p
+		if len(w.fields) > 0 {
+			item = SelectFields(p, w.fields)
+		}
+		line, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if _, err := w.c.Writer.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+
+	w.rows++
+	if w.rows%flushEvery == 0 {
+		w.Flush()
+	}
+	return w.c.Request.Context().Err()
+}
+
+// Flush pushes buffered rows to the client
+func (w *PackageWriter) Flush() {
+	if w.csv != nil {
+		w.csv.Flush()
+	}
+	w.c.Writer.Flush()
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/apikey"
+)
+
+// Tier is a rate limit plan: a short-term request rate plus daily and monthly
+// quotas counted per API key
+type Tier struct {
+	Name    string
+	Limit   int           // Maximum requests allowed in the window
+	Window  time.Duration // Duration of the rate limit window
+	Burst   int           // Most requests at once with TokenBucket, Limit when 0
+	Daily   int           // Requests per UTC day, 0 for no quota
+	Monthly int           // Requests per UTC month, 0 for no quota
+	// Upgrade is the tier suggested to keys using up this one
+	Upgrade string
+}
+
+// DefaultTiers are the plans keys can be on. The first one applies to keys
+// whose tier is unknown.
+var DefaultTiers = []Tier{
+	{Name: apikey.TierFree, Limit: 20, Window: time.Second, Burst: 40, Daily: 10_000, Monthly: 200_000, Upgrade: apikey.TierTeam},
+	{Name: apikey.TierTeam, Limit: 100, Window: time.Second, Burst: 200, Daily: 250_000, Monthly: 5_000_000},
+	{Name: apikey.TierInternal, Limit: 1000, Window: time.Second, Burst: 2000},
+}
+
+// upgradeThreshold is the share of a quota after which an upgrade is suggested
+const upgradeThreshold = 0.8
+
+// SuggestUpgrade returns the tier to suggest to a key with this usage, or ""
+// when the key is comfortably within its quotas. Keys that were limited are
+// always pointed to the next tier.
+func (t Tier) SuggestUpgrade(usage QuotaUsage, limited bool) string {
+	if t.Upgrade == "" {
+		return ""
+	}
+	if limited ||
+		t.Daily > 0 && float64(usage.Daily) >= upgradeThreshold*float64(t.Daily) ||
+		t.Monthly > 0 && float64(usage.Monthly) >= upgradeThreshold*float64(t.Monthly) {
+		return t.Upgrade
+	}
+	return ""
+}
+
+// QuotaHeaders returns the X-Quota-* headers describing usage. Quotas the
+// tier does not have are left out.
+func (t Tier) QuotaHeaders(usage QuotaUsage) map[string]string {
+	headers := make(map[string]string)
+	if t.Daily > 0 {
+		headers["X-Quota-Daily-Limit"] = strconv.Itoa(t.Daily)
+		headers["X-Quota-Daily-Remaining"] = strconv.Itoa(max(t.Daily-usage.Daily, 0))
+		headers["X-Quota-Daily-Reset"] = strconv.FormatInt(usage.DailyReset.Unix(), 10)
+	}
+	if t.Monthly > 0 {
+		headers["X-Quota-Monthly-Limit"] = strconv.Itoa(t.Monthly)
+		headers["X-Quota-Monthly-Remaining"] = strconv.Itoa(max(t.Monthly-usage.Monthly, 0))
+		headers["X-Quota-Monthly-Reset"] = strconv.FormatInt(usage.MonthlyReset.Unix(), 10)
+	}
+	return headers
+}
+
+// TierLimiter applies the rate limit and quotas of each tier
+type TierLimiter struct {
+	tiers    map[string]Tier
+	limiters map[string]Limiter
+	quotas   Quotas
+	fallback string
+	clock    Clock
+}
+
+// NewTierLimiter creates a rate limiter per tier in backend using strategy,
+// and quota counters in backend shared by the tiers. The first tier applies to unknown
+// tier names. A nil backend is MemoryBackend and a nil clock time.Now.
+func NewTierLimiter(tiers []Tier, backend Backend, strategy Strategy, clock Clock) *TierLimiter {
+	if backend == nil {
+		backend = MemoryBackend{}
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	tl := &TierLimiter{
+		tiers:    make(map[string]Tier),
+		limiters: make(map[string]Limiter),
+		quotas:   backend.NewQuotas(),
+		fallback: tiers[0].Name,
+		clock:    clock,
+	}
+	for _, tier := range tiers {
+		tl.tiers[tier.Name] = tier
+		tl.limiters[tier.Name] = backend.NewLimiter(tier.Name, RateLimiterConfig{
+			Strategy: strategy,
+			Limit:    tier.Limit,
+			Window:   tier.Window,
+			Burst:    tier.Burst,
+			Clock:    clock,
+		})
+	}
+	return tl
+}
+
+// Tier returns the tier with this name, or the fallback tier
+func (tl *TierLimiter) Tier(name string) Tier {
+	if tier, ok := tl.tiers[name]; ok {
+		return tier
+	}
+	return tl.tiers[tl.fallback]
+}
+
+// Limiter returns the rate limiter of a tier
+func (tl *TierLimiter) Limiter(tier Tier) Limiter {
+	return tl.limiters[tier.Name]
+}
+
+// UseQuota counts a request of key against the quotas of tier. It returns the
+// usage and false, without counting, once a quota is used up.
+func (tl *TierLimiter) UseQuota(key string, tier Tier) (QuotaUsage, bool) {
+	return tl.quotas.Use(key, tier, tl.clock())
+}
+
+// QuotaUsage returns the usage of key without counting a request
+func (tl *TierLimiter) QuotaUsage(key string) QuotaUsage {
+	return tl.quotas.Usage(key, tl.clock())
+}
+
+// Stop stops the cleanup of every tier's rate limiter
+func (tl *TierLimiter) Stop() {
+	for _, limiter := range tl.limiters {
+		limiter.Stop()
+	}
+}
+
+// GetStats returns the statistics of every tier's rate limiter
+func (tl *TierLimiter) GetStats() map[string]interface{} {
+	stats := make(map[string]interface{}, len(tl.limiters)+1)
+	for name, limiter := range tl.limiters {
+		stats[name] = limiter.GetStats()
+	}
+	stats["quotas"] = tl.quotas.GetStats()
+	return stats
+}
+
+// CallerKey identifies who a request is counted against: the API key with
+// keyID, or the client ip for requests made without a key. Keys and IPs get
+// separate namespaces so they never share a window.
+func CallerKey(keyID, ip string) string {
+	if keyID != "" {
+		return "key:" + keyID
+	}
+	return "ip:" + ip
+}
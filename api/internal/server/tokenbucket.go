@@ -0,0 +1,117 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// bucket holds the tokens of one caller as of updated
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// tokenBucketLimiter is the TokenBucket limiter. Buckets start full with Burst
+// tokens and refill at Limit tokens per Window; each request takes its cost.
+type tokenBucketLimiter struct {
+	buckets map[string]bucket
+	config  RateLimiterConfig
+	mutex   sync.Mutex
+	stop    chan struct{}
+}
+
+func newTokenBucketLimiter(config RateLimiterConfig) *tokenBucketLimiter {
+	tb := &tokenBucketLimiter{
+		buckets: make(map[string]bucket),
+		config:  config,
+		stop:    make(chan struct{}),
+	}
+	go cleanupRoutine(config.CleanupInterval, tb.stop, tb.cleanup)
+	return tb
+}
+
+// rate is how many tokens are added per second
+func (tb *tokenBucketLimiter) rate() float64 {
+	return float64(tb.config.Limit) / tb.config.Window.Seconds()
+}
+
+// refill returns the bucket of key topped up to now
+func (tb *tokenBucketLimiter) refill(key string, now time.Time) bucket {
+	b, ok := tb.buckets[key]
+	if !ok {
+		return bucket{tokens: float64(tb.config.Burst), updated: now}
+	}
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(float64(tb.config.Burst), b.tokens+elapsed.Seconds()*tb.rate())
+		b.updated = now
+	}
+	return b
+}
+
+// Allow implements Limiter
+func (tb *tokenBucketLimiter) Allow(key string, cost int) bool {
+	if cost <= 0 {
+		return true
+	}
+	cost = chargeable(cost, tb.config.Burst)
+
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	b := tb.refill(key, tb.config.Clock())
+	if b.tokens < float64(cost) {
+		tb.buckets[key] = b
+		return false
+	}
+	b.tokens -= float64(cost)
+	tb.buckets[key] = b
+	return true
+}
+
+// Info implements Limiter. The limit is the burst, the most requests a caller
+// can make at once.
+func (tb *tokenBucketLimiter) Info(key string) (remaining int, reset time.Time, limit int) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	now := tb.config.Clock()
+	b := tb.refill(key, now)
+	missing := float64(tb.config.Burst) - b.tokens
+	full := time.Duration(math.Ceil(missing / tb.rate() * float64(time.Second)))
+	return int(b.tokens), now.Add(full), tb.config.Burst
+}
+
+// cleanup drops buckets that have refilled completely, which are the same as
+// no bucket at all
+func (tb *tokenBucketLimiter) cleanup() {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	now := tb.config.Clock()
+	for key := range tb.buckets {
+		if tb.refill(key, now).tokens >= float64(tb.config.Burst) {
+			delete(tb.buckets, key)
+		}
+	}
+}
+
+// Stop implements Limiter
+func (tb *tokenBucketLimiter) Stop() {
+	close(tb.stop)
+}
+
+// GetStats implements Limiter
+func (tb *tokenBucketLimiter) GetStats() map[string]interface{} {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	return map[string]interface{}{
+		"strategy":         string(TokenBucket),
+		"active_keys":      len(tb.buckets),
+		"limit":            tb.config.Limit,
+		"window":           tb.config.Window.String(),
+		"burst":            tb.config.Burst,
+		"cleanup_interval": tb.config.CleanupInterval.String(),
+	}
+}
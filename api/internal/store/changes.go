@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// VersionState is what the ingest job needs to know about a stored version
+type VersionState struct {
+	Identifier  string     `bson:"PackageIdentifier"`
+	Version     string     `bson:"PackageVersion"`
+	Name        string     `bson:"PackageName"`
+	Publisher   string     `bson:"Publisher"`
+	Tags        []string   `bson:"Tags"`
+	ContentHash string     `bson:"contentHash"`
+	CreatedAt   *time.Time `bson:"createdAt"`
+	DeletedAt   *time.Time `bson:"deletedAt"`
+}
+
+// VersionStates returns the state of every stored version document,
+// including removed ones
+func (s *Store) VersionStates(ctx context.Context) ([]VersionState, error) {
+	opts := options.Find().SetProjection(bson.M{
+		"PackageIdentifier": 1, "PackageVersion": 1, "PackageName": 1, "Publisher": 1, "Tags": 1, "contentHash": 1, "createdAt": 1, "deletedAt": 1,
+	})
+	cursor, err := s.packages.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var states []VersionState
+	if err := cursor.All(ctx, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// versionFilter selects the document of one package version
+func versionFilter(identifier, version string) bson.M {
+	return bson.M{"PackageIdentifier": identifier, "PackageVersion": version}
+}
+
+// PutVersion stores a merged manifest document, replacing an earlier copy of
+// the same version. A removed version that reappears becomes live again.
+func (s *Store) PutVersion(ctx context.Context, doc bson.M, hash string, created, updated time.Time) error {
+	identifier, _ := doc["PackageIdentifier"].(string)
+	version, _ := doc["PackageVersion"].(string)
+
+	doc["contentHash"] = hash
+	doc["createdAt"] = created
+	doc["updatedAt"] = updated
+	delete(doc, "deletedAt")
+
+	_, err := s.packages.ReplaceOne(ctx, versionFilter(identifier, version), doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteVersion marks a version as removed upstream. The document is kept so
+// that the removal can be reported and a later re-add keeps its creation time.
+func (s *Store) DeleteVersion(ctx context.Context, identifier, version string, at time.Time) error {
+	_, err := s.packages.UpdateOne(ctx, versionFilter(identifier, version), bson.M{
+		"$set": bson.M{"deletedAt": at, "updatedAt": at},
+	})
+	return err
+}
+
+// WithTransaction runs fn in a transaction, so the writes it makes through
+// the store with its context are kept together or not at all. Transactions
+// need a replica set, such as an Atlas cluster.
+func (s *Store) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := s.packages.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+// CheckTransactions returns an error unless the server supports the
+// transactions of WithTransaction: a replica set member or a sharded
+// cluster's mongos
+func (s *Store) CheckTransactions(ctx context.Context) error {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := s.packages.Database().Client().Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return err
+	}
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return errors.New("MongoDB is a standalone server; transactions need a replica set")
+	}
+	return nil
+}
+
+// SetSourceCommit records the winget-pkgs commit the catalog was built from
+func (s *Store) SetSourceCommit(ctx context.Context, commit string) error {
+	_, err := s.meta.UpdateOne(ctx, bson.M{"_id": "source"},
+		bson.M{"$set": bson.M{"commit": commit, "updatedAt": time.Now().UTC()}},
+		options.UpdateOne().SetUpsert(true))
+	return err
+}
+
+// counterDocument holds the last change sequence handed out
+type counterDocument struct {
+	Sequence int64 `bson:"sequence"`
+}
+
+// AppendChanges assigns the next sequence numbers to changes, in order, and
+// appends them to the change feed
+func (s *Store) AppendChanges(ctx context.Context, changes []models.Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	var counter counterDocument
+	err := s.meta.FindOneAndUpdate(ctx, bson.M{"_id": "changes"},
+		bson.M{"$inc": bson.M{"sequence": int64(len(changes))}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return err
+	}
+
+	first := counter.Sequence - int64(len(changes)) + 1
+	docs := make([]interface{}, len(changes))
+	for i := range changes {
+		changes[i].Sequence = first + int64(i)
+		docs[i] = changes[i]
+	}
+	_, err = s.changes.InsertMany(ctx, docs)
+	return err
+}
+
+// Changes returns up to limit changes with a sequence above after, oldest first
+func (s *Store) Changes(ctx context.Context, after int64, limit int) ([]models.Change, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := s.changes.Find(ctx, bson.M{"_id": bson.M{"$gt": after}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []models.Change{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// RecentChanges returns up to limit changes matching filter, newest first
+func (s *Store) RecentChanges(ctx context.Context, filter bson.M, limit int) ([]models.Change, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := s.changes.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []models.Change{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// SequenceBefore returns the sequence of the last change recorded before t,
+// so that reading after it yields every change made at or after t
+func (s *Store) SequenceBefore(ctx context.Context, t time.Time) (int64, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})
+	var change models.Change
+	err := s.changes.FindOne(ctx, bson.M{"timestamp": bson.M{"$lt": t}}, opts).Decode(&change)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return change.Sequence, err
+}
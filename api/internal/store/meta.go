@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// sourceDocument records which winget-pkgs commit the catalog was loaded from
+type sourceDocument struct {
+	Commit string `bson:"commit"`
+}
+
+// SourceCommit returns the winget-pkgs commit the packages collection was
+// built from, or an empty string when it was never recorded
+func (s *Store) SourceCommit(ctx context.Context) (string, error) {
+	var doc sourceDocument
+	err := s.meta.FindOne(ctx, bson.M{"_id": "source"}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	return doc.Commit, err
+}
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"regexp"
+	"sort"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// live restricts filter to version documents that were not removed upstream
+func live(filter bson.M) bson.M {
+	if len(filter) == 0 {
+		return bson.M{"deletedAt": nil}
+	}
+	return bson.M{"$and": []bson.M{filter, {"deletedAt": nil}}}
+}
+
+// findPackages loads the version manifests matching filter and groups them into packages
+func (s *Store) findPackages(ctx context.Context, filter bson.M) ([]models.Package, error) {
+	cursor, err := s.packages.Find(ctx, live(filter))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var manifests []models.Manifest
+	if err := cursor.All(ctx, &manifests); err != nil {
+		return nil, err
+	}
+	return models.FromManifests(manifests), nil
+}
+
+// FindPage returns one page of the packages matching filter, ordered by
+// identifier, with the total number of matching packages. Pages are counted in
+// packages rather than documents, since each version is its own document.
+func (s *Store) FindPage(ctx context.Context, filter bson.M, skip, limit int) ([]models.Package, int, error) {
+	var identifiers []string
+	if err := s.packages.Distinct(ctx, "PackageIdentifier", live(filter)).Decode(&identifiers); err != nil {
+		return nil, 0, err
+	}
+	sort.Strings(identifiers)
+
+	total := len(identifiers)
+	if skip >= total {
+		return []models.Package{}, total, nil
+	}
+	end := skip + limit
+	if end > total {
+		end = total
+	}
+
+	packages, err := s.findPackages(ctx, bson.M{"PackageIdentifier": bson.M{"$in": identifiers[skip:end]}})
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Identifier < packages[j].Identifier })
+	return packages, total, nil
+}
+
+// PackageFilter matches the versions of the package with exactly this
+// identifier, compared case-insensitively like winget does
+func PackageFilter(identifier string) bson.M {
+	return bson.M{"PackageIdentifier": bson.M{"$regex": "^" + regexp.QuoteMeta(identifier) + "$", "$options": "i"}}
+}
+
+// Package returns every version of the package matching PackageFilter
+func (s *Store) Package(ctx context.Context, identifier string) (models.Package, error) {
+	packages, err := s.findPackages(ctx, PackageFilter(identifier))
+	if err != nil {
+		return models.Package{}, err
+	}
+	if len(packages) == 0 {
+		return models.Package{}, ErrNotFound
+	}
+	return packages[0], nil
+}
+
+// Stream calls fn for each package matching filter, ordered by identifier,
+// decoding one version document at a time instead of loading the result set.
+// Sorting the whole catalog passes the in-memory sort limit, so the server
+// may sort on disk.
+func (s *Store) Stream(ctx context.Context, filter bson.M, fn func(models.Package) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "PackageIdentifier", Value: 1}}).SetAllowDiskUse(true)
+	cursor, err := s.packages.Find(ctx, live(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	// Versions of a package are contiguous in identifier order
+	var pending []models.Manifest
+	emit := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		pkg := models.FromManifests(pending)[0]
+		pending = pending[:0]
+		return fn(pkg)
+	}
+
+	for cursor.Next(ctx) {
+		var m models.Manifest
+		if err := cursor.Decode(&m); err != nil {
+			return err
+		}
+		if len(pending) > 0 && pending[0].PackageIdentifier != m.PackageIdentifier {
+			if err := emit(); err != nil {
+				return err
+			}
+		}
+		pending = append(pending, m)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	return emit()
+}
+
+// containsFilter matches value anywhere in field, case-insensitively
+func containsFilter(field, value string) bson.M {
+	return bson.M{field: bson.M{"$regex": regexp.QuoteMeta(value), "$options": "i"}}
+}
+
+// SearchFilter matches query in the package name, publisher, short description or author
+func SearchFilter(query string) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			containsFilter("PackageName", query),
+			containsFilter("Publisher", query),
+			containsFilter("ShortDescription", query),
+			containsFilter("Author", query),
+		},
+	}
+}
+
+// NameFilter matches packages whose name contains name
+func NameFilter(name string) bson.M {
+	return containsFilter("PackageName", name)
+}
+
+// IdentifierFilter matches packages whose identifier contains identifier
+func IdentifierFilter(identifier string) bson.M {
+	return containsFilter("PackageIdentifier", identifier)
+}
+
+// PublisherFilter matches packages whose raw Publisher field contains publisher
+func PublisherFilter(publisher string) bson.M {
+	return containsFilter("Publisher", publisher)
+}
+
+// Search looks for query in the package name, publisher, short description and author
+func (s *Store) Search(ctx context.Context, query string) ([]models.Package, error) {
+	return s.findPackages(ctx, SearchFilter(query))
+}
+
+// ByName returns the packages whose name contains name
+func (s *Store) ByName(ctx context.Context, name string) ([]models.Package, error) {
+	return s.findPackages(ctx, NameFilter(name))
+}
+
+// ByIdentifier returns the packages whose identifier contains identifier
+func (s *Store) ByIdentifier(ctx context.Context, identifier string) ([]models.Package, error) {
+	return s.findPackages(ctx, IdentifierFilter(identifier))
+}
+
+// ByPublisher returns the packages whose raw Publisher field contains publisher
+func (s *Store) ByPublisher(ctx context.Context, publisher string) ([]models.Package, error) {
+	return s.findPackages(ctx, PublisherFilter(publisher))
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// publisherAliases maps normalized spellings that cannot be derived by
+// suffix stripping alone onto a single publisher key
+var publisherAliases = map[string]string{
+	"msft":                          "microsoft",
+	"microsoft windows":             "microsoft",
+	"mozilla foundation":            "mozilla",
+	"jetbrains s r o":               "jetbrains",
+	"the git development community": "git",
+	"videolan team":                 "videolan",
+	"oracle america":                "oracle",
+	"adobe systems":                 "adobe",
+}
+
+// corporate suffixes dropped before comparing publisher names
+var publisherSuffixes = []string{
+	"corporation", "corp", "incorporated", "inc", "limited", "ltd", "llc",
+	"gmbh", "co", "company", "sa", "srl", "ag", "bv", "pty", "plc",
+}
+
+var nonAlphaNum = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NormalizePublisher turns a publisher display name into a stable key,
+// so "Microsoft Corporation" and "Microsoft Corp." share the key "microsoft"
+func NormalizePublisher(name string) string {
+	cleaned := strings.TrimSpace(nonAlphaNum.ReplaceAllString(strings.ToLower(name), " "))
+	words := strings.Fields(cleaned)
+
+	// Strip trailing corporate suffixes ("Foo Software Co., Ltd." -> "foo software")
+	for len(words) > 1 && isPublisherSuffix(words[len(words)-1]) {
+		words = words[:len(words)-1]
+	}
+	joined := strings.Join(words, " ")
+
+	if alias, ok := publisherAliases[joined]; ok {
+		return alias
+	}
+	return strings.Join(words, "-")
+}
+
+func isPublisherSuffix(word string) bool {
+	for _, suffix := range publisherSuffixes {
+		if word == suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// publisherVariant is one raw Publisher value with the number of packages using it
+type publisherVariant struct {
+	Publisher string `bson:"_id"`
+	Count     int    `bson:"count"`
+}
+
+// publisherVariants returns every raw Publisher spelling with its distinct package count
+func (s *Store) publisherVariants(ctx context.Context) ([]publisherVariant, error) {
+	pipeline := []bson.M{
+		{"$match": live(bson.M{"Publisher": bson.M{"$nin": []interface{}{nil, ""}}})},
+		{"$group": bson.M{"_id": "$Publisher", "ids": bson.M{"$addToSet": "$PackageIdentifier"}}},
+		{"$project": bson.M{"count": bson.M{"$size": "$ids"}}},
+	}
+	cursor, err := s.packages.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var variants []publisherVariant
+	if err := cursor.All(ctx, &variants); err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// publishers builds the full publisher directory sorted by key
+func (s *Store) publishers(ctx context.Context) ([]models.Publisher, error) {
+	variants, err := s.publisherVariants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge spelling variants under their normalized key
+	byKey := make(map[string]*models.Publisher)
+	topCount := make(map[string]int)
+	for _, v := range variants {
+		key := NormalizePublisher(v.Publisher)
+		if key == "" {
+			continue
+		}
+		p, ok := byKey[key]
+		if !ok {
+			p = &models.Publisher{Key: key}
+			byKey[key] = p
+		}
+		p.Variants = append(p.Variants, v.Publisher)
+		p.PackageCount += v.Count
+		// The most used spelling becomes the display name
+		if v.Count > topCount[key] || (v.Count == topCount[key] && v.Publisher < p.Name) {
+			topCount[key] = v.Count
+			p.Name = v.Publisher
+		}
+	}
+
+	publishers := make([]models.Publisher, 0, len(byKey))
+	for _, p := range byKey {
+		sort.Strings(p.Variants)
+		publishers = append(publishers, *p)
+	}
+	sort.Slice(publishers, func(i, j int) bool { return publishers[i].Key < publishers[j].Key })
+	return publishers, nil
+}
+
+// ListPublishers returns one page of the publisher directory, with the
+// total number of publishers before pagination. skip and limit must not be
+// negative.
+func (s *Store) ListPublishers(ctx context.Context, skip, limit int) ([]models.Publisher, int, error) {
+	if skip < 0 || limit < 0 {
+		return nil, 0, fmt.Errorf("invalid page: skip %d, limit %d", skip, limit)
+	}
+	publishers, err := s.publishers(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(publishers)
+	if skip >= total {
+		return []models.Publisher{}, total, nil
+	}
+	end := skip + limit
+	if end > total {
+		end = total
+	}
+	return publishers[skip:end], total, nil
+}
+
+// Publisher returns the directory entry of a normalized publisher key
+func (s *Store) Publisher(ctx context.Context, key string) (models.Publisher, error) {
+	publishers, err := s.publishers(ctx)
+	if err != nil {
+		return models.Publisher{}, err
+	}
+	for _, p := range publishers {
+		if p.Key == key {
+			return p, nil
+		}
+	}
+	return models.Publisher{}, ErrNotFound
+}
+
+// PublisherVariants returns the raw Publisher spellings that normalize to key
+func (s *Store) PublisherVariants(ctx context.Context, key string) ([]string, error) {
+	variants, err := s.publisherVariants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, v := range variants {
+		if NormalizePublisher(v.Publisher) == key {
+			names = append(names, v.Publisher)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// PackagesByPublisher returns the packages of a normalized publisher key,
+// with the total number of packages before pagination
+func (s *Store) PackagesByPublisher(ctx context.Context, key string, skip, limit int) ([]models.Package, int, error) {
+	names, err := s.PublisherVariants(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(names) == 0 {
+		return nil, 0, ErrNotFound
+	}
+
+	return s.FindPage(ctx, bson.M{"Publisher": bson.M{"$in": names}}, skip, limit)
+}
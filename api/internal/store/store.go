@@ -0,0 +1,26 @@
+package store
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// ErrNotFound is returned when a lookup matches no documents
+var ErrNotFound = errors.New("not found")
+
+// Store wraps the MongoDB collections used by the API handlers
+type Store struct {
+	packages *mongo.Collection
+	meta     *mongo.Collection
+	changes  *mongo.Collection
+}
+
+// New creates a store on top of the winget database
+func New(db *mongo.Database) *Store {
+	return &Store{
+		packages: db.Collection("packages"),
+		meta:     db.Collection("meta"),
+		changes:  db.Collection("changes"),
+	}
+}
@@ -0,0 +1,356 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	logs "github.com/iamBijoyKar/winget-pkg/api/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// EventPing is sent by the test endpoint to check a receiver
+const EventPing = "ping"
+
+// Config tunes delivery
+type Config struct {
+	// PollInterval is how often new changes and due retries are picked up
+	PollInterval time.Duration
+	// RetryBase is the delay before the first retry; it doubles per attempt
+	RetryBase time.Duration
+	// RetryMax caps the delay between retries
+	RetryMax time.Duration
+	// MaxAttempts is how often a delivery is tried before it is marked failed
+	MaxAttempts int
+	// Timeout bounds a single delivery request
+	Timeout time.Duration
+	// AllowPrivateTargets permits loopback and private network receivers
+	AllowPrivateTargets bool
+}
+
+// DefaultConfig retries for about a day before giving up
+var DefaultConfig = Config{
+	PollInterval: 5 * time.Second,
+	RetryBase:    30 * time.Second,
+	RetryMax:     6 * time.Hour,
+	MaxAttempts:  10,
+	Timeout:      10 * time.Second,
+}
+
+// Backoff returns the delay after the given failed attempt (1 based):
+// RetryBase doubled per attempt, capped at RetryMax, with ±20% jitter
+func (c Config) Backoff(attempt int) time.Duration {
+	delay := c.RetryBase
+	for i := 1; i < attempt && delay < c.RetryMax; i++ {
+		delay *= 2
+	}
+	if delay > c.RetryMax {
+		delay = c.RetryMax
+	}
+	jitter := time.Duration((rand.Float64()*0.4 - 0.2) * float64(delay))
+	return delay + jitter
+}
+
+// Sign computes the X-Webhook-Signature value for a delivery body.
+// Receivers recompute it over "<X-Webhook-Timestamp>.<body>" with their secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sharedAddressSpace is 100.64.0.0/10, used inside carrier and cloud networks
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// blockedIP reports addresses webhooks must not reach by default
+func blockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast() || sharedAddressSpace.Contains(ip)
+}
+
+// newHTTPClient builds the delivery client. Redirects are not followed and,
+// unless allowed, connections to private addresses are refused after DNS
+// resolution so a public hostname cannot be used to reach internal services.
+// The check only sees the address dialled, so the environment's proxy is
+// ignored then: through a proxy it would check the proxy, not the receiver.
+func newHTTPClient(config Config) *http.Client {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	proxy := http.ProxyFromEnvironment
+	if !config.AllowPrivateTargets {
+		proxy = nil
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+				return fmt.Errorf("refusing to deliver to %s", host)
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, Proxy: proxy},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Start runs the dispatcher until ctx is cancelled: it turns new change feed
+// entries into deliveries and sends deliveries that are due
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.config.PollInterval)
+		defer ticker.Stop()
+		for {
+			if err := s.fanOut(ctx); err != nil && ctx.Err() == nil {
+				logs.PrintError("Webhook fan-out failed: %v", err)
+			}
+			if err := s.deliverDue(ctx); err != nil && ctx.Err() == nil {
+				logs.PrintError("Webhook delivery failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// cursorDocument remembers the last change sequence turned into deliveries
+type cursorDocument struct {
+	Sequence int64 `bson:"sequence"`
+}
+
+// cursor returns the dispatcher position. On first start it begins at the
+// newest change, so existing history is not replayed to new webhooks.
+func (s *Service) cursor(ctx context.Context) (int64, error) {
+	var doc cursorDocument
+	err := s.state.FindOne(ctx, bson.M{"_id": "webhooks"}).Decode(&doc)
+	if err == nil {
+		return doc.Sequence, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, err
+	}
+
+	latest, err := s.st.RecentChanges(ctx, bson.M{}, 1)
+	if err != nil {
+		return 0, err
+	}
+	var sequence int64
+	if len(latest) > 0 {
+		sequence = latest[0].Sequence
+	}
+	return sequence, s.setCursor(ctx, sequence)
+}
+
+func (s *Service) setCursor(ctx context.Context, sequence int64) error {
+	_, err := s.state.UpdateOne(ctx, bson.M{"_id": "webhooks"},
+		bson.M{"$set": bson.M{"sequence": sequence}}, options.UpdateOne().SetUpsert(true))
+	return err
+}
+
+// fanOut queues a delivery for every webhook matching each new change
+func (s *Service) fanOut(ctx context.Context) error {
+	after, err := s.cursor(ctx)
+	if err != nil {
+		return err
+	}
+	changes, err := s.st.Changes(ctx, after, 500)
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+
+	cursor, err := s.hooks.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	var hooks []Webhook
+	if err := cursor.All(ctx, &hooks); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var queued []interface{}
+	for _, change := range changes {
+		for _, w := range hooks {
+			if w.Matches(change) {
+				queued = append(queued, Delivery{
+					// Deterministic ids make a repeated fan-out harmless
+					ID:            w.ID + "_" + strconv.FormatInt(change.Sequence, 10),
+					WebhookID:     w.ID,
+					Event:         "package." + change.Type,
+					Change:        change,
+					Status:        StatusPending,
+					Attempts:      []Attempt{},
+					NextAttemptAt: &now,
+					CreatedAt:     now,
+				})
+			}
+		}
+	}
+	if len(queued) > 0 {
+		_, err := s.deliveries.InsertMany(ctx, queued, options.InsertMany().SetOrdered(false))
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return s.setCursor(ctx, changes[len(changes)-1].Sequence)
+}
+
+// Ping queues a test delivery to a webhook of owner
+func (s *Service) Ping(ctx context.Context, owner, id string) (Delivery, error) {
+	if _, err := s.Get(ctx, owner, id); err != nil {
+		return Delivery{}, err
+	}
+
+	now := time.Now().UTC()
+	d := Delivery{
+		ID:            id + "_ping_" + randomHex(4),
+		WebhookID:     id,
+		Event:         EventPing,
+		Change:        models.Change{Type: EventPing, Timestamp: now, Tags: []string{}},
+		Status:        StatusPending,
+		Attempts:      []Attempt{},
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}
+	_, err := s.deliveries.InsertOne(ctx, d)
+	return d, err
+}
+
+// deliverDue sends every pending delivery whose next attempt is due. Each one
+// is claimed by pushing its next attempt out first, so concurrent API
+// instances do not send it twice.
+func (s *Service) deliverDue(ctx context.Context) error {
+	for ctx.Err() == nil {
+		now := time.Now().UTC()
+		lease := now.Add(s.config.Timeout * 2)
+
+		var d Delivery
+		err := s.deliveries.FindOneAndUpdate(ctx,
+			bson.M{"status": StatusPending, "nextAttemptAt": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"nextAttemptAt": lease}},
+			options.FindOneAndUpdate().SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}),
+		).Decode(&d)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var w Webhook
+		if err := s.hooks.FindOne(ctx, bson.M{"_id": d.WebhookID}).Decode(&w); err != nil {
+			// The webhook was deleted while the delivery was queued
+			s.deliveries.DeleteOne(ctx, bson.M{"_id": d.ID})
+			continue
+		}
+
+		attempt := s.send(ctx, w, d)
+		d = s.config.record(d, attempt, time.Now().UTC())
+		update := bson.M{
+			"$push": bson.M{"attempts": attempt},
+			"$set":  bson.M{"status": d.Status},
+		}
+		if d.NextAttemptAt != nil {
+			update["$set"].(bson.M)["nextAttemptAt"] = *d.NextAttemptAt
+		} else {
+			update["$unset"] = bson.M{"nextAttemptAt": ""}
+		}
+		if _, err := s.deliveries.UpdateOne(ctx, bson.M{"_id": d.ID}, update); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// record adds attempt to the log of d. A successful attempt completes it and
+// the last allowed one fails it; otherwise it is retried after the backoff.
+func (c Config) record(d Delivery, attempt Attempt, now time.Time) Delivery {
+	d.Attempts = append(d.Attempts, attempt)
+	d.NextAttemptAt = nil
+	switch {
+	case attempt.Error == "":
+		d.Status = StatusSucceeded
+	case len(d.Attempts) >= c.MaxAttempts:
+		d.Status = StatusFailed
+	default:
+		next := now.Add(c.Backoff(len(d.Attempts)))
+		d.Status, d.NextAttemptAt = StatusPending, &next
+	}
+	return d
+}
+
+// payload is the JSON body of a delivery
+type payload struct {
+	ID        string        `json:"id"`
+	Event     string        `json:"event"`
+	WebhookID string        `json:"webhookId"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Data      models.Change `json:"data"`
+}
+
+// send makes one delivery attempt. Any 2xx answer counts as success.
+func (s *Service) send(ctx context.Context, w Webhook, d Delivery) (attempt Attempt) {
+	start := time.Now()
+	attempt.At = start.UTC()
+	defer func() { attempt.DurationMs = time.Since(start).Milliseconds() }()
+
+	body, err := json.Marshal(payload{ID: d.ID, Event: d.Event, WebhookID: w.ID, CreatedAt: d.CreatedAt, Data: d.Change})
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "winget-pkg-api-webhooks/1")
+	req.Header.Set("X-Webhook-Id", w.ID)
+	req.Header.Set("X-Webhook-Delivery", d.ID)
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", Sign(w.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = "receiver answered " + resp.Status
+	}
+	return attempt
+}
@@ -0,0 +1,296 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+)
+
+// receiver answers the first failFirst requests with 503 and records every
+// request it gets
+type receiver struct {
+	mu        sync.Mutex
+	failFirst int
+	requests  []*http.Request
+	bodies    [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	n := len(r.requests)
+	r.mu.Unlock()
+
+	if n <= r.failFirst {
+		http.Error(w, "try again", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newTestService returns a service that only sends, allowed to reach the
+// loopback receivers of httptest
+func newTestService(config Config) *Service {
+	config.AllowPrivateTargets = true
+	return &Service{config: config, client: newHTTPClient(config)}
+}
+
+func newTestDelivery(w Webhook) Delivery {
+	now := time.Now().UTC()
+	return Delivery{
+		ID:        w.ID + "_42",
+		WebhookID: w.ID,
+		Event:     "package.updated",
+		Change: models.Change{
+			Sequence:   42,
+			Type:       "updated",
+			Identifier: "Git.Git",
+			Timestamp:  now,
+			Tags:       []string{},
+		},
+		Status:        StatusPending,
+		Attempts:      []Attempt{},
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}
+}
+
+func TestSendSignsDeliveries(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	s := newTestService(DefaultConfig)
+	w := Webhook{ID: "wh_test", URL: server.URL, Secret: "whsec_test"}
+	d := newTestDelivery(w)
+	before := time.Now().Unix()
+
+	attempt := s.send(t.Context(), w, d)
+	if attempt.Error != "" || attempt.StatusCode != http.StatusNoContent {
+		t.Fatalf("attempt = %+v, want a 204 without error", attempt)
+	}
+	if len(r.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(r.requests))
+	}
+
+	req, body := r.requests[0], r.bodies[0]
+	headers := map[string]string{
+		"Content-Type":       "application/json",
+		"X-Webhook-Id":       w.ID,
+		"X-Webhook-Delivery": d.ID,
+		"X-Webhook-Event":    d.Event,
+	}
+	for name, want := range headers {
+		if got := req.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	timestamp := req.Header.Get("X-Webhook-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || seconds < before || seconds > time.Now().Unix() {
+		t.Errorf("X-Webhook-Timestamp = %q, want the time of sending", timestamp)
+	}
+	if got, want := req.Header.Get("X-Webhook-Signature"), Sign(w.Secret, timestamp, body); got != want {
+		t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+	}
+	if req.Header.Get("X-Webhook-Signature") == Sign("whsec_other", timestamp, body) {
+		t.Error("signature does not depend on the secret")
+	}
+
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatalf("body is not a delivery: %v", err)
+	}
+	if p.ID != d.ID || p.Event != d.Event || p.WebhookID != w.ID || p.Data.Identifier != "Git.Git" {
+		t.Errorf("payload = %+v", p)
+	}
+}
+
+func TestSendRefusesLoopback(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// The receiver listens on 127.0.0.1, which the default config refuses
+	// at connect time, after the URL passed validation
+	config := DefaultConfig
+	config.AllowPrivateTargets = false
+	s := &Service{config: config, client: newHTTPClient(config)}
+	w := Webhook{ID: "wh_test", URL: server.URL, Secret: "whsec_test"}
+
+	attempt := s.send(t.Context(), w, newTestDelivery(w))
+	if !strings.Contains(attempt.Error, "refusing to deliver to 127.0.0.1") {
+		t.Errorf("attempt = %+v, want the loopback address refused", attempt)
+	}
+	if len(r.requests) != 0 {
+		t.Errorf("receiver got %d requests, want none", len(r.requests))
+	}
+	// A proxy would be dialled instead of the receiver and hide its address
+	if s.client.Transport.(*http.Transport).Proxy != nil {
+		t.Error("the client goes through the environment's proxy")
+	}
+	if newTestService(DefaultConfig).client.Transport.(*http.Transport).Proxy == nil {
+		t.Error("the proxy is ignored when private targets are allowed")
+	}
+}
+
+func TestBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"100.64.0.1", true},
+		{"100.127.255.254", true},
+		{"100.63.255.255", false},
+		{"100.128.0.1", false},
+		{"8.8.8.8", false},
+		{"2606:4700::1111", false},
+	}
+	for _, tt := range tests {
+		if got := blockedIP(net.ParseIP(tt.ip)); got != tt.blocked {
+			t.Errorf("blockedIP(%s) = %v, want %v", tt.ip, got, tt.blocked)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Receivers compute HMAC-SHA256 over "<timestamp>.<body>"
+	got := Sign("secret", "1700000000", []byte(`{"id":"d"}`))
+	want := "sha256=c355ed8b3d66641319d7e10cde5e7c4c497c066d8d1d84a5e75f83aef640b2de"
+	if got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+	if Sign("secret", "1700000001", []byte(`{"id":"d"}`)) == got {
+		t.Error("signature does not cover the timestamp")
+	}
+	if Sign("secret", "1700000000", []byte(`{"id":"e"}`)) == got {
+		t.Error("signature does not cover the body")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	config := Config{RetryBase: 30 * time.Second, RetryMax: 6 * time.Hour}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 16 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, 6 * time.Hour},
+		{50, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		// Jitter is random, so sample a few delays of each attempt
+		for range 20 {
+			got := config.Backoff(tt.attempt)
+			low, high := tt.want*8/10, tt.want*12/10
+			if got < low || got > high {
+				t.Errorf("Backoff(%d) = %v, want %v ± 20%%", tt.attempt, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestRetryScheduleAndLog(t *testing.T) {
+	r := &receiver{failFirst: 2}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	config := DefaultConfig
+	config.RetryBase = time.Minute
+	s := newTestService(config)
+	w := Webhook{ID: "wh_test", URL: server.URL, Secret: "whsec_test"}
+	d := newTestDelivery(w)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, wantDelay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		d = s.config.record(d, s.send(t.Context(), w, d), now)
+		if d.Status != StatusPending || d.NextAttemptAt == nil {
+			t.Fatalf("after failed attempt %d: status %s, next attempt %v, want a pending retry", i+1, d.Status, d.NextAttemptAt)
+		}
+		delay := d.NextAttemptAt.Sub(now)
+		if delay < wantDelay*8/10 || delay > wantDelay*12/10 {
+			t.Errorf("retry %d is due after %v, want %v ± 20%%", i+1, delay, wantDelay)
+		}
+		now = *d.NextAttemptAt
+	}
+
+	d = s.config.record(d, s.send(t.Context(), w, d), now)
+	if d.Status != StatusSucceeded || d.NextAttemptAt != nil {
+		t.Fatalf("after the third attempt: status %s, next attempt %v, want succeeded", d.Status, d.NextAttemptAt)
+	}
+
+	// The log keeps every attempt with what the receiver answered
+	if len(d.Attempts) != 3 {
+		t.Fatalf("logged %d attempts, want 3", len(d.Attempts))
+	}
+	for i, want := range []int{503, 503, 204} {
+		a := d.Attempts[i]
+		if a.StatusCode != want || (a.Error == "") != (want == 204) || a.At.IsZero() || a.DurationMs < 0 {
+			t.Errorf("attempt %d = %+v, want status %d", i+1, a, want)
+		}
+	}
+	if len(r.requests) != 3 {
+		t.Errorf("receiver got %d requests, want 3", len(r.requests))
+	}
+	// Retries are the same delivery, re-signed at the time they are sent
+	for _, req := range r.requests {
+		if req.Header.Get("X-Webhook-Delivery") != d.ID {
+			t.Errorf("retry sent as delivery %q, want %q", req.Header.Get("X-Webhook-Delivery"), d.ID)
+		}
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	r := &receiver{failFirst: 100}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	config := DefaultConfig
+	config.MaxAttempts = 3
+	s := newTestService(config)
+	w := Webhook{ID: "wh_test", URL: server.URL, Secret: "whsec_test"}
+	d := newTestDelivery(w)
+
+	now := time.Now().UTC()
+	for range config.MaxAttempts {
+		d = s.config.record(d, s.send(t.Context(), w, d), now)
+	}
+	if d.Status != StatusFailed || d.NextAttemptAt != nil || len(d.Attempts) != config.MaxAttempts {
+		t.Errorf("after %d failures: status %s, next attempt %v, %d attempts logged, want failed without retry",
+			config.MaxAttempts, d.Status, d.NextAttemptAt, len(d.Attempts))
+	}
+
+	// Unreachable receivers are logged without a status code
+	server.Close()
+	d = newTestDelivery(w)
+	d = s.config.record(d, s.send(t.Context(), w, d), now)
+	if a := d.Attempts[0]; a.StatusCode != 0 || a.Error == "" || d.Status != StatusPending {
+		t.Errorf("attempt on a closed receiver = %+v, status %s", a, d.Status)
+	}
+}
@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/auth"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+)
+
+// createRequest is the body of POST /webhooks
+type createRequest struct {
+	URL     string  `json:"url"`
+	Filters Filters `json:"filters"`
+}
+
+// Register adds the webhook management endpoints to group. Webhooks belong to
+// the API key that created them; other keys get 404 for them.
+func (s *Service) Register(group *gin.RouterGroup) {
+	group.POST("", s.createHandler)
+	group.GET("", s.listHandler)
+	group.GET("/:id", s.getHandler)
+	group.DELETE("/:id", s.deleteHandler)
+	group.GET("/:id/deliveries", s.deliveriesHandler)
+	group.POST("/:id/ping", s.pingHandler)
+}
+
+// owner identifies the caller set by the auth middleware
+func owner(c *gin.Context) string {
+	principal, _ := c.MustGet("principal").(auth.Principal)
+	return principal.KeyID
+}
+
+// cleanList trims values and drops empty ones
+func cleanList(values []string) []string {
+	list := []string{}
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func (s *Service) createHandler(c *gin.Context) {
+	var req createRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		server.InvalidBody(c, "body", "must be a JSON object with url and filters")
+		return
+	}
+
+	req.URL = strings.TrimSpace(req.URL)
+	if req.URL == "" {
+		server.InvalidBody(c, "url", "is required")
+		return
+	}
+	if err := s.validateURL(req.URL); err != nil {
+		server.InvalidBody(c, "url", err.Error())
+		return
+	}
+
+	filters := Filters{
+		Identifiers: cleanList(req.Filters.Identifiers),
+		Publishers:  cleanList(req.Filters.Publishers),
+		Tags:        cleanList(req.Filters.Tags),
+		Events:      cleanList(req.Filters.Events),
+	}
+	for _, event := range filters.Events {
+		if event != models.ChangeAdded && event != models.ChangeUpdated && event != models.ChangeRemoved {
+			server.InvalidBody(c, "filters.events", "must only contain added, updated or removed")
+			return
+		}
+	}
+
+	w, err := s.Create(c.Request.Context(), owner(c), req.URL, filters)
+	if errors.Is(err, ErrLimitReached) {
+		server.AbortWithProblem(c, server.NewProblem(http.StatusConflict, server.CodeLimitReached,
+			"An API key can own at most "+strconv.Itoa(MaxPerOwner)+" webhooks").With("limit", MaxPerOwner))
+		return
+	}
+	if err != nil {
+		server.StoreUnavailable(c, "Failed to create the webhook")
+		return
+	}
+
+	c.Header("Location", c.Request.URL.Path+"/"+w.ID)
+	// The secret is only ever shown in this response
+	server.JSON(c, http.StatusCreated, w, nil)
+}
+
+func (s *Service) listHandler(c *gin.Context) {
+	hooks, err := s.List(c.Request.Context(), owner(c))
+	if err != nil {
+		server.StoreUnavailable(c, "Failed to list webhooks")
+		return
+	}
+	server.JSON(c, http.StatusOK, hooks, gin.H{"count": len(hooks), "limit": MaxPerOwner})
+}
+
+// abortWithError maps service errors onto problem responses
+func abortWithError(c *gin.Context, err error) {
+	if errors.Is(err, ErrNotFound) {
+		server.NotFound(c, "No webhook with id "+c.Param("id"))
+		return
+	}
+	server.StoreUnavailable(c, "Failed to read the webhook")
+}
+
+func (s *Service) getHandler(c *gin.Context) {
+	w, err := s.Get(c.Request.Context(), owner(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	server.JSON(c, http.StatusOK, w, nil)
+}
+
+func (s *Service) deleteHandler(c *gin.Context) {
+	if err := s.Delete(c.Request.Context(), owner(c), c.Param("id")); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Service) deliveriesHandler(c *gin.Context) {
+	limit := 50
+	if v, err := strconv.Atoi(c.Query("per_page")); err == nil && v > 0 && v <= 200 {
+		limit = v
+	}
+
+	deliveries, err := s.Deliveries(c.Request.Context(), owner(c), c.Param("id"), limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	server.JSON(c, http.StatusOK, deliveries, gin.H{"count": len(deliveries)})
+}
+
+func (s *Service) pingHandler(c *gin.Context) {
+	d, err := s.Ping(c.Request.Context(), owner(c), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	server.JSON(c, http.StatusAccepted, d, nil)
+}
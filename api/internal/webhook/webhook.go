@@ -0,0 +1,252 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MaxPerOwner is how many webhooks one API key may register
+const MaxPerOwner = 20
+
+var (
+	// ErrNotFound is returned for unknown webhooks and webhooks of other owners
+	ErrNotFound = errors.New("webhook not found")
+	// ErrLimitReached is returned when an owner already has MaxPerOwner webhooks
+	ErrLimitReached = errors.New("webhook limit reached")
+)
+
+// Filters select the changes a webhook is notified about. Each non-empty
+// list must match; values inside a list are alternatives.
+type Filters struct {
+	Identifiers []string `bson:"identifiers" json:"identifiers"`
+	Publishers  []string `bson:"publishers" json:"publishers"`
+	Tags        []string `bson:"tags" json:"tags"`
+	Events      []string `bson:"events" json:"events"`
+}
+
+// Webhook is a subscription owned by an API key
+type Webhook struct {
+	ID        string    `bson:"_id" json:"id"`
+	Owner     string    `bson:"owner" json:"-"`
+	URL       string    `bson:"url" json:"url"`
+	Secret    string    `bson:"secret" json:"secret,omitempty"`
+	Filters   Filters   `bson:"filters" json:"filters"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+}
+
+// Matches reports whether change passes the webhook's filters
+func (w Webhook) Matches(change models.Change) bool {
+	if change.Timestamp.Before(w.CreatedAt) {
+		return false
+	}
+	f := w.Filters
+	if len(f.Events) > 0 && !containsFold(f.Events, change.Type) {
+		return false
+	}
+	if len(f.Identifiers) > 0 && !containsFold(f.Identifiers, change.Identifier) {
+		return false
+	}
+	if len(f.Publishers) > 0 && !containsFold(normalized(f.Publishers), store.NormalizePublisher(change.Publisher)) {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		for _, tag := range change.Tags {
+			if containsFold(f.Tags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalized(publishers []string) []string {
+	keys := make([]string, len(publishers))
+	for i, p := range publishers {
+		keys[i] = store.NormalizePublisher(p)
+	}
+	return keys
+}
+
+// Attempt is one try at delivering an event
+type Attempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"statusCode" json:"statusCode"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs int64     `bson:"durationMs" json:"durationMs"`
+}
+
+// Delivery states
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Delivery is one event queued for a webhook, with its attempt log
+type Delivery struct {
+	ID            string        `bson:"_id" json:"id"`
+	WebhookID     string        `bson:"webhookId" json:"webhookId"`
+	Event         string        `bson:"event" json:"event"`
+	Change        models.Change `bson:"change" json:"change"`
+	Status        string        `bson:"status" json:"status"`
+	Attempts      []Attempt     `bson:"attempts" json:"attempts"`
+	NextAttemptAt *time.Time    `bson:"nextAttemptAt,omitempty" json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time     `bson:"createdAt" json:"createdAt"`
+}
+
+// Service stores webhooks and delivers change events to them
+type Service struct {
+	st         *store.Store
+	hooks      *mongo.Collection
+	deliveries *mongo.Collection
+	state      *mongo.Collection
+	config     Config
+	client     *http.Client
+}
+
+// New creates the webhook service on top of the winget database
+func New(db *mongo.Database, st *store.Store, config Config) *Service {
+	return &Service{
+		st:         st,
+		hooks:      db.Collection("webhooks"),
+		deliveries: db.Collection("webhook_deliveries"),
+		state:      db.Collection("meta"),
+		config:     config,
+		client:     newHTTPClient(config),
+	}
+}
+
+// validateURL accepts absolute http(s) URLs. Private and loopback targets are
+// refused unless the service allows them, e.g. for a local test receiver.
+func (s *Service) validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return errors.New("must be an absolute http or https URL")
+	}
+	if s.config.AllowPrivateTargets {
+		return nil
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return errors.New("must not point to a private or loopback address")
+	}
+	if ip := net.ParseIP(host); ip != nil && blockedIP(ip) {
+		return errors.New("must not point to a private or loopback address")
+	}
+	return nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Create registers a webhook for owner and returns it with its signing secret
+func (s *Service) Create(ctx context.Context, owner, target string, filters Filters) (Webhook, error) {
+	count, err := s.hooks.CountDocuments(ctx, bson.M{"owner": owner})
+	if err != nil {
+		return Webhook{}, err
+	}
+	if count >= MaxPerOwner {
+		return Webhook{}, ErrLimitReached
+	}
+
+	w := Webhook{
+		ID:        "wh_" + randomHex(12),
+		Owner:     owner,
+		URL:       target,
+		Secret:    "whsec_" + randomHex(24),
+		Filters:   filters,
+		CreatedAt: time.Now().UTC(),
+	}
+	if _, err := s.hooks.InsertOne(ctx, w); err != nil {
+		return Webhook{}, err
+	}
+	return w, nil
+}
+
+// List returns the webhooks of owner, without their secrets
+func (s *Service) List(ctx context.Context, owner string) ([]Webhook, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}).SetProjection(bson.M{"secret": 0})
+	cursor, err := s.hooks.Find(ctx, bson.M{"owner": owner}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	hooks := []Webhook{}
+	if err := cursor.All(ctx, &hooks); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// Get returns one webhook of owner, without its secret
+func (s *Service) Get(ctx context.Context, owner, id string) (Webhook, error) {
+	var w Webhook
+	opts := options.FindOne().SetProjection(bson.M{"secret": 0})
+	err := s.hooks.FindOne(ctx, bson.M{"_id": id, "owner": owner}, opts).Decode(&w)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Webhook{}, ErrNotFound
+	}
+	return w, err
+}
+
+// Delete removes a webhook of owner together with its delivery log
+func (s *Service) Delete(ctx context.Context, owner, id string) error {
+	result, err := s.hooks.DeleteOne(ctx, bson.M{"_id": id, "owner": owner})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	_, err = s.deliveries.DeleteMany(ctx, bson.M{"webhookId": id})
+	return err
+}
+
+// Deliveries returns the most recent deliveries of a webhook of owner
+func (s *Service) Deliveries(ctx context.Context, owner, id string, limit int) ([]Delivery, error) {
+	if _, err := s.Get(ctx, owner, id); err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := s.deliveries.Find(ctx, bson.M{"webhookId": id}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []Delivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
@@ -2,9 +2,15 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
-	"regexp"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,33 +18,67 @@ import (
 	"github.com/gin-contrib/cache/persistence"
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
+	"github.com/gomodule/redigo/redis"
+	"github.com/iamBijoyKar/winget-pkg/api/apikey"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/admin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/auth"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/diff"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/events"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/export"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/feed"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/gql"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/locale"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/models"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/openapi"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/restsource"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/rpc"
+	pb "github.com/iamBijoyKar/winget-pkg/api/internal/rpc/wingetpkgv1"
 	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
 	logs "github.com/iamBijoyKar/winget-pkg/api/internal/utils"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/webhook"
 	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 const baseURL = "api/v1"
 
-type User struct {
-	Email  string `bson:"email" json:"email"`
-	ApiKey string `bson:"apiKey" json:"apiKey"`
-}
+// streamPath is where the change streams are mounted
+const streamPath = "/" + baseURL + "/stream"
 
-func authMiddleware(coll *mongo.Collection) gin.HandlerFunc {
+func authMiddleware(authenticator *auth.Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		apiKey := c.GetHeader("X-API-Key")
 		if apiKey == "" {
-			c.JSON(401, gin.H{"error": "API key is required"})
-			c.Abort()
-			return
+			// winget clients send the value of `winget source add --header` here
+			apiKey = c.GetHeader("Windows-Package-Manager")
+		}
+		if apiKey == "" && (feed.IsFeedPath(c.Request.URL.Path) || events.IsStreamPath(streamPath, c.Request.URL.Path)) {
+			apiKey = c.Query("api_key")
 		}
 
-		result := coll.FindOne(context.TODO(), gin.H{"apiKey": apiKey})
-		if result.Err() != nil {
-			c.JSON(401, gin.H{"error": "Invalid API key"})
-			c.Abort()
+		principal, err := authenticator.Authenticate(c.Request.Context(), apiKey)
+		switch err {
+		case nil:
+			c.Set("principal", principal)
+			// Rate limits and quotas are counted per key, not per client IP
+			c.Set("rateLimitKey", server.CallerKey(principal.KeyID, ""))
+		case auth.ErrMissingKey:
+			server.AbortWithProblem(c, server.NewProblem(401, server.CodeMissingKey, "Send your API key in the X-API-Key header"))
+			return
+		case auth.ErrInvalidKey:
+			server.AbortWithProblem(c, server.NewProblem(401, server.CodeInvalidKey, "The X-API-Key header does not match any registered key"))
+			return
+		case auth.ErrExpiredKey:
+			server.AbortWithProblem(c, server.NewProblem(401, server.CodeExpiredKey, "The API key has expired, ask for a new one"))
+			return
+		case auth.ErrRevokedKey:
+			server.AbortWithProblem(c, server.NewProblem(401, server.CodeRevokedKey, "The API key has been revoked"))
+			return
+		default:
+			server.StoreUnavailable(c, "API keys cannot be checked right now")
 			return
 		}
 
@@ -46,33 +86,311 @@ func authMiddleware(coll *mongo.Collection) gin.HandlerFunc {
 	}
 }
 
-func rateLimitMiddleware(rateLimiter *server.RateLimiter) gin.HandlerFunc {
+// scopeMiddleware rejects keys that were not granted scope. It runs per route,
+// after authMiddleware has set the principal.
+func scopeMiddleware(scope string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ipv4 := c.ClientIP()
+		principal, _ := c.MustGet("principal").(auth.Principal)
+		if !principal.HasScope(scope) {
+			server.AbortWithProblem(c, server.NewProblem(403, server.CodeForbidden, "The API key lacks the "+scope+" scope this endpoint needs").
+				With("scope", scope))
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitKey returns the caller a request is counted against, the key set
+// by authMiddleware. It rejects requests without a key, so there is always one.
+func rateLimitKey(c *gin.Context) string {
+	return c.GetString("rateLimitKey")
+}
+
+// routeCosts are the HTTP routes and gRPC methods that do not cost
+// server.DefaultCost: status checks are free, partial matches scan the
+// catalog, export files are the whole catalog, GraphQL queries cost what
+// their complexity says and batches cost per package
+var routeCosts = server.Costs{
+	"/" + baseURL + "/ping":                   server.FreeCost,
+	"/" + baseURL + "/rate-limit":             server.FreeCost,
+	"/" + baseURL + "/search":                 server.SearchCost,
+	"/" + baseURL + "/packagename":            server.SearchCost,
+	"/" + baseURL + "/packageidentifier":      server.SearchCost,
+	"/" + baseURL + "/publisher":              server.SearchCost,
+	"/" + baseURL + "/graphql":                server.QueryCost,
+	"/" + baseURL + "/winget/manifestSearch":  server.SearchCost,
+	"/" + baseURL + "/export/:file":           server.ExportCost,
+	pb.PackageService_Search_FullMethodName:   server.SearchCost,
+	pb.PackageService_BatchGet_FullMethodName: server.ItemCost,
+}
+
+// routeQueries are the query parameters each route reads, checked against
+// the OpenAPI document at startup. locale and fields, which every response
+// honours, are listed where they change the result. The handlers are not
+// inspected, so add a parameter here whenever a handler starts reading one;
+// a route registered without an entry stops the server from starting.
+var routeQueries = openapi.Queries{
+	"GET /" + baseURL + "/ping":                                       {},
+	"GET /" + baseURL + "/openapi.json":                               {},
+	"GET /" + baseURL + "/rate-limit":                                 {},
+	"GET /" + baseURL + "/search":                                     {"q", "locale", "fields"},
+	"GET /" + baseURL + "/packagename":                                {"name", "locale", "fields"},
+	"GET /" + baseURL + "/packageidentifier":                          {"identifier", "locale", "fields"},
+	"GET /" + baseURL + "/publisher":                                  {"publisher", "locale", "fields"},
+	"GET /" + baseURL + "/publishers":                                 {"page", "per_page", "fields"},
+	"GET /" + baseURL + "/publishers/:publisher/packages":             {"page", "per_page", "locale", "fields"},
+	"GET /" + baseURL + "/graphql":                                    {"query", "variables", "operationName", "locale"},
+	"POST /" + baseURL + "/graphql":                                   {"locale"},
+	"GET /" + baseURL + "/changes":                                    {"since", "per_page"},
+	"GET /" + baseURL + "/feeds/new-packages":                         {"format", "api_key"},
+	"GET /" + baseURL + "/feeds/updates":                              {"publisher", "format", "api_key"},
+	"GET /" + baseURL + "/packages/:identifier/feed":                  {"format", "api_key"},
+	"GET /" + baseURL + "/packages/:identifier/diff":                  {"from", "to", "format"},
+	"GET /" + baseURL + "/stream/changes":                             {"last_event_id", "api_key"},
+	"GET /" + baseURL + "/webhooks":                                   {},
+	"POST /" + baseURL + "/webhooks":                                  {},
+	"GET /" + baseURL + "/webhooks/:id":                               {},
+	"DELETE /" + baseURL + "/webhooks/:id":                            {},
+	"POST /" + baseURL + "/webhooks/:id/ping":                         {},
+	"GET /" + baseURL + "/webhooks/:id/deliveries":                    {"per_page"},
+	"GET /" + baseURL + "/export":                                     {"format"},
+	"GET /" + baseURL + "/export/:file":                               {},
+	"GET /" + baseURL + "/winget/information":                         {},
+	"POST /" + baseURL + "/winget/manifestSearch":                     {},
+	"GET /" + baseURL + "/winget/packageManifests/:PackageIdentifier": {"Version", "Channel"},
+	"GET /" + baseURL + "/admin/users/:email/keys":                    {},
+	"POST /" + baseURL + "/admin/users/:email/keys":                   {},
+	"PATCH /" + baseURL + "/admin/users/:email/keys/:name":            {},
+	"POST /" + baseURL + "/admin/users/:email/keys/:name/rotate":      {},
+	"POST /" + baseURL + "/admin/users/:email/keys/:name/revoke":      {},
+	"POST /" + baseURL + "/admin/users/:email/revoke":                 {},
+}
 
-		// Get rate limit info for headers
-		remaining, reset, limit := server.GetRateLimitInfo(ipv4, rateLimiter)
-
-		// Add rate limit headers
-		c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
-		c.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
-		c.Header("X-RateLimit-Reset", fmt.Sprintf("%d", reset.Unix()))
-
-		if !server.CheckRateLimit(ipv4, rateLimiter) {
-			c.Header("Retry-After", fmt.Sprintf("%d", int(time.Until(reset).Seconds())))
-			c.JSON(429, gin.H{
-				"error":       "Rate limit exceeded",
-				"retry_after": int(time.Until(reset).Seconds()),
-				"limit":       limit,
-				"window":      rateLimiter.GetStats()["window"],
-			})
-			c.Abort()
+func rateLimitMiddleware(tiers *server.TierLimiter, costs server.Costs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cost := costs.Cost(c.FullPath())
+		// Routes costed by what they run charge the limit themselves
+		if cost == server.QueryCost {
+			c.Next()
 			return
 		}
+		if chargeRequest(c, tiers, cost) {
+			c.Next()
+		}
+	}
+}
+
+// chargeRequest takes cost from the rate limit and quotas of the request's
+// key and sets the headers reporting them. It answers 429 and returns false
+// when the key is limited.
+func chargeRequest(c *gin.Context, tiers *server.TierLimiter, cost int) bool {
+	p, _ := c.MustGet("principal").(auth.Principal)
+	charge := tiers.Charge(p.KeyID, p.Tier, cost)
+	for name, value := range charge.Headers {
+		c.Header(name, value)
+	}
+	c.Header("X-RateLimit-Endpoint", c.FullPath())
+
+	switch charge.Code {
+	case server.CodeRateLimited:
+		c.Header("Retry-After", fmt.Sprintf("%d", charge.RetryAfter))
+		server.AbortWithProblem(c, server.NewProblem(429, server.CodeRateLimited, "Too many requests, retry after the window resets").
+			With("retryAfter", charge.RetryAfter).
+			With("limit", charge.Limit).
+			With("cost", charge.Cost).
+			With("window", charge.Tier.Window.String()).
+			With("tier", charge.Tier.Name))
+		return false
+	case server.CodeQuotaExceeded:
+		c.Header("Retry-After", fmt.Sprintf("%d", charge.RetryAfter))
+		server.AbortWithProblem(c, server.NewProblem(429, server.CodeQuotaExceeded, "The "+charge.Quota+" quota of the "+charge.Tier.Name+" tier is used up").
+			With("retryAfter", charge.RetryAfter).
+			With("quota", charge.Quota).
+			With("tier", charge.Tier.Name))
+		return false
+	}
+	return true
+}
+
+const (
+	defaultPerPage = 50
+	maxPerPage     = 200
+)
+
+// parsePagination reads the page and per_page query parameters
+// and returns the matching skip/limit values
+func parsePagination(c *gin.Context) (page, perPage, skip int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err = strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(defaultPerPage)))
+	if err != nil || perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+	return page, perPage, (page - 1) * perPage
+}
+
+// changeToken is the opaque resume position of the change feed
+func changeToken(sequence int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("seq:" + strconv.FormatInt(sequence, 10)))
+}
+
+// parseSince resolves the since parameter of the change feed, either a token
+// from a previous page or an RFC 3339 timestamp, to the sequence to read after
+func parseSince(ctx context.Context, st *store.Store, since string) (int64, error) {
+	if since == "" {
+		return 0, nil
+	}
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return st.SequenceBefore(ctx, t)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(since)
+	if err == nil && strings.HasPrefix(string(raw), "seq:") {
+		if sequence, err := strconv.ParseInt(strings.TrimPrefix(string(raw), "seq:"), 10, 64); err == nil && sequence >= 0 {
+			return sequence, nil
+		}
+	}
+	return 0, errInvalidSince
+}
+
+var errInvalidSince = errors.New("must be a token from a previous response or an RFC 3339 timestamp")
+
+// localeMiddleware resolves the preferred locales from the locale query
+// parameter, falling back to the Accept-Language header
+func localeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Add to the Vary of the gzip middleware instead of replacing it
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		prefs := locale.Parse(c.Query("locale"))
+		if len(prefs) == 0 {
+			prefs = locale.Parse(c.GetHeader("Accept-Language"))
+			if len(prefs) > 0 {
+				// Page cache keys are built from the request URI, so carry the
+				// negotiated locales in the query to keep cached pages per language
+				query := c.Request.URL.Query()
+				query.Set("locale", strings.Join(prefs, ","))
+				c.Request.URL.RawQuery = query.Encode()
+			}
+		}
+
+		c.Set("locales", prefs)
 		c.Next()
 	}
 }
 
+// localizeResults applies the preferred locale to each package
+// and reports the locales used in the Content-Language header
+func localizeResults(c *gin.Context, results []models.Package) {
+	prefs := c.GetStringSlice("locales")
+
+	var used []string
+	seen := make(map[string]bool)
+	for i := range results {
+		tag := results[i].Localize(prefs)
+		if !seen[tag] {
+			seen[tag] = true
+			used = append(used, tag)
+		}
+	}
+
+	if len(used) > 0 {
+		c.Header("Content-Language", strings.Join(used, ", "))
+	}
+}
+
+// pageWriter keeps the headers a cached handler sets apart from those of the
+// caller, such as X-RateLimit-* and X-Quota-*, and adds them to the response
+// on the first write
+type pageWriter struct {
+	gin.ResponseWriter
+	header http.Header
+	merged bool
+}
+
+func (w *pageWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *pageWriter) merge() {
+	if w.merged {
+		return
+	}
+	w.merged = true
+	for name, values := range w.header {
+		w.ResponseWriter.Header()[name] = values
+	}
+}
+
+func (w *pageWriter) WriteHeaderNow() {
+	w.merge()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *pageWriter) Write(data []byte) (int, error) {
+	w.merge()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *pageWriter) WriteString(data string) (int, error) {
+	w.merge()
+	return w.ResponseWriter.WriteString(data)
+}
+
+// cachePage caches the pages of handle like cache.CachePageAtomic. Only the
+// headers set by handle are stored, so the rate limit and quota headers of
+// the first caller are not replayed to others.
+func cachePage(store persistence.CacheStore, expire time.Duration, handle gin.HandlerFunc) gin.HandlerFunc {
+	cached := cache.CachePageAtomic(store, expire, handle)
+	return func(c *gin.Context) {
+		writer := &pageWriter{ResponseWriter: c.Writer, header: http.Header{}}
+		c.Writer = writer
+		cached(c)
+		// Pages without a body are written once the handlers return
+		writer.merge()
+		c.Writer = writer.ResponseWriter
+	}
+}
+
+// streamMiddleware serves NDJSON or CSV straight from the database cursor when
+// the Accept header asks for it, bypassing the page cache. JSON requests pass
+// through to the cached handler.
+func streamMiddleware(st *store.Store, param string, filter func(string) bson.M) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := server.StreamFormat(c)
+		if format == "" {
+			c.Next()
+			return
+		}
+		// localeMiddleware already varies on Accept-Language
+		c.Writer.Header().Add("Vary", "Accept")
+
+		value := strings.TrimSpace(c.Query(param))
+		if value == "" {
+			server.MissingParam(c, param)
+			return
+		}
+
+		prefs := c.GetStringSlice("locales")
+		w := server.NewPackageWriter(c, format)
+		err := st.Stream(c.Request.Context(), filter(value), func(p models.Package) error {
+			p.Localize(prefs)
+			return w.Write(p)
+		})
+		if err != nil && c.Request.Context().Err() == nil {
+			// Headers are already sent, so the best we can do is log and cut the stream
+			logs.PrintError("Stream failed: %v", err)
+		}
+		w.Flush()
+		c.Abort()
+	}
+}
+
 type logEntry struct {
 	timestamp string
 	method    string
@@ -92,11 +410,28 @@ func init() {
 	}()
 }
 
+// redactURI masks the api_key query parameter, which feed readers and event
+// streams send instead of a header, so keys do not end up in the logs
+func redactURI(uri string) string {
+	path, query, found := strings.Cut(uri, "?")
+	if !found {
+		return uri
+	}
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(name); err == nil && name == "api_key" {
+			params[i] = "api_key=REDACTED"
+		}
+	}
+	return path + "?" + strings.Join(params, "&")
+}
+
 func loggerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		method := c.Request.Method
-		uri := c.Request.RequestURI
+		uri := redactURI(c.Request.RequestURI)
 		requestTime := start.Format("2006-01-02 15:04:05")
 
 		c.Next()
@@ -118,55 +453,47 @@ func loggerMiddleware() gin.HandlerFunc {
 	}
 }
 
-func main() {
-	// Load environment variables from .env file
-	err := godotenv.Load()
-	if err != nil {
-		logs.PrintWarning("No .env file found, using default environment variables")
-	}
-	// Check if MONGODB_URL is set
-	MONGODB_URL := os.Getenv("MONGODB_URL")
-	if MONGODB_URL == "" {
-		logs.PrintWarning("MONGODB_URL not set in .env, using default value")
-		os.Exit(1)
-	}
-	// Connect to MongoDB
-	client, err := mongo.Connect(options.Client().
-		ApplyURI(MONGODB_URL))
-	if err != nil {
-		panic(err)
-	}
-	// close the connection when done
-	defer func() {
-		if err := client.Disconnect(context.TODO()); err != nil {
-			panic(err)
-		} else {
-			logs.PrintInfo("MongoDB connection closed successfully")
-		}
-	}()
+// services are what the routes serve requests with. main builds them from
+// the environment and starts their background work.
+type services struct {
+	store            *store.Store
+	keys             *apikey.Store
+	authenticator    *auth.Authenticator
+	tiers            *server.TierLimiter
+	webhooks         *webhook.Service
+	broker           *events.Broker
+	exporter         *export.Exporter
+	sourceIdentifier string
+}
 
-	// package collection, mongo connection pool
-	pkgColl := client.Database("winget").Collection("packages")
-	userColl := client.Database("winget").Collection("users")
+// newRouter registers every route on a new router. Nothing is read from the
+// database until a request comes in, so the routes can be built and checked
+// against spec without one.
+func newRouter(spec *openapi.Spec, svc services) (*gin.Engine, error) {
+	st, tiers := svc.store, svc.tiers
 
 	// default router with recovery and logger
 	router := gin.New()
-	router.Use(gin.Recovery())
+	router.Use(gin.CustomRecovery(func(c *gin.Context, err any) {
+		server.AbortWithProblem(c, server.NewProblem(500, server.CodeInternal, "Unexpected error while handling the request"))
+	}))
 
 	// custom go routine logger
 	router.Use(loggerMiddleware())
 
 	// authMiddleware checks for the API key in the request header
-	router.Use(authMiddleware(userColl))
+	router.Use(authMiddleware(svc.authenticator))
 
-	router.Use(gzip.Gzip(gzip.DefaultCompression))
+	// export files are served with Range support, which compression would break,
+	// and event streams must reach the client as soon as they are flushed
+	router.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{"/" + baseURL + "/export/", streamPath + "/"})))
 
-	// rateLimitMiddleware checks if the request exceeds the rate limit
-	rateLimiter := server.CreateRateLimiter(20, time.Second) // 10 requests per second
-	router.Use(rateLimitMiddleware(rateLimiter))
+	// localeMiddleware picks the response language from locale= or Accept-Language
+	router.Use(localeMiddleware())
 
-	// Graceful shutdown - stop rate limiter when server shuts down
-	defer rateLimiter.Stop()
+	// rateLimitMiddleware checks the request against the rate limit and
+	// quotas of the key's tier
+	router.Use(rateLimitMiddleware(tiers, routeCosts))
 
 	// Add rate limit headers to successful responses
 	router.Use(func(c *gin.Context) {
@@ -174,8 +501,9 @@ func main() {
 
 		// Only add headers for successful responses
 		if c.Writer.Status() >= 200 && c.Writer.Status() < 300 {
-			ipv4 := c.ClientIP()
-			remaining, _, limit := server.GetRateLimitInfo(ipv4, rateLimiter)
+			value, _ := c.Get("principal")
+			principal, _ := value.(auth.Principal)
+			remaining, _, limit := server.GetRateLimitInfo(rateLimitKey(c), tiers.Limiter(tiers.Tier(principal.Tier)))
 
 			// Add additional rate limit headers
 			c.Header("X-RateLimit-Status", "active")
@@ -185,169 +513,414 @@ func main() {
 	})
 
 	// cache store
-	store := persistence.NewInMemoryStore(time.Second)
+	cacheStore := persistence.NewInMemoryStore(time.Second)
+
+	// Package reads need the search:read scope; the other scopes are checked
+	// where their endpoints are registered
+	readScope := scopeMiddleware(apikey.ScopeSearchRead)
+
+	// validate query parameters against the OpenAPI document
+	router.Use(spec.Validate("/" + baseURL))
+
+	router.GET(baseURL+"/openapi.json", openapi.Handler())
 
 	router.GET(baseURL+"/ping", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "pong",
-		})
+		server.JSON(c, 200, gin.H{"message": "pong"}, nil)
 	})
 
 	// Rate limit status endpoint
 	router.GET(baseURL+"/rate-limit", func(c *gin.Context) {
 		ipv4 := c.ClientIP()
-		remaining, reset, limit := server.GetRateLimitInfo(ipv4, rateLimiter)
-
-		c.JSON(200, gin.H{
-			"ip": ipv4,
-			"rate_limit": gin.H{
-				"limit":      limit,
-				"remaining":  remaining,
-				"reset":      reset.Unix(),
-				"reset_time": reset.Format(time.RFC3339),
+		principal, _ := c.MustGet("principal").(auth.Principal)
+		tier := tiers.Tier(principal.Tier)
+		remaining, reset, limit := server.GetRateLimitInfo(rateLimitKey(c), tiers.Limiter(tier))
+		usage := tiers.QuotaUsage(principal.KeyID)
+
+		quota := gin.H{}
+		if tier.Daily > 0 {
+			quota["daily"] = gin.H{"limit": tier.Daily, "used": usage.Daily, "reset": usage.DailyReset.Unix()}
+		}
+		if tier.Monthly > 0 {
+			quota["monthly"] = gin.H{"limit": tier.Monthly, "used": usage.Monthly, "reset": usage.MonthlyReset.Unix()}
+		}
+		server.JSON(c, 200, gin.H{
+			"ip":   ipv4,
+			"tier": tier.Name,
+			"rateLimit": gin.H{
+				"limit":     limit,
+				"remaining": remaining,
+				"reset":     reset.Unix(),
+				"resetTime": reset.Format(time.RFC3339),
 			},
-			"stats": rateLimiter.GetStats(),
-		})
+			"quota":            quota,
+			"upgradeSuggested": tier.SuggestUpgrade(usage, false),
+		}, gin.H{"stats": tiers.GetStats()})
 	})
 
-	router.GET(baseURL+"/search", cache.CachePageAtomic(store, time.Minute*10, func(c *gin.Context) {
+	router.GET(baseURL+"/search", readScope, streamMiddleware(st, "q", store.SearchFilter), cachePage(cacheStore, time.Minute*10, func(c *gin.Context) {
 		query := c.Query("q")
 		query = strings.TrimSpace(query)
 		// logs.PrintDebug("Search query:", query)
 		if query == "" {
-			c.JSON(400, gin.H{"error": "Query parameter 'q' is required"})
+			server.MissingParam(c, "q")
 			return
 		}
 
-		escapedQuery := regexp.QuoteMeta(query)
-		// Use regex to search in multiple fields
-		// This will search for the query in PackageName, Publisher, ShortDescription, and Author fields
-		filter := gin.H{
-			"$or": []gin.H{
-				{"PackageName": gin.H{"$regex": escapedQuery, "$options": "i"}},
-				{"Publisher": gin.H{"$regex": escapedQuery, "$options": "i"}},
-				{"ShortDescription": gin.H{"$regex": escapedQuery, "$options": "i"}},
-				{"Author": gin.H{"$regex": escapedQuery, "$options": "i"}},
-			},
-		}
-		cursor, err := pkgColl.Find(context.TODO(), filter)
+		// Searches PackageName, Publisher, ShortDescription and Author
+		results, err := st.Search(context.TODO(), query)
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to search packages"})
+			server.StoreUnavailable(c, "Failed to search packages")
 			return
 		}
-		defer cursor.Close(context.TODO())
 
-		var results []gin.H
-		for cursor.Next(context.TODO()) {
-			var pkg gin.H
-			if err := cursor.Decode(&pkg); err != nil {
-				c.JSON(500, gin.H{"error": "Failed to decode package"})
-				return
-			}
-			results = append(results, pkg)
-		}
-
-		c.JSON(200, gin.H{"results": results})
+		localizeResults(c, results)
+		server.JSON(c, 200, results, gin.H{"count": len(results)})
 	}))
 
-	router.GET(baseURL+"/packagename", cache.CachePageAtomic(store, time.Minute*10, func(c *gin.Context) {
+	router.GET(baseURL+"/packagename", readScope, streamMiddleware(st, "name", store.NameFilter), cachePage(cacheStore, time.Minute*10, func(c *gin.Context) {
 		id := c.Query("name")
 		id = strings.TrimSpace(id)
 		// logs.PrintDebug("Package name:", id)
 		if id == "" {
-			c.JSON(400, gin.H{"error": "Query parameter 'name' is required"})
+			server.MissingParam(c, "name")
 			return
 		}
 
-		escapedName := regexp.QuoteMeta(id)
-		filter := gin.H{
-			"PackageName": gin.H{"$regex": escapedName, "$options": "i"},
-		}
-		cursor, err := pkgColl.Find(context.TODO(), filter)
+		results, err := st.ByName(context.TODO(), id)
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to search packages"})
+			server.StoreUnavailable(c, "Failed to search packages")
 			return
 		}
-		defer cursor.Close(context.TODO())
 
-		var results []gin.H
-		for cursor.Next(context.TODO()) {
-			var pkg gin.H
-			if err := cursor.Decode(&pkg); err != nil {
-				c.JSON(500, gin.H{"error": "Failed to decode package"})
-				return
-			}
-			results = append(results, pkg)
-		}
-
-		c.JSON(200, gin.H{"results": results})
+		localizeResults(c, results)
+		server.JSON(c, 200, results, gin.H{"count": len(results)})
 	}))
 
-	router.GET(baseURL+"/packageidentifier", cache.CachePageAtomic(store, time.Minute*10, func(c *gin.Context) {
+	router.GET(baseURL+"/packageidentifier", readScope, streamMiddleware(st, "identifier", store.IdentifierFilter), cachePage(cacheStore, time.Minute*10, func(c *gin.Context) {
 		identifier := c.Query("identifier")
 		identifier = strings.TrimSpace(identifier)
 		// logs.PrintDebug("Package identifier:", identifier)
 		if identifier == "" {
-			c.JSON(400, gin.H{"error": "Query parameter 'identifier' is required"})
+			server.MissingParam(c, "identifier")
 			return
 		}
 
-		escapedIdentifier := regexp.QuoteMeta(identifier)
-		filter := gin.H{
-			"PackageIdentifier": gin.H{"$regex": escapedIdentifier, "$options": "i"},
-		}
-		cursor, err := pkgColl.Find(context.TODO(), filter)
+		results, err := st.ByIdentifier(context.TODO(), identifier)
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to search packages"})
+			server.StoreUnavailable(c, "Failed to search packages")
 			return
 		}
-		defer cursor.Close(context.TODO())
 
-		var results []gin.H
-		for cursor.Next(context.TODO()) {
-			var pkg gin.H
-			if err := cursor.Decode(&pkg); err != nil {
-				c.JSON(500, gin.H{"error": "Failed to decode package"})
-				return
-			}
-			results = append(results, pkg)
-		}
-
-		c.JSON(200, gin.H{"results": results})
+		localizeResults(c, results)
+		server.JSON(c, 200, results, gin.H{"count": len(results)})
 	}))
 
-	router.GET(baseURL+"/publisher", cache.CachePageAtomic(store, time.Minute*10, func(c *gin.Context) {
+	router.GET(baseURL+"/publisher", readScope, streamMiddleware(st, "publisher", store.PublisherFilter), cachePage(cacheStore, time.Minute*10, func(c *gin.Context) {
 		name := c.Query("publisher")
 		name = strings.TrimSpace(name)
 		// logs.PrintDebug("Package publisher:", name)
 		if name == "" {
-			c.JSON(400, gin.H{"error": "Query parameter 'publisher' is required"})
+			server.MissingParam(c, "publisher")
 			return
 		}
 
-		escapedPublisher := regexp.QuoteMeta(name)
-		filter := gin.H{
-			"Publisher": gin.H{"$regex": escapedPublisher, "$options": "i"},
+		results, err := st.ByPublisher(context.TODO(), name)
+		if err != nil {
+			server.StoreUnavailable(c, "Failed to search packages")
+			return
 		}
-		cursor, err := pkgColl.Find(context.TODO(), filter)
+
+		localizeResults(c, results)
+		server.JSON(c, 200, results, gin.H{"count": len(results)})
+	}))
+
+	// Publisher directory, spelling variants merged under a normalized key
+	router.GET(baseURL+"/publishers", readScope, cachePage(cacheStore, time.Minute*10, func(c *gin.Context) {
+		page, perPage, skip := parsePagination(c)
+
+		publishers, total, err := st.ListPublishers(context.TODO(), skip, perPage)
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to search packages"})
+			server.StoreUnavailable(c, "Failed to list publishers")
 			return
 		}
-		defer cursor.Close(context.TODO())
 
-		var results []gin.H
-		for cursor.Next(context.TODO()) {
-			var pkg gin.H
-			if err := cursor.Decode(&pkg); err != nil {
-				c.JSON(500, gin.H{"error": "Failed to decode package"})
-				return
-			}
-			results = append(results, pkg)
+		server.JSON(c, 200, publishers, gin.H{
+			"count":   len(publishers),
+			"page":    page,
+			"perPage": perPage,
+			"total":   total,
+		})
+	}))
+
+	router.GET(baseURL+"/publishers/:publisher/packages", readScope, cachePage(cacheStore, time.Minute*10, func(c *gin.Context) {
+		key := store.NormalizePublisher(c.Param("publisher"))
+		if key == "" {
+			server.MissingParam(c, "publisher")
+			return
 		}
+		page, perPage, skip := parsePagination(c)
 
-		c.JSON(200, gin.H{"results": results})
+		results, total, err := st.PackagesByPublisher(context.TODO(), key, skip, perPage)
+		if errors.Is(err, store.ErrNotFound) {
+			server.NotFound(c, "Publisher not found")
+			return
+		}
+		if err != nil {
+			server.StoreUnavailable(c, "Failed to search packages")
+			return
+		}
+
+		localizeResults(c, results)
+		server.JSON(c, 200, results, gin.H{
+			"publisher": key,
+			"count":     len(results),
+			"page":      page,
+			"perPage":   perPage,
+			"total":     total,
+		})
 	}))
 
+	// GraphQL over the same store, with depth and complexity limits
+	schema, err := gql.NewSchema(st)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GraphQL schema: %w", err)
+	}
+	// Queries are charged by their complexity once it is known
+	chargeQuery := func(c *gin.Context, cost int) bool { return chargeRequest(c, tiers, cost) }
+	router.GET(baseURL+"/graphql", readScope, gql.Handler(schema, chargeQuery))
+	router.POST(baseURL+"/graphql", readScope, gql.Handler(schema, chargeQuery))
+
+	// WinGet.RestSource contract, for `winget source add -t Microsoft.Rest`
+	restsource.Register(router.Group(baseURL+"/winget", readScope), st, svc.sourceIdentifier)
+
+	// Change feed written by the ingest job, for mirrors applying deltas
+	router.GET(baseURL+"/changes", readScope, func(c *gin.Context) {
+		_, limit, _ := parsePagination(c)
+
+		after, err := parseSince(c.Request.Context(), st, c.Query("since"))
+		if errors.Is(err, errInvalidSince) {
+			server.InvalidParam(c, "since", err.Error())
+			return
+		}
+		if err != nil {
+			server.StoreUnavailable(c, "Failed to read the change feed")
+			return
+		}
+
+		// Ask for one extra change to learn whether another page follows
+		changes, err := st.Changes(c.Request.Context(), after, limit+1)
+		if err != nil {
+			server.StoreUnavailable(c, "Failed to read the change feed")
+			return
+		}
+		hasMore := len(changes) > limit
+		if hasMore {
+			changes = changes[:limit]
+		}
+
+		next := after
+		if len(changes) > 0 {
+			next = changes[len(changes)-1].Sequence
+		}
+		server.JSON(c, 200, changes, gin.H{
+			"count":   len(changes),
+			"next":    changeToken(next),
+			"hasMore": hasMore,
+		})
+	})
+
+	// Atom/RSS feeds built from the change feed
+	feed.Register(router.Group(baseURL, readScope), st)
+
+	// Field-level comparison of two versions of a package, for reviewers
+	diff.Register(router.Group(baseURL, readScope), st)
+
+	// Webhook subscriptions, delivered from the change feed in the background
+	svc.webhooks.Register(router.Group(baseURL+"/webhooks", scopeMiddleware(apikey.ScopeWebhooksWrite)))
+
+	// Key management for operators, with keys holding the admin scope
+	admin.New(svc.keys, svc.authenticator).Register(router.Group(baseURL+"/admin", scopeMiddleware(apikey.ScopeAdmin)))
+
+	// Live change stream for dashboards, following the same change feed
+	svc.broker.Register(router.Group(streamPath, readScope))
+
+	// Catalog snapshots for mirrors, regenerated in the background
+	svc.exporter.Register(router.Group(baseURL+"/export", scopeMiddleware(apikey.ScopeExportRead)))
+
+	router.NoRoute(func(c *gin.Context) {
+		server.NotFound(c, "No endpoint matches "+c.Request.URL.Path)
+	})
+
+	return router, nil
+}
+
+func main() {
+	// Load environment variables from .env file
+	err := godotenv.Load()
+	if err != nil {
+		logs.PrintWarning("No .env file found, using default environment variables")
+	}
+	// Check if MONGODB_URL is set
+	MONGODB_URL := os.Getenv("MONGODB_URL")
+	if MONGODB_URL == "" {
+		logs.PrintWarning("MONGODB_URL not set in .env, using default value")
+		os.Exit(1)
+	}
+	// Connect to MongoDB
+	client, err := mongo.Connect(options.Client().
+		ApplyURI(MONGODB_URL))
+	if err != nil {
+		panic(err)
+	}
+	// close the connection when done
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		} else {
+			logs.PrintInfo("MongoDB connection closed successfully")
+		}
+	}()
+
+	// user collection, mongo connection pool
+	userColl := client.Database("winget").Collection("users")
+
+	// API key checks shared by the HTTP and gRPC servers. Keys are stored as
+	// hashes peppered with API_KEY_PEPPER, which must match the CLI's.
+	hasher, err := apikey.NewHasher(os.Getenv("API_KEY_PEPPER"))
+	if err != nil {
+		logs.PrintError("API_KEY_PEPPER: %v", err)
+		os.Exit(1)
+	}
+	keys := apikey.NewStore(userColl, hasher)
+	authenticator := auth.New(keys, auth.DefaultCacheConfig)
+	indexCtx, cancelIndex := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := keys.EnsureIndexes(indexCtx); err != nil {
+		logs.PrintWarning("Failed to create the API key index: %v", err)
+	}
+	cancelIndex()
+
+	// shared query layer over the package collection
+	st := store.New(client.Database("winget"))
+
+	// OpenAPI document, used for request validation and served to clients
+	spec, err := openapi.Load()
+	if err != nil {
+		logs.PrintError("Invalid OpenAPI document: %v", err)
+		os.Exit(1)
+	}
+
+	// Rate limits and quotas of each tier, using the RATE_LIMIT_STRATEGY
+	// algorithm
+	strategy, err := server.ParseStrategy(os.Getenv("RATE_LIMIT_STRATEGY"))
+	if err != nil {
+		logs.PrintError("RATE_LIMIT_STRATEGY: %v", err)
+		os.Exit(1)
+	}
+	// Limits are kept in memory unless RATE_LIMIT_REDIS_URL points every
+	// instance at the same Redis
+	var backend server.Backend = server.MemoryBackend{}
+	if redisURL := os.Getenv("RATE_LIMIT_REDIS_URL"); redisURL != "" {
+		pool := &redis.Pool{
+			MaxIdle:     16,
+			IdleTimeout: 4 * time.Minute,
+			Dial: func() (redis.Conn, error) {
+				return redis.DialURL(redisURL)
+			},
+		}
+		defer pool.Close()
+		conn := pool.Get()
+		if _, err := conn.Do("PING"); err != nil {
+			logs.PrintWarning("Rate limit Redis unreachable, requests are allowed until it is: %v", err)
+		} else {
+			logs.PrintInfo("Rate limits are kept in Redis")
+		}
+		conn.Close()
+		backend = server.NewRedisBackend(pool, "winget-pkg:ratelimit:")
+	}
+	tiers := server.NewTierLimiter(server.DefaultTiers, backend, strategy, nil)
+
+	// Graceful shutdown - stop rate limiter when server shuts down
+	defer tiers.Stop()
+
+	// Source identifier reported to winget clients
+	sourceIdentifier := os.Getenv("WINGET_SOURCE_IDENTIFIER")
+	if sourceIdentifier == "" {
+		sourceIdentifier = "winget-pkg-api"
+	}
+
+	// Webhooks are delivered from the change feed in the background
+	webhookConfig := webhook.DefaultConfig
+	webhookConfig.AllowPrivateTargets = os.Getenv("WEBHOOK_ALLOW_PRIVATE_TARGETS") == "true"
+	if d, err := time.ParseDuration(os.Getenv("WEBHOOK_RETRY_BASE")); err == nil && d > 0 {
+		webhookConfig.RetryBase = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("WEBHOOK_POLL_INTERVAL")); err == nil && d > 0 {
+		webhookConfig.PollInterval = d
+	}
+	webhooks := webhook.New(client.Database("winget"), st, webhookConfig)
+	webhooks.Start(context.Background())
+
+	// The change stream follows the same change feed
+	broker := events.New(st, events.DefaultConfig)
+	broker.Start(context.Background())
+
+	// Catalog snapshots are regenerated in the background
+	exportDir := os.Getenv("EXPORT_DIR")
+	if exportDir == "" {
+		exportDir = filepath.Join(os.TempDir(), "winget-pkg-export")
+	}
+	exportInterval, err := time.ParseDuration(os.Getenv("EXPORT_INTERVAL"))
+	if err != nil || exportInterval <= 0 {
+		exportInterval = 6 * time.Hour
+	}
+	exporter, err := export.New(st, exportDir)
+	if err != nil {
+		logs.PrintError("Failed to prepare export directory %s: %v", exportDir, err)
+		os.Exit(1)
+	}
+	exporter.Start(context.Background(), exportInterval)
+
+	router, err := newRouter(spec, services{
+		store:            st,
+		keys:             keys,
+		authenticator:    authenticator,
+		tiers:            tiers,
+		webhooks:         webhooks,
+		broker:           broker,
+		exporter:         exporter,
+		sourceIdentifier: sourceIdentifier,
+	})
+	if err != nil {
+		logs.PrintError("%v", err)
+		os.Exit(1)
+	}
+
+	// Refuse to start when the OpenAPI document and the registered routes diverge
+	if problems := spec.CheckRoutes(router.Routes(), routeQueries, "/"+baseURL); len(problems) > 0 {
+		for _, problem := range problems {
+			logs.PrintError("OpenAPI: %s", problem)
+		}
+		os.Exit(1)
+	}
+
+	// gRPC server alongside the Gin router, sharing auth and rate limits
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9090"
+	}
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		logs.PrintError("Failed to listen on gRPC port %s: %v", grpcPort, err)
+		os.Exit(1)
+	}
+	grpcServer := rpc.NewServer(st, authenticator, tiers, routeCosts)
+	defer grpcServer.GracefulStop()
+	go func() {
+		logs.PrintInfo("gRPC server listening on :%s", grpcPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			logs.PrintError("gRPC server stopped: %v", err)
+		}
+	}()
+
 	router.Run() // listen and serve on 0.0.0.0:8080
 }
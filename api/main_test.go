@@ -0,0 +1,166 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/cache/persistence"
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/auth"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+)
+
+func TestRedactURI(t *testing.T) {
+	tests := []struct {
+		uri, want string
+	}{
+		{"/api/v1/ping", "/api/v1/ping"},
+		{"/api/v1/search?q=git", "/api/v1/search?q=git"},
+		{"/api/v1/feeds/updates?api_key=wpk_secret", "/api/v1/feeds/updates?api_key=REDACTED"},
+		{"/api/v1/feeds/updates?format=rss&api_key=wpk_secret&publisher=Mozilla", "/api/v1/feeds/updates?format=rss&api_key=REDACTED&publisher=Mozilla"},
+		{"/api/v1/stream/changes?last_event_id=7&api%5Fkey=wpk_secret", "/api/v1/stream/changes?last_event_id=7&api_key=REDACTED"},
+		{"/api/v1/stream/changes?api_key=a&api_key=b", "/api/v1/stream/changes?api_key=REDACTED&api_key=REDACTED"},
+		{"/api/v1/search?q=api_key", "/api/v1/search?q=api_key"},
+	}
+	for _, tt := range tests {
+		if got := redactURI(tt.uri); got != tt.want {
+			t.Errorf("redactURI(%q) = %q, want %q", tt.uri, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitMiddlewareCharges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tiers := server.NewTierLimiter([]server.Tier{
+		{Name: "test", Limit: 10, Window: time.Minute, Daily: 100},
+	}, nil, server.FixedWindow, nil)
+	t.Cleanup(tiers.Stop)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("principal", auth.Principal{KeyID: "key_test", Tier: "test"})
+		c.Set("rateLimitKey", server.CallerKey("key_test", ""))
+	})
+	router.Use(rateLimitMiddleware(tiers, server.Costs{"/free": server.FreeCost, "/export": server.ExportCost}))
+	for _, path := range []string{"/free", "/export"} {
+		router.GET(path, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// Free routes use neither the limit nor the quotas
+	if w := get("/free"); w.Code != http.StatusNoContent || w.Header().Get("X-RateLimit-Cost") != "0" {
+		t.Fatalf("free route: status %d, cost %q", w.Code, w.Header().Get("X-RateLimit-Cost"))
+	}
+	if usage := tiers.QuotaUsage("key_test"); usage.Daily != 0 {
+		t.Errorf("free route counted %d requests against the daily quota", usage.Daily)
+	}
+
+	// A route costing more than the limit reports what it took
+	w := get("/export")
+	if w.Code != http.StatusNoContent || w.Header().Get("X-RateLimit-Cost") != "10" {
+		t.Fatalf("export: status %d, cost %q, want 204 costing the limit of 10", w.Code, w.Header().Get("X-RateLimit-Cost"))
+	}
+	if usage := tiers.QuotaUsage("key_test"); usage.Daily != 1 {
+		t.Errorf("export counted %d requests against the daily quota, want 1", usage.Daily)
+	}
+	if w := get("/export"); w.Code != http.StatusTooManyRequests || w.Header().Get("X-RateLimit-Cost") != "10" {
+		t.Errorf("second export: status %d, cost %q, want 429 costing 10", w.Code, w.Header().Get("X-RateLimit-Cost"))
+	}
+}
+
+func TestCachePageKeepsCallerHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Header("X-RateLimit-Remaining", c.GetHeader("X-Caller"))
+	})
+	calls := 0
+	router.GET("/page", cachePage(persistence.NewInMemoryStore(time.Minute), time.Minute, func(c *gin.Context) {
+		calls++
+		c.Header("X-Page", "cached")
+		c.String(http.StatusOK, "page")
+	}), func(c *gin.Context) {
+		c.Header("X-Quota-Daily-Remaining", c.GetHeader("X-Caller"))
+	})
+
+	for _, caller := range []string{"first", "second"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/page", nil)
+		req.Header.Set("X-Caller", caller)
+		router.ServeHTTP(w, req)
+
+		if w.Body.String() != "page" || w.Header().Get("X-Page") != "cached" {
+			t.Fatalf("%s caller got %q with X-Page %q", caller, w.Body.String(), w.Header().Get("X-Page"))
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != caller {
+			t.Errorf("%s caller got X-RateLimit-Remaining %q", caller, got)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want the second page from the cache", calls)
+	}
+}
+
+func TestLocaleMiddlewareKeepsVary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Writer.Header().Add("Vary", "Accept-Encoding") }, localeMiddleware())
+	router.GET("/page", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/page", nil))
+	if got := w.Header().Values("Vary"); !slices.Contains(got, "Accept-Encoding") || !slices.Contains(got, "Accept-Language") {
+		t.Errorf("Vary = %q, want Accept-Encoding and Accept-Language", got)
+	}
+}
+
+func TestStreamMiddlewareKeepsVary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Writer.Header().Add("Vary", "Accept-Encoding") }, localeMiddleware())
+	// Without a query the middleware answers before the store is used
+	router.GET("/search", streamMiddleware(nil, "q", nil))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/search", nil)
+	req.Header.Set("Accept", server.MIMENDJSON)
+	router.ServeHTTP(w, req)
+	want := []string{"Accept-Encoding", "Accept-Language", "Accept"}
+	if got := w.Header().Values("Vary"); !slices.Equal(got, want) {
+		t.Errorf("Vary = %q, want %q", got, want)
+	}
+}
+
+func TestAuthMiddlewareQueryKeyOnlyForFeedsAndStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Malformed keys are rejected before the key store is asked
+	router.Use(authMiddleware(auth.New(nil, auth.CacheConfig{})))
+
+	tests := []struct {
+		path string
+		code string
+	}{
+		{streamPath + "/changes?api_key=junk", server.CodeInvalidKey},
+		{"/" + baseURL + "/feeds/updates?api_key=junk", server.CodeInvalidKey},
+		// A stream segment elsewhere does not make a route a stream
+		{"/" + baseURL + "/publishers/stream/packages?api_key=junk", server.CodeMissingKey},
+		{"/" + baseURL + "/search?q=git&api_key=junk", server.CodeMissingKey},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), `"code":"`+tt.code+`"`) {
+			t.Errorf("GET %s = %d %s, want 401 %s", tt.path, w.Code, w.Body.String(), tt.code)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamBijoyKar/winget-pkg/api/apikey"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/auth"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/events"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/export"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/openapi"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/server"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/store"
+	"github.com/iamBijoyKar/winget-pkg/api/internal/webhook"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// newTestRouter builds the router on a client that never connects, as no
+// request is served
+func newTestRouter(t *testing.T) (*gin.Engine, *openapi.Spec) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Disconnect(t.Context()) })
+	db := client.Database("winget")
+
+	hasher, err := apikey.NewHasher("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := apikey.NewStore(db.Collection("users"), hasher)
+	st := store.New(db)
+	tiers := server.NewTierLimiter(server.DefaultTiers, nil, server.FixedWindow, nil)
+	t.Cleanup(tiers.Stop)
+	exporter, err := export.New(st, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := newRouter(spec, services{
+		store:            st,
+		keys:             keys,
+		authenticator:    auth.New(keys, auth.DefaultCacheConfig),
+		tiers:            tiers,
+		webhooks:         webhook.New(db, st, webhook.DefaultConfig),
+		broker:           events.New(st, events.DefaultConfig),
+		exporter:         exporter,
+		sourceIdentifier: "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return router, spec
+}
+
+func TestRoutesMatchSpec(t *testing.T) {
+	router, spec := newTestRouter(t)
+	for _, problem := range spec.CheckRoutes(router.Routes(), routeQueries, "/"+baseURL) {
+		t.Error(problem)
+	}
+}
+
+func TestCheckRoutesReportsQueryParameters(t *testing.T) {
+	router, spec := newTestRouter(t)
+
+	queries := openapi.Queries{}
+	for route, params := range routeQueries {
+		queries[route] = params
+	}
+	queries["GET /"+baseURL+"/search"] = []string{"q", "locale", "fields", "limit"}
+	queries["GET /"+baseURL+"/changes"] = nil
+	// A route without an entry is reported, not taken to read nothing
+	delete(queries, "GET /"+baseURL+"/ping")
+
+	problems := spec.CheckRoutes(router.Routes(), queries, "/"+baseURL)
+	if len(problems) != 3 {
+		t.Fatalf("got %d problems, want one for each changed route: %q", len(problems), problems)
+	}
+	if want := "GET /" + baseURL + "/ping is registered but its query parameters are not listed"; !slices.Contains(problems, want) {
+		t.Errorf("problems = %q, want %q", problems, want)
+	}
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
-	"crypto/sha256"
+	"flag"
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 	"time"
 
+	"github.com/iamBijoyKar/winget-pkg/api/apikey"
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -28,33 +30,109 @@ func printInfo(msg string, args ...interface{}) {
 	fmt.Printf("Info: "+msg+"\n", args...)
 }
 
-type User struct {
-	Email  string `bson:"email" json:"email"`
-	ApiKey string `bson:"apiKey" json:"apiKey"`
-}
-
-func addUserToDatabase(client *mongo.Client, email string, salt string) error {
-	coll := client.Database("winget").Collection("users")
-	currentTime := time.Now().UTC()
+func addUserToDatabase(keys *apikey.Store, email string, opts apikey.KeyOptions) error {
 	if email == "" {
 		printError("Email cannot be empty")
 		return fmt.Errorf("email cannot be empty")
 	}
-	newUser := User{
-		Email:  email,
-		ApiKey: fmt.Sprintf("%x", sha256.Sum256([]byte(email+salt+currentTime.Format(time.RFC3339)))),
+	key, err := keys.CreateKey(context.TODO(), email, opts)
+	if err != nil {
+		return err
+	}
+	if err := apikey.Validate(key.String()); err != nil {
+		return fmt.Errorf("generated key failed validation: %w", err)
+	}
+	if opts.Name == "" {
+		opts.Name = apikey.DefaultKeyName
+	}
+	if opts.Tier == "" {
+		opts.Tier = apikey.TierFree
+	}
+	fmt.Printf("Issued key %q (id %s, tier %s) to %s\n", opts.Name, key.ID, opts.Tier, email)
+	if opts.ExpiresAt != nil {
+		fmt.Printf("The key expires at %s\n", opts.ExpiresAt.Format(time.RFC3339))
 	}
+	fmt.Printf("API key (shown only once, store it safely): %s\n", key)
+	return nil
+}
 
-	result, err := coll.InsertOne(context.TODO(), newUser)
+// expiryFlag turns an -expires-in duration into an expiry time, nil for keys
+// that do not expire
+func expiryFlag(expiresIn time.Duration) *time.Time {
+	if expiresIn <= 0 {
+		return nil
+	}
+	t := time.Now().UTC().Add(expiresIn)
+	return &t
+}
+
+// listKeys prints the keys of a user, including one in its rotation overlap
+func listKeys(keys *apikey.Store, email string) error {
+	u, err := keys.ByEmail(context.TODO(), email)
 	if err != nil {
-		printError("Failed to insert user: %v", err)
-		panic(err)
+		return err
+	}
+	for _, k := range u.KeyInfos(time.Now()) {
+		line := fmt.Sprintf("%-12s %s  %-8s %-8s %s", k.Name, k.ID, k.Status, k.Tier, strings.Join(k.Scopes, ","))
+		if k.ReplacedBy != "" {
+			line += "  replaced by " + k.ReplacedBy
+		}
+		line += "  created " + k.CreatedAt.Format(time.RFC3339)
+		if k.ExpiresAt != nil {
+			line += "  expires " + k.ExpiresAt.Format(time.RFC3339)
+		}
+		if k.RevokedAt != nil {
+			line += "  revoked " + k.RevokedAt.Format(time.RFC3339)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// rotateKey issues a new key under name while the old one keeps working for
+// overlap
+func rotateKey(keys *apikey.Store, email, name string, overlap time.Duration, expiresAt *time.Time) error {
+	key, _, err := keys.Rotate(context.TODO(), email, name, overlap, expiresAt)
+	if err != nil {
+		return err
+	}
+	if overlap > 0 {
+		printInfo("The previous key keeps working for %s", overlap)
 	}
-	fmt.Printf("Inserted user with ID: %v\n", result)
+	fmt.Printf("API key (shown only once, store it safely): %s\n", key)
 	return nil
 }
 
+const usage = `Usage:
+  cli                                   register a user interactively
+  cli register [-tier free] [-expires-in 720h] [email]
+                                        register a user, optionally with an expiring key
+  cli create-key -name <name> [-scopes search:read,...] [-tier free] [-expires-in 720h] <email>
+                                        issue another named key to a user
+  cli list-keys <email>                 list the keys of a user
+  cli set-tier [-name default] -tier <tier> <email>
+                                        move a key to another rate limit tier
+  cli revoke [-name <name>] <email>     revoke one named key, or every key of a user
+  cli rotate [-name default] [-overlap 24h] [-expires-in 720h] <email>
+                                        issue a new key, keeping the old one for the overlap
+  cli migrate-keys                      hash plaintext keys left by older versions
+  cli check-key <key>                   check a key's format and checksum offline
+
+Scopes: search:read, export:read, webhooks:write, admin. Keys get every scope
+but admin unless -scopes is given.
+Tiers: free, team, internal. Keys are on free unless -tier is given.`
+
 func main() {
+	// check-key validates a key offline, without the database
+	if len(os.Args) > 2 && os.Args[1] == "check-key" {
+		if err := apikey.Validate(os.Args[2]); err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		printInfo("The key is well-formed and its checksum matches")
+		return
+	}
+
 	// Load environment variables from .env file
 	err := godotenv.Load()
 	if err != nil {
@@ -66,10 +144,10 @@ func main() {
 		printWarning("MONGODB_URL not set in .env, using default value")
 		os.Exit(1)
 	}
-	// Check if SALT is set
-	SALT := os.Getenv("SALT")
-	if SALT == "" {
-		printWarning("SALT not set in .env, using default value")
+	// Check if API_KEY_PEPPER is set; the API must use the same value
+	hasher, err := apikey.NewHasher(os.Getenv("API_KEY_PEPPER"))
+	if err != nil {
+		printError("API_KEY_PEPPER: %v", err)
 		os.Exit(1)
 	}
 	// Connect to MongoDB
@@ -87,9 +165,89 @@ func main() {
 		}
 	}()
 
-	fmt.Println("Enter your email address to register a new user:")
-	var email string
-	fmt.Scanln(&email)
+	keys := apikey.NewStore(client.Database("winget").Collection("users"), hasher)
+
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	expiresIn := flags.Duration("expires-in", 0, "lifetime of the new key, e.g. 720h; 0 never expires")
+	overlap := flags.Duration("overlap", apikey.DefaultOverlap, "how long the replaced key keeps working")
+	name := flags.String("name", "", "name of the key, e.g. the service using it")
+	scopeList := flags.String("scopes", "", "comma separated scopes of a new key")
+	tierName := flags.String("tier", "", "rate limit tier of the key: free, team or internal")
+	if command != "" {
+		flags.Parse(os.Args[2:])
+	}
+	email := flags.Arg(0)
+	if email == "" && (command == "create-key" || command == "list-keys" || command == "revoke" || command == "rotate" || command == "set-tier") ||
+		*name == "" && command == "create-key" || *tierName == "" && command == "set-tier" {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	scopes, err := apikey.ParseScopes(*scopeList)
+	if err != nil {
+		printError("%v", err)
+		os.Exit(2)
+	}
+	tier, err := apikey.ParseTier(*tierName)
+	if err != nil {
+		printError("%v", err)
+		os.Exit(2)
+	}
+
+	switch command {
+	case "", "register", "create-key":
+	case "migrate-keys":
+		migrated, err := keys.MigrateLegacyKeys(context.TODO())
+		if err != nil {
+			printError("Failed to migrate API keys: %v", err)
+			return
+		}
+		printInfo("Migrated %d users with plaintext keys", migrated)
+		return
+	case "list-keys":
+		if err := listKeys(keys, email); err != nil {
+			printError("Failed to list keys of %q: %v", email, err)
+		}
+		return
+	case "revoke":
+		if _, err := keys.Revoke(context.TODO(), email, *name); err != nil {
+			printError("Failed to revoke keys of %q: %v", email, err)
+			return
+		}
+		if *name == "" {
+			printInfo("Revoked every key of %s; running API servers stop accepting them within a minute", email)
+		} else {
+			printInfo("Revoked key %q of %s; running API servers stop accepting it within a minute", *name, email)
+		}
+		return
+	case "set-tier":
+		keyName := *name
+		if keyName == "" {
+			keyName = apikey.DefaultKeyName
+		}
+		if _, err := keys.SetTier(context.TODO(), email, keyName, tier); err != nil {
+			printError("Failed to set the tier of %q: %v", email, err)
+			return
+		}
+		printInfo("Moved key %q of %s to the %s tier; running API servers pick it up within a minute", keyName, email, tier)
+		return
+	case "rotate":
+		if err := rotateKey(keys, email, *name, *overlap, expiryFlag(*expiresIn)); err != nil {
+			printError("Failed to rotate the key of %q: %v", email, err)
+		}
+		return
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if email == "" {
+		fmt.Println("Enter your email address to register a new user:")
+		fmt.Scanln(&email)
+	}
 
 	// Validate email format
 	emailRegex := `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
@@ -98,7 +256,8 @@ func main() {
 		return
 	}
 
-	if err := addUserToDatabase(client, email, SALT); err != nil {
+	opts := apikey.KeyOptions{Name: *name, Scopes: scopes, Tier: tier, ExpiresAt: expiryFlag(*expiresIn)}
+	if err := addUserToDatabase(keys, email, opts); err != nil {
 		printError("Failed to add user to database: %v", err)
 		panic(err)
 	}